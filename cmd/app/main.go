@@ -4,6 +4,7 @@ import (
 	wrapper "github.com/Arlandaren/pgxWrappy/pkg/postgres"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"net/http"
 	"service/internal/application"
 	"service/internal/infrastructure/config"
 	"service/internal/infrastructure/logger"
@@ -30,7 +31,15 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to initialize Minio: %v", err)
 	}
-	app := application.NewApp(db, rdb, s3, gin.Default(), cfg)
+	r := gin.New()
+	// http.ErrAbortHandler должен дойти до net/http: так обрывается уже начатый потоковый ответ
+	r.Use(gin.Logger(), gin.CustomRecovery(func(ctx *gin.Context, err any) {
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	app := application.NewApp(db, rdb, s3, r, cfg)
 
 	app.Run()
 }
@@ -16,16 +16,18 @@ import (
 
 type App struct {
 	Controller *Controller
+	Service    *Service
 	wg         *sync.WaitGroup
 	cfg        *config.Config
 }
 
 func NewApp(db *postgres.Wrapper, rdb *redis.RDB, s3 *minio.Minio, r *gin.Engine, cfg *config.Config) *App {
-	repo := NewRepository(db, rdb, s3)
-	svc := NewService(repo)
+	repo := NewRepository(db, rdb, s3, cfg)
+	svc := NewService(repo, cfg)
 	controller := NewController(svc, r)
 	return &App{
 		Controller: controller,
+		Service:    svc,
 		wg:         &sync.WaitGroup{},
 		cfg:        cfg,
 	}
@@ -44,6 +46,57 @@ func (app *App) Run() {
 		app.Controller.Run(app.cfg.Address.Http, ctx)
 	}()
 
+	// Keep validation rules in sync with the config file and the database
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.Service.Validation.Watch(ctx)
+	}()
+
+	// Run the scheduled exports
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.Service.Exports.Watch(ctx)
+	}()
+
+	// Check the saved searches on changes made outside imports and retry the alert webhooks
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.Service.Alerts.Watch(ctx)
+	}()
+
+	// Recompute the data quality gauges after imports and rollbacks
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.Service.Person.WatchQuality(ctx)
+	}()
+
+	// Give the records imported before the name parts and search keys existed their parts and keys
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		n, err := app.Service.Person.ParseNames(ctx)
+		if err != nil {
+			log.Errorf("Failed to parse names: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Parsed names of %d persons", n)
+		}
+
+		n, err = app.Service.Person.IndexNames(ctx)
+		if err != nil {
+			log.Errorf("Failed to index names: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Indexed names of %d persons", n)
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
@@ -6,28 +6,52 @@ import (
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"service/internal/domains/alerts"
 	"service/internal/domains/api"
+	"service/internal/domains/batch"
+	"service/internal/domains/exports"
 	"service/internal/domains/person"
+	"service/internal/domains/resolution"
+	"service/internal/domains/survivorship"
+	"service/internal/domains/validation"
 	"time"
 )
 
 type Controller struct {
-	person *person.Controller
-	api    *api.Controller
-	Router *gin.Engine
+	person       *person.Controller
+	api          *api.Controller
+	validation   *validation.Controller
+	resolution   *resolution.Controller
+	batch        *batch.Controller
+	survivorship *survivorship.Controller
+	exports      *exports.Controller
+	alerts       *alerts.Controller
+	Router       *gin.Engine
 }
 
 func NewController(svc *Service, r *gin.Engine) *Controller {
 	return &Controller{
-		person: person.NewController(svc.Person),
-		api:    api.NewController(svc.Api),
-		Router: r,
+		person:       person.NewController(svc.Person),
+		api:          api.NewController(svc.Api),
+		validation:   validation.NewController(svc.Validation),
+		resolution:   resolution.NewController(svc.Resolution),
+		batch:        batch.NewController(svc.Batch),
+		survivorship: survivorship.NewController(svc.Survivorship),
+		exports:      exports.NewController(svc.Exports),
+		alerts:       alerts.NewController(svc.Alerts),
+		Router:       r,
 	}
 }
 
 func (c *Controller) InitRouter() {
 	c.api.Endpoints(c.Router)
 	c.person.Endpoints(c.Router)
+	c.validation.Endpoints(c.Router)
+	c.resolution.Endpoints(c.Router)
+	c.batch.Endpoints(c.Router)
+	c.survivorship.Endpoints(c.Router)
+	c.exports.Endpoints(c.Router)
+	c.alerts.Endpoints(c.Router)
 }
 
 func (c *Controller) Run(addr string, ctx context.Context) {
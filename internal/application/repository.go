@@ -2,20 +2,39 @@ package application
 
 import (
 	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"service/internal/domains/alerts"
 	"service/internal/domains/api"
+	"service/internal/domains/batch"
+	"service/internal/domains/exports"
 	"service/internal/domains/person"
+	"service/internal/domains/resolution"
+	"service/internal/domains/survivorship"
+	"service/internal/domains/validation"
+	"service/internal/infrastructure/config"
 	"service/internal/infrastructure/storage/minio"
 	"service/internal/infrastructure/storage/redis"
 )
 
 type Repository struct {
-	Person *person.Repository
-	Api    *api.Repository
+	Person       *person.Repository
+	Api          *api.Repository
+	Validation   *validation.Repository
+	Resolution   *resolution.Repository
+	Batch        *batch.Repository
+	Survivorship *survivorship.Repository
+	Exports      *exports.Repository
+	Alerts       *alerts.Repository
 }
 
-func NewRepository(db *postgres.Wrapper, rdb *redis.RDB, s3 *minio.Minio) *Repository {
+func NewRepository(db *postgres.Wrapper, rdb *redis.RDB, s3 *minio.Minio, cfg *config.Config) *Repository {
 	return &Repository{
-		Person: person.NewRepository(db, rdb),
-		Api:    api.NewRepository(db, rdb, s3),
+		Person:       person.NewRepository(db, rdb, cfg.Cache),
+		Api:          api.NewRepository(db, rdb, s3),
+		Validation:   validation.NewRepository(db),
+		Resolution:   resolution.NewRepository(db),
+		Batch:        batch.NewRepository(db),
+		Survivorship: survivorship.NewRepository(db),
+		Exports:      exports.NewRepository(db, s3),
+		Alerts:       alerts.NewRepository(db),
 	}
 }
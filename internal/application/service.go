@@ -1,19 +1,44 @@
 package application
 
 import (
+	"service/internal/domains/alerts"
+	"service/internal/domains/batch"
+	"service/internal/domains/exports"
 	"service/internal/domains/person"
+	"service/internal/domains/person/rules"
+	"service/internal/domains/resolution"
+	"service/internal/domains/survivorship"
+	"service/internal/domains/validation"
+	"service/internal/infrastructure/config"
 
 	"service/internal/domains/api"
 )
 
 type Service struct {
-	Person *person.Service
-	Api    *api.Service
+	Person       *person.Service
+	Api          *api.Service
+	Validation   *validation.Service
+	Resolution   *resolution.Service
+	Batch        *batch.Service
+	Survivorship *survivorship.Service
+	Exports      *exports.Service
+	Alerts       *alerts.Service
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo *Repository, cfg *config.Config) *Service {
+	engine := rules.NewEngine()
+	persons := person.NewService(repo.Person, engine, cfg.Paging)
+	alertsSvc := alerts.NewService(repo.Alerts, persons, cfg.Alerts)
+	// Сохраненные поиски проверяются после каждого импорта
+	persons.OnBatch(alertsSvc.AfterBatch)
 	return &Service{
-		Person: person.NewService(repo.Person),
-		Api:    api.NewService(repo.Api),
+		Person:       persons,
+		Api:          api.NewService(repo.Api),
+		Validation:   validation.NewService(repo.Validation, engine, cfg.Rules),
+		Resolution:   resolution.NewService(repo.Resolution, persons),
+		Batch:        batch.NewService(repo.Batch, persons),
+		Survivorship: survivorship.NewService(repo.Survivorship, persons),
+		Exports:      exports.NewService(repo.Exports, persons, cfg.Exports),
+		Alerts:       alertsSvc,
 	}
 }
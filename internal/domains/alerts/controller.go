@@ -0,0 +1,210 @@
+package alerts
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/internal/domains/alerts/models"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAlertLimit = 50
+	maxAlertLimit     = 500
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/saved-searches", c.ListSearches)
+	r.POST("/saved-searches", c.CreateSearch)
+	r.GET("/saved-searches/:id", c.GetSearch)
+	r.PUT("/saved-searches/:id", c.UpdateSearch)
+	r.DELETE("/saved-searches/:id", c.DeleteSearch)
+	r.POST("/saved-searches/:id/mute", c.MuteSearch)
+	r.POST("/saved-searches/:id/unmute", c.UnmuteSearch)
+
+	r.GET("/alerts", c.ListAlerts)
+	r.POST("/alerts/:id/ack", c.Acknowledge)
+	r.POST("/alerts/:id/mute", c.Mute)
+}
+
+// ListSearches returns the saved searches: ?owner=
+func (c *Controller) ListSearches(ctx *gin.Context) {
+	searches, err := c.svc.ListSearches(ctx.Request.Context(), ctx.Query("owner"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"saved_searches": searches})
+}
+
+// CreateSearch saves a search, see models.SavedSearchRequest. It is checked on the records
+// created or changed from now on; each match becomes an alert sent to the webhook
+func (c *Controller) CreateSearch(ctx *gin.Context) {
+	var request models.SavedSearchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := c.svc.SaveSearch(ctx.Request.Context(), 0, request)
+	respond(ctx, http.StatusCreated, gin.H{"saved_search": search}, err)
+}
+
+// GetSearch returns a saved search
+func (c *Controller) GetSearch(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	search, err := c.svc.GetSearch(ctx.Request.Context(), id)
+	respond(ctx, http.StatusOK, gin.H{"saved_search": search}, err)
+}
+
+// UpdateSearch replaces a saved search; an empty webhook_secret keeps the current one
+func (c *Controller) UpdateSearch(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	var request models.SavedSearchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := c.svc.SaveSearch(ctx.Request.Context(), id, request)
+	respond(ctx, http.StatusOK, gin.H{"saved_search": search}, err)
+}
+
+// DeleteSearch deletes a saved search with its alerts
+func (c *Controller) DeleteSearch(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	err := c.svc.DeleteSearch(ctx.Request.Context(), id)
+	respond(ctx, http.StatusOK, gin.H{"deleted": id}, err)
+}
+
+// MuteSearch stops the webhooks of a search: {"until": "2026-11-01T00:00:00Z"}, without a body until unmuted
+func (c *Controller) MuteSearch(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	var request models.MuteRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var until *time.Time
+	if request.Until != "" {
+		t, err := time.Parse(time.RFC3339, request.Until)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный until: " + request.Until})
+			return
+		}
+		until = &t
+	}
+
+	search, err := c.svc.MuteSearch(ctx.Request.Context(), id, until)
+	respond(ctx, http.StatusOK, gin.H{"saved_search": search}, err)
+}
+
+// UnmuteSearch resumes the webhooks of a search
+func (c *Controller) UnmuteSearch(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	search, err := c.svc.UnmuteSearch(ctx.Request.Context(), id)
+	respond(ctx, http.StatusOK, gin.H{"saved_search": search}, err)
+}
+
+// ListAlerts returns the alerts, the newest first: ?owner=&status=new|acknowledged|muted&saved_search_id=&limit=&offset=
+func (c *Controller) ListAlerts(ctx *gin.Context) {
+	filter := models.AlertFilter{Owner: ctx.Query("owner"), Status: ctx.Query("status")}
+	var err error
+	filter.Limit, err = strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultAlertLimit)))
+	if err != nil || filter.Limit <= 0 || filter.Limit > maxAlertLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+		return
+	}
+	filter.Offset, err = strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || filter.Offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный offset"})
+		return
+	}
+	if value := ctx.Query("saved_search_id"); value != "" {
+		if filter.SavedSearchId, err = strconv.Atoi(value); err != nil || filter.SavedSearchId <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный saved_search_id"})
+			return
+		}
+	}
+
+	alerts, err := c.svc.ListAlerts(ctx.Request.Context(), filter)
+	respond(ctx, http.StatusOK, gin.H{"alerts": alerts}, err)
+}
+
+// Acknowledge marks an alert as handled: {"by": "petrov"}
+func (c *Controller) Acknowledge(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	var request models.AckRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	alert, err := c.svc.Acknowledge(ctx.Request.Context(), id, request.By)
+	respond(ctx, http.StatusOK, gin.H{"alert": alert}, err)
+}
+
+// Mute hides an alert and cancels its webhook if it has not been sent
+func (c *Controller) Mute(ctx *gin.Context) {
+	id, ok := paramId(ctx)
+	if !ok {
+		return
+	}
+	alert, err := c.svc.Mute(ctx.Request.Context(), id)
+	respond(ctx, http.StatusOK, gin.H{"alert": alert}, err)
+}
+
+func paramId(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respond(ctx *gin.Context, status int, body gin.H, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(status, body)
+	}
+}
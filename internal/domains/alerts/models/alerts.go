@@ -0,0 +1,112 @@
+package models
+
+import (
+	personModels "service/internal/domains/person/models"
+	"service/internal/domains/person/query"
+)
+
+// Alert statuses set by the investigators
+const (
+	StatusNew          = "new"
+	StatusAcknowledged = "acknowledged"
+	StatusMuted        = "muted"
+)
+
+// Webhook delivery states of an alert. An alert is skipped when its search has no webhook or is muted
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+	DeliverySkipped   = "skipped"
+)
+
+// SavedSearch is a structured query checked on every new or changed record
+type SavedSearch struct {
+	Id         int         `db:"id" json:"id"`
+	Name       string      `db:"name" json:"name"`
+	Owner      string      `db:"owner" json:"owner"`
+	Query      *query.Node `db:"query" json:"query"`
+	WebhookUrl string      `db:"webhook_url" json:"webhook_url,omitempty"`
+	// HasSecret tells whether webhooks are signed; the secret itself is never returned
+	HasSecret    bool   `db:"has_secret" json:"has_secret"`
+	Enabled      bool   `db:"enabled" json:"enabled"`
+	MutedUntil   string `db:"muted_until" json:"muted_until,omitempty"`
+	CheckedUntil string `db:"checked_until" json:"checked_until"`
+	CreatedAt    string `db:"created_at" json:"created_at"`
+	UpdatedAt    string `db:"updated_at" json:"updated_at"`
+}
+
+// SavedSearchRequest creates or replaces a saved search:
+//
+//	{"name": "Иванов И. в Москве", "owner": "petrov",
+//	 "query": {"and": [{"field": "surname", "op": "equals", "value": "Иванов"},
+//	                   {"field": "region", "op": "equals", "value": "Москва"}]},
+//	 "webhook_url": "https://hooks.example.com/alerts", "webhook_secret": "..."}
+type SavedSearchRequest struct {
+	Name          string      `json:"name" binding:"required"`
+	Owner         string      `json:"owner" binding:"required"`
+	Query         *query.Node `json:"query" binding:"required"`
+	WebhookUrl    string      `json:"webhook_url"`
+	WebhookSecret string      `json:"webhook_secret"`
+	Enabled       *bool       `json:"enabled"`
+}
+
+// MuteRequest stops the webhooks of a saved search until the moment (RFC 3339) or, without it, until unmuted
+type MuteRequest struct {
+	Until string `json:"until"`
+}
+
+// Alert is a record that matched a saved search
+type Alert struct {
+	Id            int    `db:"id" json:"id"`
+	SavedSearchId int    `db:"saved_search_id" json:"saved_search_id"`
+	SearchName    string `db:"search_name" json:"search_name"`
+	Owner         string `db:"owner" json:"owner"`
+	PersonId      int    `db:"person_id" json:"person_id"`
+	ImportBatchId string `db:"import_batch_id" json:"import_batch_id,omitempty"`
+	Status        string `db:"status" json:"status"`
+	Delivery      string `db:"delivery" json:"delivery"`
+	Attempts      int    `db:"attempts" json:"attempts"`
+	LastError     string `db:"last_error" json:"last_error,omitempty"`
+
+	DeliveredAt    string `db:"delivered_at" json:"delivered_at,omitempty"`
+	AcknowledgedAt string `db:"acknowledged_at" json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string `db:"acknowledged_by" json:"acknowledged_by,omitempty"`
+	CreatedAt      string `db:"created_at" json:"created_at"`
+}
+
+// AlertFilter selects the alerts of GET /alerts; empty fields do not restrict
+type AlertFilter struct {
+	Owner         string
+	Status        string
+	SavedSearchId int
+	Limit         int
+	Offset        int
+}
+
+// AckRequest acknowledges an alert
+type AckRequest struct {
+	By string `json:"by"`
+}
+
+// Delivery is a pending alert taken for a webhook call with the target of its search
+type Delivery struct {
+	Alert         `db:"-"`
+	WebhookUrl    string `db:"webhook_url"`
+	WebhookSecret string `db:"webhook_secret"`
+}
+
+// Payload is the body of an alert webhook
+type Payload struct {
+	AlertId     int                 `json:"alert_id"`
+	SavedSearch PayloadSearch       `json:"saved_search"`
+	Person      personModels.Person `json:"person"`
+	MatchedAt   string              `json:"matched_at"`
+}
+
+// PayloadSearch identifies the saved search in a webhook
+type PayloadSearch struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
@@ -0,0 +1,354 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"service/internal/domains/alerts/models"
+	"service/internal/domains/person/query"
+	"strings"
+	"time"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+}
+
+func NewRepository(db *postgres.Wrapper) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+const searchColumns = `
+    id,
+    name,
+    owner,
+    query,
+    webhook_url,
+    webhook_secret <> '' AS has_secret,
+    enabled,
+    COALESCE(muted_until::text, '') AS muted_until,
+    checked_until::text AS checked_until,
+    created_at::text AS created_at,
+    updated_at::text AS updated_at`
+
+const alertColumns = `
+    a.id,
+    a.saved_search_id,
+    s.name AS search_name,
+    s.owner,
+    a.person_id,
+    COALESCE(a.import_batch_id::text, '') AS import_batch_id,
+    a.status,
+    a.delivery,
+    a.attempts,
+    a.last_error,
+    COALESCE(a.delivered_at::text, '') AS delivered_at,
+    COALESCE(a.acknowledged_at::text, '') AS acknowledged_at,
+    a.acknowledged_by,
+    a.created_at::text AS created_at`
+
+// ListSearches returns the saved searches, of one owner if it is given
+func (r *Repository) ListSearches(ctx context.Context, owner string) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	query := fmt.Sprintf(`SELECT %s FROM saved_searches WHERE $1 = '' OR owner = $1 ORDER BY id`, searchColumns)
+	if err := r.db.Select(ctx, &searches, query, owner); err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// GetSearch returns a saved search or nil if it does not exist
+func (r *Repository) GetSearch(ctx context.Context, id int) (*models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	query := fmt.Sprintf(`SELECT %s FROM saved_searches WHERE id = $1`, searchColumns)
+	if err := r.db.Select(ctx, &searches, query, id); err != nil {
+		return nil, fmt.Errorf("failed to query saved search: %w", err)
+	}
+	if len(searches) == 0 {
+		return nil, nil
+	}
+	return &searches[0], nil
+}
+
+// SaveSearch creates a saved search (id 0) or replaces one and returns it, or nil if the search to replace
+// does not exist. A new search is checked from now on; an empty secret keeps the current one
+func (r *Repository) SaveSearch(ctx context.Context, id int, request models.SavedSearchRequest, enabled bool) (*models.SavedSearch, error) {
+	args := []interface{}{request.Name, request.Owner, request.Query, request.WebhookUrl, request.WebhookSecret, enabled}
+	var query string
+	if id == 0 {
+		query = `
+            INSERT INTO saved_searches (name, owner, query, webhook_url, webhook_secret, enabled)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id`
+	} else {
+		// После выключения поиск не должен разом поднять все изменения за время простоя
+		query = `
+            UPDATE saved_searches
+            SET name = $1, owner = $2, query = $3, webhook_url = $4,
+                webhook_secret = CASE WHEN $5 = '' THEN webhook_secret ELSE $5 END,
+                checked_until = CASE WHEN enabled THEN checked_until ELSE now() END,
+                watch_from = CASE WHEN enabled THEN watch_from ELSE now() END,
+                enabled = $6, updated_at = now()
+            WHERE id = $7
+            RETURNING id`
+		args = append(args, id)
+	}
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save saved search: %w", err)
+	}
+	return r.GetSearch(ctx, id)
+}
+
+// DeleteSearch deletes a saved search with its alerts. It reports false if the search does not exist
+func (r *Repository) DeleteSearch(ctx context.Context, id int) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// MuteSearch stops the webhooks of a search until the moment, nil mutes it until unmuted.
+// It reports false if the search does not exist
+func (r *Repository) MuteSearch(ctx context.Context, id int, until *time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE saved_searches SET muted_until = COALESCE($2, 'infinity'::timestamptz), updated_at = now()
+        WHERE id = $1`, id, until)
+	if err != nil {
+		return false, fmt.Errorf("failed to mute saved search: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UnmuteSearch resumes the webhooks of a search. It reports false if the search does not exist
+func (r *Repository) UnmuteSearch(ctx context.Context, id int) (bool, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE saved_searches SET muted_until = NULL, updated_at = now() WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmute saved search: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnabledSearches returns the searches to check
+func (r *Repository) EnabledSearches(ctx context.Context) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	query := fmt.Sprintf(`SELECT %s FROM saved_searches WHERE enabled ORDER BY id`, searchColumns)
+	if err := r.db.Select(ctx, &searches, query); err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// MatchChanges records an alert for every person matching the compiled query that was created or changed
+// since the search was last checked, and moves the check mark. The window starts overlap earlier to catch
+// the changes of transactions committed late, but never before the search started watching; a person is
+// alerted once per search, so this adds no duplicates. Changes of phones, documents and addresses count
+// as changes of the person (see migration 24). The search row is locked, so instances do not check one
+// search at the same time
+func (r *Repository) MatchChanges(ctx context.Context, searchId int, compiled query.Compiled, overlap time.Duration) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var checkedUntil, watchFrom time.Time
+	err = tx.QueryRow(ctx, `SELECT checked_until, watch_from FROM saved_searches WHERE id = $1 AND enabled FOR UPDATE`, searchId).
+		Scan(&checkedUntil, &watchFrom)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Поиск удалили или выключили во время проверки
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock saved search: %w", err)
+	}
+
+	since := checkedUntil.Add(-overlap)
+	if since.Before(watchFrom) {
+		since = watchFrom
+	}
+	args := append(compiled.Args, since, searchId)
+	n := len(compiled.Args)
+	query := fmt.Sprintf(`
+        INSERT INTO alerts (saved_search_id, person_id, import_batch_id, delivery)
+        SELECT s.id, p.id, p.import_batch_id,
+            CASE WHEN s.webhook_url = '' OR s.muted_until > now() THEN '%s' ELSE '%s' END
+        FROM (
+            SELECT id, import_batch_id FROM persons
+            WHERE updated_at > $%d AND updated_at <= now() AND (%s)
+        ) p
+        CROSS JOIN saved_searches s
+        WHERE s.id = $%d
+        ON CONFLICT (saved_search_id, person_id) DO NOTHING`,
+		models.DeliverySkipped, models.DeliveryPending, n+1, compiled.Where, n+2)
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to match saved search: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE saved_searches SET checked_until = now() WHERE id = $1`, searchId); err != nil {
+		return 0, fmt.Errorf("failed to move saved search check mark: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ListAlerts returns the alerts matching the filter, the newest first
+func (r *Repository) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	var conditions []string
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Owner != "" {
+		conditions = append(conditions, "s.owner = "+param(filter.Owner))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "a.status = "+param(filter.Status))
+	}
+	if filter.SavedSearchId != 0 {
+		conditions = append(conditions, "a.saved_search_id = "+param(filter.SavedSearchId))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM alerts a JOIN saved_searches s ON s.id = a.saved_search_id`, alertColumns)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY a.id DESC LIMIT %s OFFSET %s", param(filter.Limit), param(filter.Offset))
+
+	var alerts []models.Alert
+	if err := r.db.Select(ctx, &alerts, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// SetStatus acknowledges or mutes an alert and returns it, or nil if it does not exist.
+// A webhook that has not been sent yet is not sent any more
+func (r *Repository) SetStatus(ctx context.Context, id int, status, by string) (*models.Alert, error) {
+	query := `
+        UPDATE alerts
+        SET status = $2,
+            delivery = CASE WHEN delivery = $4 THEN $5 ELSE delivery END,
+            acknowledged_at = CASE WHEN $2 = $6 THEN now() ELSE acknowledged_at END,
+            acknowledged_by = CASE WHEN $2 = $6 THEN $3 ELSE acknowledged_by END
+        WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id, status, by, models.DeliveryPending, models.DeliverySkipped, models.StatusAcknowledged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	var alerts []models.Alert
+	query = fmt.Sprintf(`SELECT %s FROM alerts a JOIN saved_searches s ON s.id = a.saved_search_id WHERE a.id = $1`, alertColumns)
+	if err := r.db.Select(ctx, &alerts, query, id); err != nil {
+		return nil, fmt.Errorf("failed to query alert: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	return &alerts[0], nil
+}
+
+// ClaimDeliveries takes up to limit pending webhooks that are due and counts the attempt. The next attempt
+// is put off by lease, so another instance does not send them while the calls are in flight
+func (r *Repository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.Delivery, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Пока поиск заглушен, его уведомления не отправляются
+	if _, err := tx.Exec(ctx, `
+        UPDATE alerts a SET delivery = $1
+        FROM saved_searches s
+        WHERE s.id = a.saved_search_id AND a.delivery = $2 AND s.muted_until > now()`,
+		models.DeliverySkipped, models.DeliveryPending); err != nil {
+		return nil, fmt.Errorf("failed to skip muted alerts: %w", err)
+	}
+
+	var ids []int
+	rows, err := tx.Query(ctx, `
+        UPDATE alerts SET attempts = attempts + 1, next_attempt_at = now() + $3::interval
+        WHERE id IN (
+            SELECT id FROM alerts
+            WHERE delivery = $1 AND next_attempt_at <= now()
+            ORDER BY next_attempt_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id`, models.DeliveryPending, limit, lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim alert deliveries: %w", err)
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan alert id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim alert deliveries: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []models.Delivery
+	query := fmt.Sprintf(`
+        SELECT %s, s.webhook_url, s.webhook_secret
+        FROM alerts a JOIN saved_searches s ON s.id = a.saved_search_id
+        WHERE a.id = ANY($1)
+        ORDER BY a.id`, alertColumns)
+	if err := tx.Select(ctx, &deliveries, query, ids); err != nil {
+		return nil, fmt.Errorf("failed to query alert deliveries: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Delivered marks a webhook as sent
+func (r *Repository) Delivered(ctx context.Context, id int) error {
+	_, err := r.db.Exec(ctx, `UPDATE alerts SET delivery = $2, delivered_at = now(), last_error = '' WHERE id = $1`,
+		id, models.DeliveryDelivered)
+	if err != nil {
+		return fmt.Errorf("failed to mark alert delivered: %w", err)
+	}
+	return nil
+}
+
+// DeliveryFailed records a failed webhook call. With retry the call is repeated after the delay,
+// otherwise the delivery is given up
+func (r *Repository) DeliveryFailed(ctx context.Context, id int, message string, retry bool, delay time.Duration) error {
+	delivery := models.DeliveryFailed
+	if retry {
+		delivery = models.DeliveryPending
+	}
+	_, err := r.db.Exec(ctx, `
+        UPDATE alerts SET delivery = $2, last_error = $3, next_attempt_at = now() + $4::interval
+        WHERE id = $1 AND delivery = $5`, id, delivery, message, delay, models.DeliveryPending)
+	if err != nil {
+		return fmt.Errorf("failed to record alert delivery error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"service/internal/domains/alerts/models"
+	"service/internal/domains/alerts/webhook"
+	"service/internal/domains/person"
+	personModels "service/internal/domains/person/models"
+	"service/internal/domains/person/query"
+	"service/internal/infrastructure/config"
+	"service/internal/infrastructure/metrics"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for a missing saved search or alert
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for a saved search with a bad query or webhook, or a bad alert filter
+	ErrInvalid = errors.New("invalid saved search")
+)
+
+const (
+	// matchOverlap is how far back a check repeats the previous one, see Repository.MatchChanges
+	matchOverlap = 5 * time.Minute
+	// deliveryBatch is the number of webhooks sent per claim
+	deliveryBatch = 50
+	// maxAttempts is the number of webhook calls before the delivery is given up
+	maxAttempts = 5
+	// retryDelay is multiplied by the attempt number: 1, 2, 3 and 4 minutes between the calls
+	retryDelay = time.Minute
+)
+
+type Service struct {
+	repo    *Repository
+	persons *person.Service
+	cfg     *config.AlertsConfig
+	client  *http.Client
+	// checking runs one check of the saved searches at a time
+	checking sync.Mutex
+}
+
+func NewService(repo *Repository, persons *person.Service, cfg *config.AlertsConfig) *Service {
+	timeout := 10 * time.Second
+	if cfg != nil && cfg.WebhookTimeout > 0 {
+		timeout = cfg.WebhookTimeout
+	}
+	return &Service{
+		repo:    repo,
+		persons: persons,
+		cfg:     cfg,
+		client:  webhook.NewClient(timeout),
+	}
+}
+
+// ListSearches returns the saved searches, of one owner if it is given
+func (s *Service) ListSearches(ctx context.Context, owner string) ([]models.SavedSearch, error) {
+	return s.repo.ListSearches(ctx, owner)
+}
+
+// GetSearch returns a saved search
+func (s *Service) GetSearch(ctx context.Context, id int) (*models.SavedSearch, error) {
+	search, err := s.repo.GetSearch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil {
+		return nil, fmt.Errorf("%w: saved search %d", ErrNotFound, id)
+	}
+	return search, nil
+}
+
+// SaveSearch checks the query and the webhook and creates a saved search (id 0) or replaces one
+func (s *Service) SaveSearch(ctx context.Context, id int, request models.SavedSearchRequest) (*models.SavedSearch, error) {
+	request.Name = strings.TrimSpace(request.Name)
+	request.Owner = strings.TrimSpace(request.Owner)
+	request.WebhookUrl = strings.TrimSpace(request.WebhookUrl)
+	if request.Name == "" || request.Owner == "" {
+		return nil, fmt.Errorf("%w: name and owner are required", ErrInvalid)
+	}
+	if request.Query == nil {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalid)
+	}
+	if _, err := query.Compile(*request.Query, nil); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if request.WebhookUrl != "" {
+		if err := webhook.ValidateURL(request.WebhookUrl); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+
+	search, err := s.repo.SaveSearch(ctx, id, request, request.Enabled == nil || *request.Enabled)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil {
+		return nil, fmt.Errorf("%w: saved search %d", ErrNotFound, id)
+	}
+	return search, nil
+}
+
+// DeleteSearch deletes a saved search with its alerts
+func (s *Service) DeleteSearch(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeleteSearch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: saved search %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// MuteSearch stops the webhooks of a search until the moment, or until unmuted if it is nil.
+// Matches are still recorded as alerts while the search is muted
+func (s *Service) MuteSearch(ctx context.Context, id int, until *time.Time) (*models.SavedSearch, error) {
+	if until != nil && !until.After(time.Now()) {
+		return nil, fmt.Errorf("%w: until must be in the future", ErrInvalid)
+	}
+	muted, err := s.repo.MuteSearch(ctx, id, until)
+	if err != nil {
+		return nil, err
+	}
+	if !muted {
+		return nil, fmt.Errorf("%w: saved search %d", ErrNotFound, id)
+	}
+	return s.GetSearch(ctx, id)
+}
+
+// UnmuteSearch resumes the webhooks of a search
+func (s *Service) UnmuteSearch(ctx context.Context, id int) (*models.SavedSearch, error) {
+	unmuted, err := s.repo.UnmuteSearch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !unmuted {
+		return nil, fmt.Errorf("%w: saved search %d", ErrNotFound, id)
+	}
+	return s.GetSearch(ctx, id)
+}
+
+// ListAlerts returns the alerts matching the filter, the newest first
+func (s *Service) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	switch filter.Status {
+	case "", models.StatusNew, models.StatusAcknowledged, models.StatusMuted:
+	default:
+		return nil, fmt.Errorf("%w: status must be new, acknowledged or muted", ErrInvalid)
+	}
+	return s.repo.ListAlerts(ctx, filter)
+}
+
+// Acknowledge marks an alert as seen by the investigator
+func (s *Service) Acknowledge(ctx context.Context, id int, by string) (*models.Alert, error) {
+	return s.setStatus(ctx, id, models.StatusAcknowledged, strings.TrimSpace(by))
+}
+
+// Mute hides an alert from the new ones and cancels its webhook if it has not been sent
+func (s *Service) Mute(ctx context.Context, id int) (*models.Alert, error) {
+	return s.setStatus(ctx, id, models.StatusMuted, "")
+}
+
+func (s *Service) setStatus(ctx context.Context, id int, status, by string) (*models.Alert, error) {
+	alert, err := s.repo.SetStatus(ctx, id, status, by)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, fmt.Errorf("%w: alert %d", ErrNotFound, id)
+	}
+	return alert, nil
+}
+
+// AfterBatch checks the saved searches on the records of a finished import batch and sends the webhooks.
+// It is registered with person.Service.OnBatch
+func (s *Service) AfterBatch(ctx context.Context, result personModels.ImportResult) {
+	created := s.Check(ctx)
+	if created > 0 {
+		log.Infof("Import batch %s raised %d alerts", result.BatchId, created)
+	}
+	s.Deliver(ctx)
+}
+
+// Watch checks the saved searches and retries the webhooks every configured interval until the context
+// is cancelled. It catches the changes made outside imports, such as merges
+func (s *Service) Watch(ctx context.Context) {
+	if s.cfg == nil || s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Check(ctx)
+			s.Deliver(ctx)
+		}
+	}
+}
+
+// Check matches every enabled saved search against the records changed since its last check
+// and returns the number of new alerts
+func (s *Service) Check(ctx context.Context) int {
+	s.checking.Lock()
+	defer s.checking.Unlock()
+
+	searches, err := s.repo.EnabledSearches(ctx)
+	if err != nil {
+		log.Errorf("Failed to check saved searches: %v", err)
+		return 0
+	}
+
+	total := 0
+	for _, search := range searches {
+		compiled, err := query.Compile(*search.Query, nil)
+		if err != nil {
+			// Запрос проверяется при сохранении, сюда попадает только ставший неверным после изменения полей
+			log.Errorf("Saved search %d has an invalid query: %v", search.Id, err)
+			continue
+		}
+		created, err := s.repo.MatchChanges(ctx, search.Id, compiled, matchOverlap)
+		if err != nil {
+			log.Errorf("Failed to check saved search %d: %v", search.Id, err)
+			continue
+		}
+		total += created
+	}
+	metrics.AlertsCreated(total)
+	return total
+}
+
+// Deliver sends the due webhooks until none is left
+func (s *Service) Deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		// Вызовы идут по очереди, аренда покрывает их все
+		deliveries, err := s.repo.ClaimDeliveries(ctx, deliveryBatch, s.client.Timeout*(deliveryBatch+1))
+		if err != nil {
+			log.Errorf("Failed to claim alert webhooks: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		for _, delivery := range deliveries {
+			s.deliver(ctx, delivery)
+		}
+	}
+}
+
+func (s *Service) deliver(ctx context.Context, delivery models.Delivery) {
+	err := s.send(ctx, delivery)
+	if err == nil {
+		metrics.AlertWebhook("delivered")
+		if err := s.repo.Delivered(ctx, delivery.Id); err != nil {
+			log.Errorf("Alert %d: %v", delivery.Id, err)
+		}
+		return
+	}
+
+	retry := delivery.Attempts < maxAttempts
+	if retry {
+		metrics.AlertWebhook("retry")
+	} else {
+		metrics.AlertWebhook("failed")
+		log.Errorf("Gave up alert %d webhook after %d attempts: %v", delivery.Id, delivery.Attempts, err)
+	}
+	if err := s.repo.DeliveryFailed(ctx, delivery.Id, err.Error(), retry, retryDelay*time.Duration(delivery.Attempts)); err != nil {
+		log.Errorf("Alert %d: %v", delivery.Id, err)
+	}
+}
+
+// send posts the alert with the current state of the person
+func (s *Service) send(ctx context.Context, delivery models.Delivery) error {
+	p, err := s.persons.GetPerson(ctx, delivery.PersonId)
+	if err != nil {
+		return fmt.Errorf("failed to load person %d: %w", delivery.PersonId, err)
+	}
+	body, err := json.Marshal(models.Payload{
+		AlertId: delivery.Id,
+		SavedSearch: models.PayloadSearch{
+			Id:    delivery.SavedSearchId,
+			Name:  delivery.SearchName,
+			Owner: delivery.Owner,
+		},
+		Person:    p,
+		MatchedAt: delivery.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	return webhook.Deliver(ctx, s.client, delivery.WebhookUrl, delivery.WebhookSecret, body)
+}
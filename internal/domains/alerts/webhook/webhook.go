@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the body when the saved search has a secret
+const SignatureHeader = "X-Alert-Signature"
+
+var (
+	// ErrInvalidURL is returned for a webhook address that is not an absolute http(s) URL
+	ErrInvalidURL = errors.New("invalid webhook url")
+	// ErrForbiddenAddress is returned for a webhook on an internal address: loopback, private networks,
+	// link-local (cloud metadata) and other addresses not routed on the internet
+	ErrForbiddenAddress = errors.New("webhook address is not public")
+)
+
+// Сети, не маршрутизируемые в интернете, сверх netip.Addr.IsPrivate, IsLoopback и т.п.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// maxResponse limits the part of an error response kept for the alert
+const maxResponse = 512
+
+// ValidateURL checks a webhook address. A host given as an IP or as localhost must be public;
+// names are checked after resolution on every delivery by the client of NewClient
+func ValidateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, value)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !public(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// NewClient returns the client webhooks are delivered with. It connects only to public addresses,
+// checked after DNS resolution so that a name cannot point at an internal service, and does not follow
+// redirects: a 3xx answer fails the delivery. Proxies from the environment are not used, the check
+// must see the address of the receiver
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialControl runs with the resolved address before every connection
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+func public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sign returns the signature of the body: "sha256=" and the hex HMAC with the secret.
+// The receiver computes the same over the raw body to check the sender
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts the JSON body to the webhook. Any status outside 2xx is an error
+func Deliver(ctx context.Context, client *http.Client, address, secret string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		request.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		text, _ := io.ReadAll(io.LimitReader(response.Body, maxResponse))
+		return fmt.Errorf("webhook responded %s: %s", response.Status, bytes.TrimSpace(text))
+	}
+	// Дочитываем ответ, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
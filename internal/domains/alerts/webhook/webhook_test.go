@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://hooks.example.com/alerts?team=1"))
+	for _, value := range []string{"", "hooks.example.com", "ftp://example.com", "http://", "/alerts"} {
+		assert.ErrorIs(t, ValidateURL(value), ErrInvalidURL, value)
+	}
+	for _, value := range []string{"http://127.0.0.1:8080/", "http://localhost/alerts", "http://[::1]/", "http://10.1.2.3/",
+		"http://192.168.0.10/", "http://169.254.169.254/latest/meta-data", "http://100.64.0.1/", "http://[::ffff:127.0.0.1]/",
+		"http://0.0.0.0/", "http://[fd00::1]/"} {
+		assert.ErrorIs(t, ValidateURL(value), ErrForbiddenAddress, value)
+	}
+	assert.NoError(t, ValidateURL("http://93.184.215.14/alerts"))
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Адрес тестового сервера - loopback, клиент вебхуков к нему не подключается
+	err := Deliver(context.Background(), NewClient(time.Second), server.URL, "", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestDeliver(t *testing.T) {
+	body := []byte(`{"alert_id": 1}`)
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		data, _ := io.ReadAll(r.Body)
+		if string(data) != string(body) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, Deliver(context.Background(), server.Client(), server.URL, "secret", body))
+	assert.Equal(t, Sign("secret", body), received.Get(SignatureHeader))
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+
+	assert.NoError(t, Deliver(context.Background(), server.Client(), server.URL, "", body))
+	assert.Empty(t, received.Get(SignatureHeader))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	err := Deliver(context.Background(), failing.Client(), failing.URL, "", body)
+	assert.ErrorContains(t, err, "503 Service Unavailable: unavailable")
+}
+
+func TestSign(t *testing.T) {
+	// Значение из RFC 4231, тест 2
+	assert.Equal(t, "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		Sign("Jefe", []byte("what do ya want for nothing?")))
+}
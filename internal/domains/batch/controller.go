@@ -0,0 +1,81 @@
+package batch
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"service/internal/domains/person"
+	"strconv"
+)
+
+const (
+	defaultBatchLimit = 50
+	maxBatchLimit     = 500
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/import-batches", c.ListBatches)
+	r.DELETE("/import-batches/:id", c.Rollback)
+}
+
+// ListBatches returns the import history: ?source=&limit=&offset=
+func (c *Controller) ListBatches(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultBatchLimit)))
+	if err != nil || limit <= 0 || limit > maxBatchLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный offset"})
+		return
+	}
+
+	batches, err := c.svc.ListBatches(ctx.Request.Context(), ctx.Query("source"), limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// Rollback removes the records of an import batch. With ?dry_run=true only the impact is returned
+func (c *Controller) Rollback(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор пакета"})
+		return
+	}
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный dry_run"})
+		return
+	}
+
+	impact, err := c.svc.Rollback(ctx.Request.Context(), id, dryRun)
+	switch {
+	case errors.Is(err, person.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Пакет не найден"})
+	case errors.Is(err, person.ErrBatchNotFinished):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, person.ErrRollbackConflict):
+		// Оператор видит, какие ручные правки мешают откату
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "impact": impact})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"impact": impact})
+	}
+}
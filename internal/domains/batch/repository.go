@@ -0,0 +1,50 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"service/internal/domains/person/models"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+}
+
+func NewRepository(db *postgres.Wrapper) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+const batchColumns = `
+    b.id::text AS id,
+    b.source_id,
+    COALESCE(b.file_name, '') AS file_name,
+    COALESCE(b.format, '') AS format,
+    b.status,
+    b.row_count,
+    b.saved,
+    b.duplicates,
+    b.merged,
+    b.rejected,
+    b.quarantined,
+    b.warnings,
+    (SELECT count(*) FROM persons p WHERE p.import_batch_id = b.id)::int AS persons,
+    b.created_at::text AS created_at,
+    COALESCE(b.finished_at::text, '') AS finished_at,
+    COALESCE(b.rolled_back_at::text, '') AS rolled_back_at`
+
+// ListBatches returns the import history, newest first, optionally for one source
+func (r *Repository) ListBatches(ctx context.Context, sourceId string, limit, offset int) ([]models.ImportBatch, error) {
+	var batches []models.ImportBatch
+	query := fmt.Sprintf(`
+        SELECT %s FROM import_batches b
+        WHERE $1 = '' OR b.source_id = $1
+        ORDER BY b.created_at DESC, b.id
+        LIMIT $2 OFFSET $3`, batchColumns)
+	if err := r.db.Select(ctx, &batches, query, sourceId, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to query import batches: %w", err)
+	}
+	return batches, nil
+}
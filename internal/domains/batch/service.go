@@ -0,0 +1,29 @@
+package batch
+
+import (
+	"context"
+	"service/internal/domains/person"
+	"service/internal/domains/person/models"
+)
+
+type Service struct {
+	repo    *Repository
+	persons *person.Service
+}
+
+func NewService(repo *Repository, persons *person.Service) *Service {
+	return &Service{
+		repo:    repo,
+		persons: persons,
+	}
+}
+
+// ListBatches returns the import history with row counts
+func (s *Service) ListBatches(ctx context.Context, sourceId string, limit, offset int) ([]models.ImportBatch, error) {
+	return s.repo.ListBatches(ctx, sourceId, limit, offset)
+}
+
+// Rollback deletes everything an import batch created and recomputes the affected golden records
+func (s *Service) Rollback(ctx context.Context, batchId string, dryRun bool) (*models.RollbackImpact, error) {
+	return s.persons.RollbackBatch(ctx, batchId, dryRun)
+}
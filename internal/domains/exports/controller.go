@@ -0,0 +1,132 @@
+package exports
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/internal/domains/exports/models"
+	"strconv"
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/exports", c.List)
+	r.POST("/exports", c.Create)
+	r.GET("/exports/:id", c.Get)
+	r.PUT("/exports/:id", c.Update)
+	r.DELETE("/exports/:id", c.Delete)
+	r.POST("/exports/:id/run", c.Run)
+	r.GET("/exports/:id/runs", c.Runs)
+}
+
+// List returns the scheduled exports
+func (c *Controller) List(ctx *gin.Context) {
+	definitions, err := c.svc.List(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"exports": definitions})
+}
+
+// Create adds an export, see models.DefinitionRequest. The schedule is a cron expression
+// ("0 2 * * *"), @hourly, @daily, @weekly, @monthly or "@every 6h"; mode=full|delta
+func (c *Controller) Create(ctx *gin.Context) {
+	var request models.DefinitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := c.svc.Save(ctx.Request.Context(), 0, request)
+	c.respond(ctx, http.StatusCreated, gin.H{"export": definition}, err)
+}
+
+// Get returns an export
+func (c *Controller) Get(ctx *gin.Context) {
+	id, ok := exportId(ctx)
+	if !ok {
+		return
+	}
+	definition, err := c.svc.Get(ctx.Request.Context(), id)
+	c.respond(ctx, http.StatusOK, gin.H{"export": definition}, err)
+}
+
+// Update replaces an export definition
+func (c *Controller) Update(ctx *gin.Context) {
+	id, ok := exportId(ctx)
+	if !ok {
+		return
+	}
+	var request models.DefinitionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := c.svc.Save(ctx.Request.Context(), id, request)
+	c.respond(ctx, http.StatusOK, gin.H{"export": definition}, err)
+}
+
+// Delete deletes an export; the written files stay in the bucket
+func (c *Controller) Delete(ctx *gin.Context) {
+	id, ok := exportId(ctx)
+	if !ok {
+		return
+	}
+	err := c.svc.Delete(ctx.Request.Context(), id)
+	c.respond(ctx, http.StatusOK, gin.H{"deleted": id}, err)
+}
+
+// Run starts an export out of schedule; its progress is seen in GET /exports/:id/runs
+func (c *Controller) Run(ctx *gin.Context) {
+	id, ok := exportId(ctx)
+	if !ok {
+		return
+	}
+	err := c.svc.RunNow(ctx.Request.Context(), id)
+	c.respond(ctx, http.StatusAccepted, gin.H{"scheduled": id}, err)
+}
+
+// Runs returns the latest runs of an export with their files, row counts and checksums
+func (c *Controller) Runs(ctx *gin.Context) {
+	id, ok := exportId(ctx)
+	if !ok {
+		return
+	}
+	runs, err := c.svc.Runs(ctx.Request.Context(), id)
+	c.respond(ctx, http.StatusOK, gin.H{"runs": runs}, err)
+}
+
+func exportId(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный id выгрузки"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (c *Controller) respond(ctx *gin.Context, status int, body gin.H, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrConflict):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(status, body)
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"service/internal/domains/person/export"
+	"service/internal/domains/person/query"
+)
+
+// Export modes: a full export has every matching person, a delta only those changed since the previous run
+const (
+	ModeFull  = "full"
+	ModeDelta = "delta"
+)
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Definition is an export written to a MinIO bucket on a schedule
+type Definition struct {
+	Id       int             `db:"id" json:"id"`
+	Name     string          `db:"name" json:"name"`
+	Query    *query.Node     `db:"query" json:"query,omitempty"`
+	Options  *export.Options `db:"options" json:"options"`
+	Schedule string          `db:"schedule" json:"schedule"`
+	Mode     string          `db:"mode" json:"mode"`
+	Bucket   string          `db:"bucket" json:"bucket"`
+	Prefix   string          `db:"prefix" json:"prefix"`
+	Enabled  bool            `db:"enabled" json:"enabled"`
+
+	NextRunAt string `db:"next_run_at" json:"next_run_at,omitempty"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+	UpdatedAt string `db:"updated_at" json:"updated_at"`
+}
+
+// DefinitionRequest creates or replaces an export:
+//
+//	{"name": "crm-nightly", "query": {"field": "source_id", "op": "equals", "value": "crm"},
+//	 "options": {"format": "csv", "columns": ["id", "fio", "phone"]},
+//	 "schedule": "0 2 * * *", "mode": "delta", "bucket": "exports", "prefix": "crm/"}
+type DefinitionRequest struct {
+	Name     string         `json:"name" binding:"required"`
+	Query    *query.Node    `json:"query"`
+	Options  export.Options `json:"options"`
+	Schedule string         `json:"schedule" binding:"required"`
+	Mode     string         `json:"mode"`
+	Bucket   string         `json:"bucket" binding:"required"`
+	Prefix   string         `json:"prefix"`
+	Enabled  *bool          `json:"enabled"`
+}
+
+// Run is one execution of an export
+type Run struct {
+	Id       int    `db:"id" json:"id"`
+	ExportId int    `db:"export_id" json:"export_id"`
+	Mode     string `db:"mode" json:"mode"`
+	Status   string `db:"status" json:"status"`
+	// Since and Until bound the changes of a delta run; a full run and the first delta have no Since
+	Since    string `db:"since" json:"since,omitempty"`
+	Until    string `db:"until" json:"until"`
+	Rows     int    `db:"rows" json:"rows"`
+	Size     int64  `db:"size" json:"size"`
+	Checksum string `db:"checksum" json:"checksum,omitempty"`
+	Object   string `db:"object" json:"object,omitempty"`
+	Manifest string `db:"manifest" json:"manifest,omitempty"`
+	Error    string `db:"error" json:"error,omitempty"`
+
+	StartedAt  string `db:"started_at" json:"started_at"`
+	FinishedAt string `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// Manifest is written next to every exported file so that consumers can check it
+type Manifest struct {
+	Export      string   `json:"export"`
+	RunId       int      `json:"run_id"`
+	Mode        string   `json:"mode"`
+	Since       string   `json:"since,omitempty"`
+	Until       string   `json:"until"`
+	Format      string   `json:"format"`
+	Columns     []string `json:"columns"`
+	Object      string   `json:"object"`
+	Rows        int      `json:"rows"`
+	Size        int64    `json:"size"`
+	Checksum    string   `json:"checksum"`
+	GeneratedAt string   `json:"generated_at"`
+	Deleted     []int    `json:"deleted,omitempty"` // persons deleted within (Since, Until], whatever the query
+}
@@ -0,0 +1,252 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"io"
+	"service/internal/domains/exports/models"
+	"service/internal/infrastructure/storage/minio"
+	"time"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+	s3 *minio.Minio
+}
+
+func NewRepository(db *postgres.Wrapper, s3 *minio.Minio) *Repository {
+	return &Repository{
+		db: db,
+		s3: s3,
+	}
+}
+
+const definitionColumns = `
+    id,
+    name,
+    query,
+    options,
+    schedule,
+    mode,
+    bucket,
+    prefix,
+    enabled,
+    COALESCE(next_run_at::text, '') AS next_run_at,
+    created_at::text AS created_at,
+    updated_at::text AS updated_at`
+
+const runColumns = `
+    id,
+    export_id,
+    mode,
+    status,
+    COALESCE(since::text, '') AS since,
+    until::text AS until,
+    rows,
+    size,
+    checksum,
+    object,
+    manifest,
+    error,
+    started_at::text AS started_at,
+    COALESCE(finished_at::text, '') AS finished_at`
+
+// ListDefinitions returns all exports by name
+func (r *Repository) ListDefinitions(ctx context.Context) ([]models.Definition, error) {
+	var definitions []models.Definition
+	query := fmt.Sprintf(`SELECT %s FROM export_definitions ORDER BY name`, definitionColumns)
+	if err := r.db.Select(ctx, &definitions, query); err != nil {
+		return nil, fmt.Errorf("failed to query exports: %w", err)
+	}
+	return definitions, nil
+}
+
+// GetDefinition returns an export or nil if it does not exist
+func (r *Repository) GetDefinition(ctx context.Context, id int) (*models.Definition, error) {
+	var definitions []models.Definition
+	query := fmt.Sprintf(`SELECT %s FROM export_definitions WHERE id = $1`, definitionColumns)
+	if err := r.db.Select(ctx, &definitions, query, id); err != nil {
+		return nil, fmt.Errorf("failed to query export: %w", err)
+	}
+	if len(definitions) == 0 {
+		return nil, nil
+	}
+	return &definitions[0], nil
+}
+
+// SaveDefinition creates an export (id 0) or replaces one and returns it, or nil if the export to replace
+// does not exist. It returns ErrConflict if another export has the name
+func (r *Repository) SaveDefinition(ctx context.Context, id int, d models.Definition, nextRunAt *time.Time) (*models.Definition, error) {
+	args := []interface{}{d.Name, d.Query, d.Options, d.Schedule, d.Mode, d.Bucket, d.Prefix, d.Enabled, nextRunAt}
+	var query string
+	if id == 0 {
+		query = `
+            INSERT INTO export_definitions (name, query, options, schedule, mode, bucket, prefix, enabled, next_run_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            RETURNING id`
+	} else {
+		query = `
+            UPDATE export_definitions
+            SET name = $1, query = $2, options = $3, schedule = $4, mode = $5, bucket = $6, prefix = $7,
+                enabled = $8, next_run_at = $9, updated_at = now()
+            WHERE id = $10
+            RETURNING id`
+		args = append(args, id)
+	}
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&id)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return nil, fmt.Errorf("%w: export %q already exists", ErrConflict, d.Name)
+	case err != nil:
+		return nil, fmt.Errorf("failed to save export: %w", err)
+	}
+	return r.GetDefinition(ctx, id)
+}
+
+// DeleteDefinition deletes an export with its runs. It reports false if the export does not exist
+func (r *Repository) DeleteDefinition(ctx context.Context, id int) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM export_definitions WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete export: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Schedule makes an export due now. It reports false if the export does not exist
+func (r *Repository) Schedule(ctx context.Context, id int) (bool, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE export_definitions SET next_run_at = now() WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule export: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// claim is a due export taken by this instance together with its started run
+type claim struct {
+	models.Definition
+	RunId int
+	Since *time.Time
+	Until time.Time
+}
+
+// ClaimDue takes the export due first, moves its next run with next and starts a run. Rows locked by another
+// instance are skipped, so each run happens once. It returns nil when nothing is due
+func (r *Repository) ClaimDue(ctx context.Context, next func(models.Definition) *time.Time, overlap time.Duration) (*claim, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var due []models.Definition
+	query := fmt.Sprintf(`
+        SELECT %s FROM export_definitions
+        WHERE enabled AND next_run_at <= now()
+        ORDER BY next_run_at
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED`, definitionColumns)
+	if err := tx.Select(ctx, &due, query); err != nil {
+		return nil, fmt.Errorf("failed to query due exports: %w", err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	c := &claim{Definition: due[0]}
+
+	if _, err := tx.Exec(ctx, `UPDATE export_definitions SET next_run_at = $2 WHERE id = $1`, c.Id, next(c.Definition)); err != nil {
+		return nil, fmt.Errorf("failed to move next export run: %w", err)
+	}
+
+	if c.Mode == models.ModeDelta {
+		// Дельта продолжает последний успешный запуск любого режима
+		var until time.Time
+		err := tx.QueryRow(ctx, `
+            SELECT until FROM export_runs
+            WHERE export_id = $1 AND status = $2
+            ORDER BY id DESC
+            LIMIT 1`, c.Id, models.StatusSucceeded).Scan(&until)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return nil, fmt.Errorf("failed to query previous export run: %w", err)
+		default:
+			since := until.Add(-overlap)
+			c.Since = &since
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+        INSERT INTO export_runs (export_id, mode, since, until) VALUES ($1, $2, $3, now())
+        RETURNING id, until`, c.Id, c.Mode, c.Since).Scan(&c.RunId, &c.Until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start export run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return c, nil
+}
+
+// FinishRun records the outcome of a run
+func (r *Repository) FinishRun(ctx context.Context, run models.Run) error {
+	query := `
+        UPDATE export_runs
+        SET status = $2, rows = $3, size = $4, checksum = $5, object = $6, manifest = $7, error = $8,
+            finished_at = now()
+        WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, run.Id, run.Status, run.Rows, run.Size, run.Checksum, run.Object, run.Manifest, run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to finish export run: %w", err)
+	}
+	return nil
+}
+
+// ListRuns returns the latest runs of an export, the newest first
+func (r *Repository) ListRuns(ctx context.Context, exportId, limit int) ([]models.Run, error) {
+	var runs []models.Run
+	query := fmt.Sprintf(`SELECT %s FROM export_runs WHERE export_id = $1 ORDER BY id DESC LIMIT $2`, runColumns)
+	if err := r.db.Select(ctx, &runs, query, exportId, limit); err != nil {
+		return nil, fmt.Errorf("failed to query export runs: %w", err)
+	}
+	return runs, nil
+}
+
+// Deletions returns the ids of the persons deleted within (since, until]
+func (r *Repository) Deletions(ctx context.Context, since, until time.Time) ([]int, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT DISTINCT person_id FROM person_deletions
+        WHERE deleted_at > $1 AND deleted_at <= $2
+        ORDER BY person_id`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted persons: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted person: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Upload writes an object to the bucket and returns its size
+func (r *Repository) Upload(ctx context.Context, bucket, object, contentType string, reader io.Reader) (int64, error) {
+	size, err := r.s3.UploadStream(ctx, bucket, object, contentType, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload %s/%s: %w", bucket, object, err)
+	}
+	return size, nil
+}
@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is returned for a schedule that cannot be parsed
+var ErrInvalid = errors.New("invalid schedule")
+
+// Schedule tells when an export runs next
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+var aliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Parse reads a five-field cron expression (minute hour day-of-month month day-of-week, with *, lists,
+// ranges and steps: "30 2 * * 1-5"), an alias (@hourly, @daily, @weekly, @monthly) or "@every 6h"
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if value, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("%w: @every needs a duration of at least 1m", ErrInvalid)
+		}
+		return every(interval), nil
+	}
+	if expr, ok := aliases[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: %q must have five fields: minute hour day month weekday", ErrInvalid, spec)
+	}
+	var c cron
+	var err error
+	bounds := []struct {
+		set      *[]bool
+		min, max int
+	}{{&c.minute, 0, 59}, {&c.hour, 0, 23}, {&c.day, 1, 31}, {&c.month, 1, 12}, {&c.weekday, 0, 7}}
+	for i, b := range bounds {
+		if *b.set, err = parseField(fields[i], b.min, b.max); err != nil {
+			return nil, err
+		}
+	}
+	// 7 - тоже воскресенье
+	c.weekday[0] = c.weekday[0] || c.weekday[7]
+	c.anyDay, c.anyWeekday = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e)).Truncate(time.Minute)
+}
+
+type cron struct {
+	minute, hour, day, month, weekday []bool
+	anyDay, anyWeekday                bool
+}
+
+// maxSearch bounds the search for a date that exists: "0 0 31 2 *" never comes
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+	for t.Before(limit) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron: with both day fields restricted either may match
+func (c cron) dayMatches(t time.Time) bool {
+	day, weekday := c.day[t.Day()], c.weekday[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+func parseField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, value, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%w: bad step in %q", ErrInvalid, part)
+			}
+			part, step = base, n
+		}
+
+		from, to := min, max
+		if part != "*" {
+			lo, hi, isRange := strings.Cut(part, "-")
+			var err error
+			if from, err = strconv.Atoi(lo); err != nil {
+				return nil, fmt.Errorf("%w: bad value %q", ErrInvalid, part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(hi); err != nil {
+					return nil, fmt.Errorf("%w: bad range %q", ErrInvalid, part)
+				}
+			} else if step > 1 {
+				// "5/15" - с 5-й минуты каждые 15
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%w: %q is out of %d-%d", ErrInvalid, part, min, max)
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
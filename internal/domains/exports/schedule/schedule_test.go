@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(value string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04", value)
+	return t
+}
+
+func TestNext(t *testing.T) {
+	for spec, want := range map[string][2]string{
+		"@daily":          {"2026-10-19 12:34", "2026-10-20 00:00"},
+		"30 2 * * *":      {"2026-10-19 02:30", "2026-10-20 02:30"},
+		"*/15 * * * *":    {"2026-10-19 12:34", "2026-10-19 12:45"},
+		"0 9 * * 1-5":     {"2026-10-23 10:00", "2026-10-26 09:00"},
+		"0 0 1 * *":       {"2026-12-15 00:00", "2027-01-01 00:00"},
+		"0 0 29 2 *":      {"2026-03-01 00:00", "2028-02-29 00:00"},
+		"0 3 * * 7":       {"2026-10-19 00:00", "2026-10-25 03:00"},
+		"0 0 13 * 5":      {"2026-10-19 00:00", "2026-10-23 00:00"},
+		"5/20 8,20 * * *": {"2026-10-19 08:30", "2026-10-19 08:45"},
+		"@every 6h":       {"2026-10-19 12:34", "2026-10-19 18:34"},
+	} {
+		s, err := Parse(spec)
+		assert.NoError(t, err, spec)
+		assert.Equal(t, at(want[1]), s.Next(at(want[0])), spec)
+	}
+
+	s, _ := Parse("0 0 31 2 *")
+	assert.True(t, s.Next(at("2026-01-01 00:00")).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every 10s", "@yearly"} {
+		_, err := Parse(spec)
+		assert.ErrorIs(t, err, ErrInvalid, spec)
+	}
+}
@@ -0,0 +1,304 @@
+package exports
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"path"
+	"regexp"
+	"service/internal/domains/exports/models"
+	"service/internal/domains/exports/schedule"
+	"service/internal/domains/person"
+	"service/internal/domains/person/listing"
+	"service/internal/domains/person/query"
+	"service/internal/infrastructure/config"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for a missing export
+	ErrNotFound = errors.New("export not found")
+	// ErrInvalid is returned for a definition with a bad name, schedule, mode, filter or file options
+	ErrInvalid = errors.New("invalid export")
+	// ErrConflict is returned when another export has the name
+	ErrConflict = errors.New("export conflict")
+)
+
+// deltaOverlap starts a delta this long before the previous run ended, so that the changes of transactions
+// committed after that run are not lost. Consumers apply deltas as upserts by id
+const deltaOverlap = 5 * time.Minute
+
+// runsLimit is the number of runs GET /exports/:id/runs returns
+const runsLimit = 50
+
+// The name becomes part of the object name
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type Service struct {
+	repo    *Repository
+	persons *person.Service
+	cfg     *config.ExportsConfig
+}
+
+func NewService(repo *Repository, persons *person.Service, cfg *config.ExportsConfig) *Service {
+	return &Service{
+		repo:    repo,
+		persons: persons,
+		cfg:     cfg,
+	}
+}
+
+// List returns all exports
+func (s *Service) List(ctx context.Context) ([]models.Definition, error) {
+	return s.repo.ListDefinitions(ctx)
+}
+
+// Get returns an export
+func (s *Service) Get(ctx context.Context, id int) (*models.Definition, error) {
+	definition, err := s.repo.GetDefinition(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return definition, nil
+}
+
+// Save checks a definition and creates an export (id 0) or replaces one. The next run is computed from the schedule
+func (s *Service) Save(ctx context.Context, id int, request models.DefinitionRequest) (*models.Definition, error) {
+	definition, spec, err := parseDefinition(request)
+	if err != nil {
+		return nil, err
+	}
+	var nextRunAt *time.Time
+	if definition.Enabled {
+		nextRunAt = nextRun(spec, time.Now())
+	}
+
+	saved, err := s.repo.SaveDefinition(ctx, id, definition, nextRunAt)
+	if err != nil {
+		return nil, err
+	}
+	if saved == nil {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return saved, nil
+}
+
+// Delete deletes an export and its run history; the files already written stay in the bucket
+func (s *Service) Delete(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeleteDefinition(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// RunNow makes an export due, the scheduler picks it up on its next tick
+func (s *Service) RunNow(ctx context.Context, id int) error {
+	scheduled, err := s.repo.Schedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !scheduled {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// Runs returns the latest runs of an export
+func (s *Service) Runs(ctx context.Context, id int) ([]models.Run, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRuns(ctx, id, runsLimit)
+}
+
+// Watch runs the due exports every configured interval until the context is cancelled
+func (s *Service) Watch(ctx context.Context) {
+	if s.cfg == nil || s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue runs the due exports one by one until none is left
+func (s *Service) runDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		c, err := s.repo.ClaimDue(ctx, func(d models.Definition) *time.Time {
+			spec, err := schedule.Parse(d.Schedule)
+			if err != nil {
+				// Расписание проверяется при сохранении, сюда попадает только испорченное вручную
+				log.Errorf("Export %s has an invalid schedule, it is stopped: %v", d.Name, err)
+				return nil
+			}
+			return nextRun(spec, time.Now())
+		}, deltaOverlap)
+		if err != nil {
+			log.Errorf("Failed to claim a due export: %v", err)
+			return
+		}
+		if c == nil {
+			return
+		}
+		s.run(ctx, c)
+	}
+}
+
+// run writes the file of a claimed export with its manifest and records the outcome
+func (s *Service) run(ctx context.Context, c *claim) {
+	run := models.Run{Id: c.RunId, Status: models.StatusSucceeded}
+	manifest, err := s.write(ctx, c)
+	if err != nil {
+		run.Status = models.StatusFailed
+		run.Error = err.Error()
+		log.Errorf("Export %s run %d failed: %v", c.Name, c.RunId, err)
+	} else {
+		run.Rows, run.Size, run.Checksum = manifest.Rows, manifest.Size, manifest.Checksum
+		run.Object, run.Manifest = manifest.Object, objectName(c, "manifest.json")
+		log.Infof("Export %s run %d wrote %d persons to %s/%s", c.Name, c.RunId, run.Rows, c.Bucket, run.Object)
+	}
+
+	// Итог записываем и после отмены контекста, иначе запуск навсегда останется running
+	if err := s.repo.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		log.Errorf("Export %s run %d: %v", c.Name, c.RunId, err)
+	}
+}
+
+// write streams the export to the bucket while hashing it, then uploads the manifest
+func (s *Service) write(ctx context.Context, c *claim) (*models.Manifest, error) {
+	opts, err := c.Options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var filter listing.Filter
+	if c.Mode == models.ModeDelta {
+		filter.ChangedSince, filter.ChangedUntil = c.Since, &c.Until
+	}
+
+	manifest := &models.Manifest{
+		Export:  c.Name,
+		RunId:   c.RunId,
+		Mode:    c.Mode,
+		Until:   c.Until.UTC().Format(time.RFC3339Nano),
+		Format:  opts.Format,
+		Columns: opts.Columns,
+		Object:  objectName(c, opts.Format),
+	}
+	if c.Since != nil {
+		manifest.Since = c.Since.UTC().Format(time.RFC3339Nano)
+		// Первая дельта - полный снимок, удаления нужны только относительно прошлой выгрузки
+		if manifest.Deleted, err = s.repo.Deletions(ctx, *c.Since, c.Until); err != nil {
+			return nil, err
+		}
+	}
+
+	reader, writer := io.Pipe()
+	hash := sha256.New()
+	exported := make(chan error, 1)
+	go func() {
+		rows, err := s.persons.Export(ctx, io.MultiWriter(writer, hash), filter, c.Query, opts)
+		manifest.Rows = rows
+		writer.CloseWithError(err)
+		exported <- err
+	}()
+
+	size, err := s.repo.Upload(ctx, c.Bucket, manifest.Object, opts.ContentType(), reader)
+	// Если загрузка оборвалась, выгрузка не должна ждать чтения из трубы
+	reader.CloseWithError(err)
+	if exportErr := <-exported; exportErr != nil {
+		return nil, exportErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Size = size
+	manifest.Checksum = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	manifest.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if _, err := s.repo.Upload(ctx, c.Bucket, objectName(c, "manifest.json"), "application/json", bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// objectName places the files of a run under the prefix: crm/crm-nightly-20261019-020000-delta.csv
+func objectName(c *claim, extension string) string {
+	name := fmt.Sprintf("%s-%s-%s.%s", c.Name, c.Until.UTC().Format("20060102-150405"), c.Mode, extension)
+	return path.Join(c.Prefix, name)
+}
+
+// nextRun returns the next run time or nil if the schedule never fires again
+func nextRun(spec schedule.Schedule, after time.Time) *time.Time {
+	next := spec.Next(after)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// parseDefinition checks a request and fills the defaults: full mode, enabled, CSV with the default columns
+func parseDefinition(request models.DefinitionRequest) (models.Definition, schedule.Schedule, error) {
+	definition := models.Definition{
+		Name:     strings.TrimSpace(request.Name),
+		Query:    request.Query,
+		Schedule: strings.TrimSpace(request.Schedule),
+		Mode:     strings.ToLower(strings.TrimSpace(request.Mode)),
+		Bucket:   strings.TrimSpace(request.Bucket),
+		Prefix:   strings.Trim(strings.TrimSpace(request.Prefix), "/"),
+		Enabled:  request.Enabled == nil || *request.Enabled,
+	}
+	if !namePattern.MatchString(definition.Name) {
+		return definition, nil, fmt.Errorf("%w: name may contain only latin letters, digits, '.', '_' and '-'", ErrInvalid)
+	}
+	if definition.Mode == "" {
+		definition.Mode = models.ModeFull
+	}
+	if definition.Mode != models.ModeFull && definition.Mode != models.ModeDelta {
+		return definition, nil, fmt.Errorf("%w: mode must be full or delta", ErrInvalid)
+	}
+	if definition.Bucket == "" {
+		return definition, nil, fmt.Errorf("%w: bucket is required", ErrInvalid)
+	}
+
+	spec, err := schedule.Parse(definition.Schedule)
+	if err != nil {
+		return definition, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if definition.Query != nil {
+		if _, err := query.Compile(*definition.Query, nil); err != nil {
+			return definition, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+	opts, err := request.Options.Validate()
+	if err != nil {
+		return definition, nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	definition.Options = &opts
+	return definition, spec, nil
+}
@@ -0,0 +1,48 @@
+package person
+
+import (
+	"context"
+	"log"
+	"service/internal/domains/person/models"
+	"strconv"
+)
+
+// Теги кэша: результаты поиска зависят от любых записей, карточка персоны - от нее самой и ее мастер-записи
+const (
+	// tagSearch marks every search, listing and count result
+	tagSearch = "search"
+	// tagPersons marks every person lookup, for writes that cannot tell which persons they touched
+	tagPersons = "persons"
+)
+
+func tagPerson(id int) string {
+	return "person:" + strconv.Itoa(id)
+}
+
+func tagMaster(id int) string {
+	return "master:" + strconv.Itoa(id)
+}
+
+func personTags(person models.Person) []string {
+	tags := []string{tagPersons, tagPerson(person.Id)}
+	if person.MasterId != 0 {
+		tags = append(tags, tagMaster(person.MasterId))
+	}
+	return tags
+}
+
+// Invalidate drops the cached results of the tags. A failure is logged: the entries expire with their TTL
+func (r *Repository) Invalidate(ctx context.Context, tags ...string) {
+	if err := r.cache.Invalidate(ctx, tags...); err != nil {
+		log.Printf("Failed to invalidate cache %v: %v", tags, err)
+	}
+}
+
+// invalidateMasters drops the searches and the lookups of the members of the masters
+func (r *Repository) invalidateMasters(ctx context.Context, masterIds ...int) {
+	tags := []string{tagSearch}
+	for _, id := range masterIds {
+		tags = append(tags, tagMaster(id))
+	}
+	r.Invalidate(ctx, tags...)
+}
@@ -1,8 +1,23 @@
 package person
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"net/http"
+	"service/internal/domains/person/export"
+	"service/internal/domains/person/listing"
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/parser"
+	"service/internal/domains/person/query"
+	"service/internal/domains/person/stats"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type Controller struct {
@@ -19,9 +34,23 @@ func (c *Controller) Endpoints(r *gin.Engine) {
 	r.POST("/person/upload", c.UploadFile)
 	r.GET("/person/find", c.FindPerson)
 	r.POST("/person/upload/ai/csv", c.UploadCSVWithAi)
+	r.POST("/person/upload/mapping", c.ProposeMapping)
 	r.GET("/persons", c.ListPersons)
+	r.POST("/persons/search", c.Search)
+	r.GET("/persons/search/fields", c.SearchFields)
+	r.GET("/persons/by-identifier", c.FindByIdentifier)
+	r.GET("/persons/export", c.Export)
+	r.GET("/persons/quality", c.Quality)
+	r.GET("/persons/stats", c.Stats)
+	r.GET("/persons/:id", c.GetPerson)
+	r.GET("/persons/:id/sources", c.Sources)
+	r.GET("/persons/:id/history", c.History)
+	r.POST("/persons/merge", c.Merge)
+	r.POST("/persons/:id/unmerge", c.Unmerge)
 }
 
+// UploadFile imports a file. The partner is identified by the "source" form field,
+// otherwise the source is inferred from the file name
 func (c *Controller) UploadFile(ctx *gin.Context) {
 	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
@@ -30,33 +59,69 @@ func (c *Controller) UploadFile(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	filename := header.Filename
+	upload := models.Upload{SourceId: ctx.PostForm("source"), FileName: header.Filename}
 
 	// Process the file based on its type
-	if err := c.svc.ProcessFile(ctx.Request.Context(), file, filename); err != nil {
+	result, err := c.svc.ProcessFile(ctx.Request.Context(), file, upload)
+	if errors.Is(err, ErrInvalidSource) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "File successfully uploaded and processed"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "File successfully uploaded and processed", "result": result})
 }
 
 func (c *Controller) UploadCSVWithAi(ctx *gin.Context) {
-	file, _, err := ctx.Request.FormFile("file")
+	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Файл не найден"})
 		return
 	}
 	defer file.Close()
 
-	if err := c.svc.ParseAndSaveCSVWithAi(ctx.Request.Context(), file); err != nil {
+	upload := models.Upload{SourceId: ctx.PostForm("source"), FileName: header.Filename}
+
+	result, err := c.svc.UploadCSVWithAi(ctx.Request.Context(), file, upload)
+	if errors.Is(err, ErrInvalidSource) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "CSV файл успешно загружен и обработан"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "CSV файл успешно загружен и обработан", "result": result})
 }
 
+// ProposeMapping shows the detected column mapping of a file without importing it
+func (c *Controller) ProposeMapping(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File not found"})
+		return
+	}
+	defer file.Close()
+
+	m, err := c.svc.ProposeMapping(file, header.Filename)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"mapping": m})
+}
+
+// FindPerson searches by field and value. Without mode the value is a substring of the field
+// (or of any field). mode=fts|trigram|prefix|name switches to the indexed searches ranked by score,
+// threshold sets the trigram similarity, limit the number of hits. mode=name tolerates scripts,
+// look-alike letters, transliteration, phonetic spelling and max_distance typos per word, each hit
+// explains its match.
+// explain=true only returns the plan of the search with the indexes it used
 func (c *Controller) FindPerson(ctx *gin.Context) {
 	field := ctx.Query("field")
 	value := ctx.Query("value")
@@ -66,7 +131,50 @@ func (c *Controller) FindPerson(ctx *gin.Context) {
 		return
 	}
 
-	persons, err := c.svc.FindPerson(ctx.Request.Context(), field, value)
+	asOf, err := parseAsOf(ctx.Query("as_of"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := models.TextSearch{Mode: ctx.Query("mode"), Field: field, Value: value}
+	if req.Explain, err = strconv.ParseBool(ctx.DefaultQuery("explain", "false")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный explain"})
+		return
+	}
+	if v := ctx.Query("threshold"); v != "" {
+		if req.Threshold, err = strconv.ParseFloat(v, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный threshold"})
+			return
+		}
+	}
+	if v := ctx.Query("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil || req.Limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+			return
+		}
+	}
+	if req.MaxDistance, err = strconv.Atoi(ctx.DefaultQuery("max_distance", "-1")); err != nil || req.MaxDistance < -1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный max_distance"})
+		return
+	}
+
+	if req.Mode != "" || req.Explain {
+		hits, explain, err := c.svc.TextSearch(ctx.Request.Context(), req, asOf)
+		switch {
+		case errors.Is(err, query.ErrInvalid):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		case explain != nil:
+			ctx.JSON(http.StatusOK, gin.H{"explain": explain})
+		default:
+			ctx.JSON(http.StatusOK, gin.H{"persons": hits})
+		}
+		return
+	}
+
+	persons, err := c.svc.FindPerson(ctx.Request.Context(), field, value, asOf)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -75,12 +183,405 @@ func (c *Controller) FindPerson(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"persons": persons})
 }
 
+// Export streams the persons matching the filters of GET /persons and an optional structured query
+// (query={"field": "surname", "op": "prefix", "value": "Иван"}, see Search) as a file:
+//
+//	format=csv|xlsx|json|ndjson, columns=id,fio,phone,attr.city, mask=phone,snils,
+//	delimiter=;|tab (csv), encoding=utf-8|utf-8-bom|windows-1251 (csv)
+func (c *Controller) Export(ctx *gin.Context) {
+	req, err := parseListRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var node *query.Node
+	if value := ctx.Query("query"); value != "" {
+		node = &query.Node{}
+		if err := json.Unmarshal([]byte(value), node); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный query: " + err.Error()})
+			return
+		}
+	}
+	opts, err := export.Options{
+		Format:    ctx.DefaultQuery("format", export.FormatCSV),
+		Columns:   splitList(ctx.QueryArray("columns")),
+		Mask:      splitList(ctx.QueryArray("mask")),
+		Delimiter: ctx.Query("delimiter"),
+		Encoding:  ctx.Query("encoding"),
+	}.Validate()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("persons-%s.%s", time.Now().Format("20060102-150405"), opts.Format)
+	ctx.Header("Content-Type", opts.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+
+	_, err = c.svc.Export(ctx.Request.Context(), ctx.Writer, req.Filter, node, opts)
+	if err == nil {
+		return
+	}
+	if ctx.Writer.Written() {
+		// Часть файла уже отправлена: статус не изменить, обрываем соединение, чтобы клиент
+		// не принял усеченный файл за целый
+		log.Errorf("Export interrupted: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+	ctx.Writer.Header().Del("Content-Type")
+	ctx.Writer.Header().Del("Content-Disposition")
+	switch {
+	case errors.Is(err, query.ErrInvalid), errors.Is(err, listing.ErrInvalid), errors.Is(err, export.ErrTooManyRows):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// splitList joins repeated and comma-separated query values: has=phone&has=snils or has=phone,snils
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// FindByIdentifier finds the records with an identifier written in any format:
+// type=phone|snils|inn|passport, value="8 (912) 618-26-85", as_of
+func (c *Controller) FindByIdentifier(ctx *gin.Context) {
+	asOf, err := parseAsOf(ctx.Query("as_of"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lookup, err := c.svc.FindByIdentifier(ctx.Request.Context(), ctx.Query("type"), ctx.Query("value"), asOf)
+	if errors.Is(err, ErrInvalidIdentifier) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lookup)
+}
+
+// ListPersons returns a page of persons.
+//
+//	limit, cursor (next_cursor/prev_cursor of the previous response), sort=name|-created_at|birth_date|id,
+//	count=estimated|exact|none, <field>=<exact value>, birth_from, birth_to, has=phone,snils, lacks=inn,
+//	import_batch_id, source, attr.<key>=<value>, as_of (the records known at that moment),
+//	changed_since (RFC 3339, the records changed after that moment)
 func (c *Controller) ListPersons(ctx *gin.Context) {
-	persons, err := c.svc.ListPersons(ctx.Request.Context())
+	req, err := parseListRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.svc.ListPersons(ctx.Request.Context(), req, ctx.Query("count"))
+	if errors.Is(err, listing.ErrInvalid) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"persons": persons})
+	ctx.JSON(http.StatusOK, page)
+}
+
+// Search runs a structured query, see query.Node:
+//
+//	{"query": {"and": [{"field": "surname", "op": "equals", "value": "Иванов"},
+//	                   {"field": "phone", "op": "suffix", "value": "4567"}]},
+//	 "limit": 50, "cursor": "", "count": "estimated", "as_of": ""}
+func (c *Controller) Search(ctx *gin.Context) {
+	var req query.Request
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	asOf, err := parseAsOf(req.AsOf)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.svc.Search(ctx.Request.Context(), req, asOf)
+	if errors.Is(err, query.ErrInvalid) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// SearchFields lists the searchable fields and their operators
+func (c *Controller) SearchFields(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"fields": query.Fields(), "attribute_prefix": query.AttributePrefix})
+}
+
+func parseListRequest(ctx *gin.Context) (listing.Request, error) {
+	var req listing.Request
+	var err error
+
+	if value := ctx.Query("limit"); value != "" {
+		if req.Limit, err = strconv.Atoi(value); err != nil || req.Limit <= 0 {
+			return req, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+	if req.Sort, err = listing.ParseSort(ctx.Query("sort")); err != nil {
+		return req, err
+	}
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := listing.DecodeCursor(value)
+		if err != nil {
+			return req, err
+		}
+		req.Cursor = &cursor
+	}
+
+	filter := &req.Filter
+	if filter.AsOf, err = parseAsOf(ctx.Query("as_of")); err != nil {
+		return req, err
+	}
+	if value := ctx.Query("changed_since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return req, fmt.Errorf("invalid changed_since: %s", value)
+		}
+		filter.ChangedSince = &since
+	}
+	for _, bound := range []struct {
+		param string
+		date  **time.Time
+	}{{"birth_from", &filter.BirthFrom}, {"birth_to", &filter.BirthTo}} {
+		if value := ctx.Query(bound.param); value != "" {
+			date, ok := parser.ParseDate(value)
+			if !ok {
+				return req, fmt.Errorf("invalid %s: %s", bound.param, value)
+			}
+			*bound.date = &date
+		}
+	}
+	if value := ctx.Query("import_batch_id"); value != "" {
+		if _, err := uuid.Parse(value); err != nil {
+			return req, fmt.Errorf("invalid import_batch_id: %s", value)
+		}
+		filter.ImportBatchId = value
+	}
+	filter.SourceId = ctx.Query("source")
+
+	for _, field := range listing.FilterFields() {
+		if value, ok := ctx.GetQuery(field); ok {
+			if filter.Equals == nil {
+				filter.Equals = make(map[string]string)
+			}
+			filter.Equals[field] = value
+		}
+	}
+	filter.Has = splitList(ctx.QueryArray("has"))
+	filter.Lacks = splitList(ctx.QueryArray("lacks"))
+
+	for param, values := range ctx.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(param, AttributePrefix); ok && key != "" && len(values) > 0 {
+			if filter.Attributes == nil {
+				filter.Attributes = make(map[string]string)
+			}
+			filter.Attributes[key] = values[0]
+		}
+	}
+	return req, nil
+}
+
+// Stats counts the persons by up to four dimensions, filtered like GET /persons:
+//
+//	group_by=region,birth_decade (region, region.address, region.passport, region.phone, birth_year,
+//	birth_decade, gender, source, quality, quality.<field>), birth_bucket=5 (years in a birth_year group),
+//	min_group_size=10 (hides the groups of fewer people)
+func (c *Controller) Stats(ctx *gin.Context) {
+	req, err := parseListRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	statsReq := stats.Request{GroupBy: splitList(ctx.QueryArray("group_by"))}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"birth_bucket", &statsReq.BirthBucket}, {"min_group_size", &statsReq.MinGroupSize}} {
+		if value := ctx.Query(param.name); value != "" {
+			if *param.value, err = strconv.Atoi(value); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %s", param.name, value)})
+				return
+			}
+		}
+	}
+
+	result, err := c.svc.Stats(ctx.Request.Context(), req.Filter, statsReq)
+	if errors.Is(err, stats.ErrInvalid) || errors.Is(err, listing.ErrInvalid) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *Controller) Quality(ctx *gin.Context) {
+	report, err := c.svc.QualityReport(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetPerson returns a source record by id
+func (c *Controller) GetPerson(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор"})
+		return
+	}
+
+	person, err := c.svc.GetPerson(ctx.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// Sources lists the source records resolved into the same master as the person
+func (c *Controller) Sources(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор"})
+		return
+	}
+
+	sources, err := c.svc.PersonSources(ctx.Request.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sources)
+}
+
+// Merge joins several records into one master, optionally choosing golden values per field
+func (c *Controller) Merge(ctx *gin.Context) {
+	var request models.MergeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit, err := c.svc.Merge(ctx.Request.Context(), request)
+	switch {
+	case errors.Is(err, ErrInvalidMerge):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"audit": audit})
+	}
+}
+
+// Unmerge restores the records merged into the person's master
+func (c *Controller) Unmerge(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор"})
+		return
+	}
+
+	var request models.UnmergeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit, err := c.svc.Unmerge(ctx.Request.Context(), id, request)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена"})
+	case errors.Is(err, ErrNothingToUnmerge):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"audit": audit})
+	}
+}
+
+// History returns the field versions of the person's master: ?as_of= gives the state at a moment
+func (c *Controller) History(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор"})
+		return
+	}
+	asOf, err := parseAsOf(ctx.Query("as_of"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := c.svc.History(ctx.Request.Context(), id, asOf)
+	if errors.Is(err, ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Запись не найдена"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// parseAsOf reads an as_of timestamp. A date without time means the end of that day
+func parseAsOf(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, ok := parser.ParseDate(value); ok {
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+		}
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid as_of: %s", value)
 }
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"fmt"
+	"service/internal/domains/person/models"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AttributePrefix selects an attribute column: "attr.city"
+const AttributePrefix = "attr."
+
+var columns = map[string]func(p models.Person) string{
+	"id":         func(p models.Person) string { return strconv.Itoa(p.Id) },
+	"fio":        func(p models.Person) string { return p.Fio },
+	"surname":    func(p models.Person) string { return p.Surname },
+	"first_name": func(p models.Person) string { return p.FirstName },
+	"patronymic": func(p models.Person) string { return p.Patronymic },
+	"gender":     func(p models.Person) string { return p.Gender },
+	"birth_date": func(p models.Person) string { return p.BirthDate },
+	"birth_day":  func(p models.Person) string { return p.BirthDay },
+	"phone":      func(p models.Person) string { return p.Phone },
+	"phones": func(p models.Person) string {
+		values := make([]string, len(p.Phones))
+		for i, phone := range p.Phones {
+			values[i] = phone.Value
+		}
+		return strings.Join(values, "; ")
+	},
+	"snils":    func(p models.Person) string { return p.Snils },
+	"inn":      func(p models.Person) string { return p.Inn },
+	"passport": func(p models.Person) string { return p.Passport },
+	"address":  func(p models.Person) string { return p.Address },
+	"master_id": func(p models.Person) string {
+		if p.MasterId == 0 {
+			return ""
+		}
+		return strconv.Itoa(p.MasterId)
+	},
+	"source_id":       func(p models.Person) string { return p.Provenance.SourceId },
+	"source_file":     func(p models.Person) string { return p.Provenance.SourceFile },
+	"import_batch_id": func(p models.Person) string { return p.ImportBatchId },
+	"quality_score":   func(p models.Person) string { return strconv.FormatFloat(p.QualityScore, 'f', -1, 64) },
+	"imported_at":     func(p models.Person) string { return p.Provenance.ImportedAt },
+}
+
+// DefaultColumns are exported when the request does not choose columns
+var DefaultColumns = []string{"id", "fio", "surname", "first_name", "patronymic", "gender", "birth_date",
+	"phone", "snils", "inn", "passport", "address", "source_id"}
+
+// numericColumns are written to XLSX as numbers
+var numericColumns = []string{"id", "master_id", "quality_score"}
+
+// Columns lists the columns that can be exported, attributes aside
+func Columns() []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func validColumn(name string) bool {
+	if key, ok := strings.CutPrefix(name, AttributePrefix); ok {
+		return key != ""
+	}
+	_, ok := columns[name]
+	return ok
+}
+
+// Value returns the column of the person as text
+func Value(p models.Person, column string) string {
+	if key, ok := strings.CutPrefix(column, AttributePrefix); ok {
+		return p.Attributes[key]
+	}
+	if value, ok := columns[column]; ok {
+		return value(p)
+	}
+	return ""
+}
+
+// Identifier columns keep their last digits when masked, dates keep the year
+var (
+	identifierColumns = []string{"phone", "phones", "snils", "inn", "passport"}
+	dateColumns       = []string{"birth_date", "birth_day"}
+)
+
+// maskedDigits is how many trailing digits of an identifier stay visible
+const maskedDigits = 4
+
+// Mask hides a value: identifiers keep their last four digits ("+7*******2685"), dates keep the year,
+// text keeps the first letter of each word ("И***** И***")
+func Mask(column, value string) string {
+	switch {
+	case value == "":
+		return ""
+	case slices.Contains(identifierColumns, column):
+		digits := 0
+		for _, r := range value {
+			if unicode.IsDigit(r) {
+				digits++
+			}
+		}
+		var b strings.Builder
+		for _, r := range value {
+			if unicode.IsDigit(r) {
+				digits--
+				if digits >= maskedDigits {
+					r = '*'
+				}
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	case slices.Contains(dateColumns, column):
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool { return !unicode.IsDigit(r) }) {
+			if len(part) == 4 {
+				return part
+			}
+		}
+		return "****"
+	}
+
+	var b strings.Builder
+	first := true
+	for _, r := range value {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			first = true
+		case first:
+			first = false
+		default:
+			r = '*'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// row renders the selected columns of the person, masking the requested ones
+func (o Options) row(p models.Person) []string {
+	values := make([]string, len(o.Columns))
+	for i, column := range o.Columns {
+		values[i] = Value(p, column)
+		if slices.Contains(o.Mask, column) {
+			values[i] = Mask(column, values[i])
+		}
+	}
+	return values
+}
+
+func checkColumns(names []string, what string) error {
+	for _, name := range names {
+		if !validColumn(name) {
+			return fmt.Errorf("%w: unknown %s column %q", ErrInvalid, what, name)
+		}
+	}
+	return nil
+}
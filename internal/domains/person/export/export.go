@@ -0,0 +1,340 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+	"io"
+	"service/internal/domains/person/models"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// CSV encodings: Excel opens UTF-8 files correctly only with the byte order mark
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingCP1251  = "windows-1251"
+)
+
+// MaxXLSXRows is the sheet row limit of Excel, the header included
+const MaxXLSXRows = 1048576
+
+var (
+	// ErrInvalid is returned for export options that cannot be applied
+	ErrInvalid = errors.New("invalid export options")
+	// ErrTooManyRows is returned when the rows do not fit into an XLSX sheet
+	ErrTooManyRows = errors.New("too many rows for an XLSX sheet")
+)
+
+var formats = map[string]struct {
+	contentType string
+}{
+	FormatCSV:    {"text/csv"},
+	FormatXLSX:   {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	FormatJSON:   {"application/json"},
+	FormatNDJSON: {"application/x-ndjson"},
+}
+
+// Options describe the file: its format, columns in order, masked columns and the CSV dialect
+type Options struct {
+	Format    string   `json:"format"`
+	Columns   []string `json:"columns,omitempty"`
+	Mask      []string `json:"mask,omitempty"`
+	Delimiter string   `json:"delimiter,omitempty"`
+	Encoding  string   `json:"encoding,omitempty"`
+}
+
+// Validate checks the options and fills the defaults: CSV, the default columns, comma and UTF-8
+func (o Options) Validate() (Options, error) {
+	if o.Format == "" {
+		o.Format = FormatCSV
+	}
+	if _, ok := formats[o.Format]; !ok {
+		return o, fmt.Errorf("%w: unknown format %q, use csv, xlsx, json or ndjson", ErrInvalid, o.Format)
+	}
+	if len(o.Columns) == 0 {
+		o.Columns = DefaultColumns
+	}
+	if err := checkColumns(o.Columns, "export"); err != nil {
+		return o, err
+	}
+	if err := checkColumns(o.Mask, "masked"); err != nil {
+		return o, err
+	}
+
+	if o.Format != FormatCSV {
+		if o.Delimiter != "" || o.Encoding != "" {
+			return o, fmt.Errorf("%w: delimiter and encoding apply to csv only", ErrInvalid)
+		}
+		return o, nil
+	}
+	if _, err := o.comma(); err != nil {
+		return o, err
+	}
+	switch strings.ToLower(o.Encoding) {
+	case "", "utf8", EncodingUTF8:
+		o.Encoding = EncodingUTF8
+	case "utf8-bom", EncodingUTF8BOM:
+		o.Encoding = EncodingUTF8BOM
+	case "cp1251", EncodingCP1251:
+		o.Encoding = EncodingCP1251
+	default:
+		return o, fmt.Errorf("%w: unknown encoding %q, use utf-8, utf-8-bom or windows-1251", ErrInvalid, o.Encoding)
+	}
+	return o, nil
+}
+
+func (o Options) comma() (rune, error) {
+	switch o.Delimiter {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(o.Delimiter)
+	if size != len(o.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("%w: delimiter must be one character other than a quote or a line break", ErrInvalid)
+	}
+	return r, nil
+}
+
+// ContentType is the MIME type of the format
+func (o Options) ContentType() string {
+	contentType := formats[o.Format].contentType
+	if o.Format == FormatCSV && o.Encoding == EncodingCP1251 {
+		return contentType + "; charset=windows-1251"
+	}
+	if o.Format != FormatXLSX {
+		return contentType + "; charset=utf-8"
+	}
+	return contentType
+}
+
+// Writer writes persons to a file of one format
+type Writer interface {
+	Write(p models.Person) error
+	// Close writes what is buffered; the XLSX file is only written here
+	Close() error
+}
+
+// NewWriter starts a file with validated options: the CSV and XLSX header is written at once
+func NewWriter(w io.Writer, o Options) (Writer, error) {
+	switch o.Format {
+	case FormatCSV:
+		return newCSVWriter(w, o)
+	case FormatXLSX:
+		return newXLSXWriter(w, o)
+	case FormatJSON, FormatNDJSON:
+		return &jsonWriter{w: bufio.NewWriter(w), o: o, lines: o.Format == FormatNDJSON}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalid, o.Format)
+}
+
+type csvWriter struct {
+	buf *bufio.Writer
+	enc io.WriteCloser
+	csv *csv.Writer
+	o   Options
+}
+
+func newCSVWriter(w io.Writer, o Options) (*csvWriter, error) {
+	c := &csvWriter{buf: bufio.NewWriter(w), o: o}
+	var out io.Writer = c.buf
+	switch o.Encoding {
+	case EncodingUTF8BOM:
+		if _, err := c.buf.WriteString("\uFEFF"); err != nil {
+			return nil, err
+		}
+	case EncodingCP1251:
+		// Кодировщик сам держит незавершенные последовательности UTF-8 между записями csv;
+		// символы, которых нет в Windows-1251, заменяются символом замены кодировки
+		c.enc = transform.NewWriter(c.buf, encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder()))
+		out = c.enc
+	}
+
+	comma, err := o.comma()
+	if err != nil {
+		return nil, err
+	}
+	c.csv = csv.NewWriter(out)
+	c.csv.Comma = comma
+	// Excel ждет CRLF
+	c.csv.UseCRLF = o.Encoding != EncodingUTF8
+	if err := c.csv.Write(o.Columns); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(p models.Person) error {
+	return c.csv.Write(c.o.row(p))
+}
+
+func (c *csvWriter) Close() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	if c.enc != nil {
+		if err := c.enc.Close(); err != nil {
+			return fmt.Errorf("failed to encode csv: %w", err)
+		}
+	}
+	return c.buf.Flush()
+}
+
+type jsonWriter struct {
+	w     *bufio.Writer
+	o     Options
+	lines bool
+	rows  int
+}
+
+func (j *jsonWriter) Write(p models.Person) error {
+	// Объект собирается вручную, чтобы ключи шли в порядке выбранных колонок
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, value := range j.o.row(p) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(j.o.Columns[i])
+		encoded, _ := json.Marshal(value)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(encoded)
+	}
+	b.WriteByte('}')
+
+	prefix := ","
+	switch {
+	case j.lines:
+		prefix = ""
+	case j.rows == 0:
+		prefix = "["
+	}
+	j.rows++
+	if _, err := j.w.WriteString(prefix + b.String()); err != nil {
+		return err
+	}
+	if j.lines {
+		return j.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (j *jsonWriter) Close() error {
+	if !j.lines {
+		end := "]"
+		if j.rows == 0 {
+			end = "[]"
+		}
+		if _, err := j.w.WriteString(end); err != nil {
+			return err
+		}
+	}
+	return j.w.Flush()
+}
+
+// xlsxWriter keeps the rows in the excelize stream writer, which spills them to a temporary file,
+// and writes the workbook on Close
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	o      Options
+	rows   int
+}
+
+const xlsxSheet = "Persons"
+
+func newXLSXWriter(w io.Writer, o Options) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return nil, fmt.Errorf("failed to name sheet: %w", err)
+	}
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create xlsx stream: %w", err)
+	}
+
+	style, err := file.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"4472C4"}},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "bottom", Color: "2F528F", Style: 2},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+
+	// Ширины и закрепление шапки задаются до первой строки
+	if err := stream.SetColWidth(1, len(o.Columns), 20); err != nil {
+		return nil, fmt.Errorf("failed to set column width: %w", err)
+	}
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, fmt.Errorf("failed to freeze header: %w", err)
+	}
+
+	header := make([]interface{}, len(o.Columns))
+	for i, column := range o.Columns {
+		header[i] = excelize.Cell{StyleID: style, Value: column}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return nil, fmt.Errorf("failed to write xlsx header: %w", err)
+	}
+	return &xlsxWriter{w: w, file: file, stream: stream, o: o, rows: 1}, nil
+}
+
+func (x *xlsxWriter) Write(p models.Person) error {
+	if x.rows >= MaxXLSXRows {
+		return fmt.Errorf("%w: the limit is %d", ErrTooManyRows, MaxXLSXRows-1)
+	}
+	x.rows++
+
+	values := x.o.row(p)
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+		if slices.Contains(numericColumns, x.o.Columns[i]) && !slices.Contains(x.o.Mask, x.o.Columns[i]) {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				cells[i] = number
+			}
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.rows)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to flush xlsx stream: %w", err)
+	}
+	if err := x.file.Write(x.w); err != nil {
+		return fmt.Errorf("failed to write xlsx: %w", err)
+	}
+	return nil
+}
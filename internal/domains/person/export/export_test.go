@@ -0,0 +1,111 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"service/internal/domains/person/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+)
+
+var persons = []models.Person{
+	{Id: 1, Fio: "Иванов Иван", Phone: "+7 912 618-26-85", BirthDate: "15.05.1985", Attributes: map[string]string{"city": "Москва"}},
+	{Id: 2, Fio: `Петров "Петя"`, Snils: "112-233-445 95"},
+}
+
+func write(t *testing.T, o Options) string {
+	o, err := o.Validate()
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, o)
+	assert.NoError(t, err)
+	for _, p := range persons {
+		assert.NoError(t, w.Write(p))
+	}
+	assert.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestCSV(t *testing.T) {
+	out := write(t, Options{Columns: []string{"id", "fio", "attr.city"}, Delimiter: ";"})
+	assert.Equal(t, "id;fio;attr.city\n1;Иванов Иван;Москва\n2;\"Петров \"\"Петя\"\"\";\n", out)
+
+	out = write(t, Options{Columns: []string{"fio"}, Encoding: "utf-8-bom", Delimiter: "tab"})
+	assert.True(t, strings.HasPrefix(out, "\uFEFFfio\r\n"))
+
+	out = write(t, Options{Columns: []string{"fio"}, Encoding: "cp1251"})
+	decoded, err := charmap.Windows1251.NewDecoder().String(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "fio\r\nИванов Иван\r\n\"Петров \"\"Петя\"\"\"\r\n", decoded)
+}
+
+func TestCSVCP1251Large(t *testing.T) {
+	// Больше буфера csv (4 КБ): границы записей попадают внутрь двухбайтовых букв
+	o, err := Options{Columns: []string{"fio"}, Encoding: "cp1251"}.Validate()
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, o)
+	assert.NoError(t, err)
+	var expected strings.Builder
+	expected.WriteString("fio\r\n")
+	for i := 0; i < 1000; i++ {
+		fio := strings.Repeat("Щ", i%7+1) + " Ёлкина"
+		assert.NoError(t, w.Write(models.Person{Fio: fio}))
+		expected.WriteString(fio + "\r\n")
+	}
+	assert.NoError(t, w.Close())
+	assert.Greater(t, buf.Len(), 8192)
+
+	decoded, err := charmap.Windows1251.NewDecoder().String(buf.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), decoded)
+}
+
+func TestJSON(t *testing.T) {
+	out := write(t, Options{Format: FormatJSON, Columns: []string{"id", "phone"}, Mask: []string{"phone"}})
+	assert.Equal(t, `[{"id":"1","phone":"+* *** ***-26-85"},{"id":"2","phone":""}]`, out)
+
+	out = write(t, Options{Format: FormatNDJSON, Columns: []string{"snils"}})
+	assert.Equal(t, "{\"snils\":\"\"}\n{\"snils\":\"112-233-445 95\"}\n", out)
+}
+
+func TestXLSX(t *testing.T) {
+	out := write(t, Options{Format: FormatXLSX, Columns: []string{"id", "fio"}})
+	f, err := excelize.OpenReader(strings.NewReader(out))
+	assert.NoError(t, err)
+	rows, err := f.GetRows(xlsxSheet)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"id", "fio"}, {"1", "Иванов Иван"}, {"2", `Петров "Петя"`}}, rows)
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "И***** И***", Mask("fio", "Иванов Иван"))
+	assert.Equal(t, "***-***-*45 95", Mask("snils", "112-233-445 95"))
+	assert.Equal(t, "1985", Mask("birth_date", "15.05.1985"))
+	assert.Equal(t, "", Mask("inn", ""))
+}
+
+func TestValidate(t *testing.T) {
+	o, err := Options{}.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, o.Format)
+	assert.Equal(t, DefaultColumns, o.Columns)
+	assert.Equal(t, "text/csv; charset=utf-8", o.ContentType())
+
+	for name, o := range map[string]Options{
+		"format":         {Format: "xml"},
+		"column":         {Columns: []string{"password"}},
+		"mask":           {Mask: []string{"attr."}},
+		"delimiter":      {Delimiter: `"`},
+		"long delimiter": {Delimiter: ";;"},
+		"encoding":       {Encoding: "koi8-r"},
+		"json encoding":  {Format: FormatJSON, Encoding: "cp1251"},
+	} {
+		_, err := o.Validate()
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+}
@@ -0,0 +1,338 @@
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"service/internal/domains/person/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes used when the configuration does not set them
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+)
+
+// ErrInvalid is returned for an unknown sort or filter field, a bad cursor or page size
+var ErrInvalid = errors.New("invalid listing parameters")
+
+// Fields that can be filtered by equality and presence (has/lacks)
+var filterFields = []string{
+	"fio", "surname", "first_name", "patronymic", "gender", "phone", "snils", "inn", "passport", "birth_date", "address",
+}
+
+// FilterFields lists the fields that can be filtered by equality and presence
+func FilterFields() []string {
+	return slices.Clone(filterFields)
+}
+
+type column struct {
+	expr string
+	cast string
+}
+
+// sortKey lists the columns of an index and how to read their values from a row for the cursor
+type sortKey struct {
+	columns []column
+	values  func(p models.Person) []string
+}
+
+// noBirthDate stands for an unknown birth date, so that keyset comparison never meets NULL
+const noBirthDate = "0001-01-01"
+
+var sorts = map[string]sortKey{
+	"id": {
+		columns: []column{{"id", "int"}},
+		values:  func(p models.Person) []string { return []string{strconv.Itoa(p.Id)} },
+	},
+	"name": {
+		columns: []column{
+			{"COALESCE(surname, '')", "text"},
+			{"COALESCE(first_name, '')", "text"},
+			{"COALESCE(patronymic, '')", "text"},
+			{"id", "int"},
+		},
+		values: func(p models.Person) []string {
+			return []string{p.Surname, p.FirstName, p.Patronymic, strconv.Itoa(p.Id)}
+		},
+	},
+	"created_at": {
+		columns: []column{{"created_at", "timestamptz"}, {"id", "int"}},
+		values:  func(p models.Person) []string { return []string{p.Provenance.ImportedAt, strconv.Itoa(p.Id)} },
+	},
+	"birth_date": {
+		columns: []column{{"COALESCE(birth_day, DATE '" + noBirthDate + "')", "date"}, {"id", "int"}},
+		values: func(p models.Person) []string {
+			day := p.BirthDay
+			if day == "" {
+				day = noBirthDate
+			}
+			return []string{day, strconv.Itoa(p.Id)}
+		},
+	},
+}
+
+// Sort is the order of a listing: a field backed by an index and the direction
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses "name" or "-created_at"; an empty value sorts by name
+func ParseSort(value string) (Sort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Sort{Field: "name"}, nil
+	}
+	sort := Sort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+	if _, ok := sorts[sort.Field]; !ok {
+		return Sort{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalid, sort.Field)
+	}
+	return sort, nil
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Cursor points at the row a page starts after (or, going back, ends before)
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Encode returns the opaque cursor string passed to the client
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor received from the client
+func DecodeCursor(value string) (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	return cursor, nil
+}
+
+// Filter restricts the listed persons. All conditions must hold
+type Filter struct {
+	// Equals holds exact field values
+	Equals map[string]string
+	// BirthFrom and BirthTo bound the birth date, both inclusive
+	BirthFrom *time.Time
+	BirthTo   *time.Time
+	// Has and Lacks list fields that must be filled or empty
+	Has   []string
+	Lacks []string
+
+	ImportBatchId string
+	SourceId      string
+	// Attributes holds exact attribute values
+	Attributes map[string]string
+	// AsOf limits the listing to records imported by then
+	AsOf *time.Time
+	// ChangedSince and ChangedUntil bound the time of the last change, exclusive and inclusive
+	ChangedSince *time.Time
+	ChangedUntil *time.Time
+}
+
+// Empty reports whether the filter has no conditions
+func (f Filter) Empty() bool {
+	return len(f.Equals) == 0 && f.BirthFrom == nil && f.BirthTo == nil && len(f.Has) == 0 && len(f.Lacks) == 0 &&
+		f.ImportBatchId == "" && f.SourceId == "" && len(f.Attributes) == 0 && f.AsOf == nil &&
+		f.ChangedSince == nil && f.ChangedUntil == nil
+}
+
+// Conditions compiles the filter into SQL conditions; values are appended to args as parameters
+func (f Filter) Conditions(args *[]interface{}) ([]string, error) {
+	var conditions []string
+	param := func(value interface{}) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+
+	// Порядок полей фиксирован, чтобы запрос не зависел от обхода map
+	for _, field := range filterFields {
+		if value, ok := f.Equals[field]; ok {
+			conditions = append(conditions, fmt.Sprintf("%s = %s", field, param(value)))
+		}
+	}
+	for field := range f.Equals {
+		if !slices.Contains(filterFields, field) {
+			return nil, fmt.Errorf("%w: unknown filter field %q", ErrInvalid, field)
+		}
+	}
+	for _, field := range f.Has {
+		if !slices.Contains(filterFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalid, field)
+		}
+		conditions = append(conditions, fmt.Sprintf("COALESCE(%s, '') <> ''", field))
+	}
+	for _, field := range f.Lacks {
+		if !slices.Contains(filterFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalid, field)
+		}
+		conditions = append(conditions, fmt.Sprintf("COALESCE(%s, '') = ''", field))
+	}
+
+	if f.BirthFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("birth_day >= %s::date", param(f.BirthFrom.Format("2006-01-02"))))
+	}
+	if f.BirthTo != nil {
+		conditions = append(conditions, fmt.Sprintf("birth_day <= %s::date", param(f.BirthTo.Format("2006-01-02"))))
+	}
+	if f.ImportBatchId != "" {
+		conditions = append(conditions, fmt.Sprintf("import_batch_id = %s::uuid", param(f.ImportBatchId)))
+	}
+	if f.SourceId != "" {
+		conditions = append(conditions, fmt.Sprintf("source_id = %s", param(f.SourceId)))
+	}
+	if len(f.Attributes) > 0 {
+		// Вхождение jsonb (@>) использует GIN-индекс по attributes
+		conditions = append(conditions, fmt.Sprintf("attributes @> %s", param(f.Attributes)))
+	}
+	if f.AsOf != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at <= %s", param(*f.AsOf)))
+	}
+	if f.ChangedSince != nil {
+		conditions = append(conditions, fmt.Sprintf("updated_at > %s", param(*f.ChangedSince)))
+	}
+	if f.ChangedUntil != nil {
+		conditions = append(conditions, fmt.Sprintf("updated_at <= %s", param(*f.ChangedUntil)))
+	}
+	return conditions, nil
+}
+
+// Request is one page of a listing
+type Request struct {
+	Filter Filter
+	Sort   Sort
+	Limit  int
+	Cursor *Cursor
+}
+
+// Count modes of a listing
+const (
+	CountExact     = "exact"
+	CountEstimated = "estimated"
+	CountNone      = "none"
+)
+
+// Query is the compiled page query: persons matching Where and Keyset in OrderBy order, at most Limit rows.
+// Where does not include the cursor condition and takes only CountArgs, so it can be used for counting
+type Query struct {
+	Where     string
+	CountArgs []interface{}
+	Keyset    string
+	Args      []interface{}
+	OrderBy   string
+	Limit     int
+}
+
+// Build compiles the request. One row more than the page is requested to know if there is a next page
+func Build(req Request) (Query, error) {
+	key, ok := sorts[req.Sort.Field]
+	if !ok {
+		return Query{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalid, req.Sort.Field)
+	}
+	if req.Limit <= 0 {
+		return Query{}, fmt.Errorf("%w: limit must be positive", ErrInvalid)
+	}
+
+	var q Query
+	conditions, err := req.Filter.Conditions(&q.Args)
+	if err != nil {
+		return Query{}, err
+	}
+	q.Where = strings.Join(conditions, " AND ")
+	q.CountArgs = slices.Clone(q.Args)
+
+	// Назад по страницам идем в обратном порядке и разворачиваем результат
+	desc := req.Sort.Desc
+	if req.Cursor != nil && req.Cursor.Backward {
+		desc = !desc
+	}
+
+	exprs := make([]string, len(key.columns))
+	order := make([]string, len(key.columns))
+	for i, c := range key.columns {
+		exprs[i] = c.expr
+		order[i] = c.expr
+		if desc {
+			order[i] += " DESC"
+		}
+	}
+	q.OrderBy = strings.Join(order, ", ")
+
+	if req.Cursor != nil {
+		if req.Cursor.Sort != req.Sort.String() || len(req.Cursor.Values) != len(key.columns) {
+			return Query{}, fmt.Errorf("%w: cursor does not match the sort", ErrInvalid)
+		}
+		values := make([]string, len(key.columns))
+		for i, c := range key.columns {
+			q.Args = append(q.Args, req.Cursor.Values[i])
+			values[i] = fmt.Sprintf("$%d::%s", len(q.Args), c.cast)
+		}
+		operator := ">"
+		if desc {
+			operator = "<"
+		}
+		q.Keyset = fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), operator, strings.Join(values, ", "))
+	}
+
+	q.Limit = req.Limit + 1
+	return q, nil
+}
+
+// Paginate trims the rows fetched by the Build query to the page and returns the cursors
+// of the next and previous pages; an empty cursor means there is no such page
+func Paginate(rows []models.Person, req Request) (page []models.Person, next, prev string) {
+	key := sorts[req.Sort.Field]
+	cursor := func(p models.Person, backward bool) string {
+		return Cursor{Sort: req.Sort.String(), Values: key.values(p), Backward: backward}.Encode()
+	}
+
+	more := len(rows) > req.Limit
+	if more {
+		rows = rows[:req.Limit]
+	}
+	backward := req.Cursor != nil && req.Cursor.Backward
+	if backward {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	first, last := rows[0], rows[len(rows)-1]
+	if backward {
+		// Раз пришли назад, следующая страница точно есть
+		next = cursor(last, false)
+		if more {
+			prev = cursor(first, true)
+		}
+		return rows, next, prev
+	}
+	if more {
+		next = cursor(last, false)
+	}
+	if req.Cursor != nil {
+		prev = cursor(first, true)
+	}
+	return rows, next, prev
+}
@@ -0,0 +1,115 @@
+package listing
+
+import (
+	"testing"
+	"time"
+
+	"service/internal/domains/person/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	sort, err := ParseSort("-created_at")
+	assert.NoError(t, err)
+	assert.Equal(t, Sort{Field: "created_at", Desc: true}, sort)
+	assert.Equal(t, "-created_at", sort.String())
+
+	sort, err = ParseSort("")
+	assert.NoError(t, err)
+	assert.Equal(t, "name", sort.Field)
+
+	_, err = ParseSort("quality_score; DROP TABLE persons")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{Sort: "name", Values: []string{"Иванов", "Иван", "", "42"}, Backward: true}
+	decoded, err := DecodeCursor(cursor.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = DecodeCursor("not a cursor!")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestBuild(t *testing.T) {
+	from := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := Request{
+		Filter: Filter{
+			Equals:        map[string]string{"surname": "Иванов"},
+			BirthFrom:     &from,
+			Has:           []string{"phone"},
+			Lacks:         []string{"inn"},
+			ImportBatchId: "2f1f5a7e-2b4c-4d9a-9c3b-1d2e3f4a5b6c",
+		},
+		Sort:   Sort{Field: "created_at", Desc: true},
+		Limit:  20,
+		Cursor: &Cursor{Sort: "-created_at", Values: []string{"2026-01-01 10:00:00+00", "7"}},
+	}
+
+	q, err := Build(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "surname = $1 AND COALESCE(phone, '') <> '' AND COALESCE(inn, '') = '' AND "+
+		"birth_day >= $2::date AND import_batch_id = $3::uuid", q.Where)
+	assert.Equal(t, "(created_at, id) < ($4::timestamptz, $5::int)", q.Keyset)
+	assert.Equal(t, "created_at DESC, id DESC", q.OrderBy)
+	assert.Equal(t, []interface{}{"Иванов", "1980-01-01", "2f1f5a7e-2b4c-4d9a-9c3b-1d2e3f4a5b6c",
+		"2026-01-01 10:00:00+00", "7"}, q.Args)
+	assert.Equal(t, 21, q.Limit)
+
+	// Назад по убывающей сортировке - по возрастанию
+	req.Cursor.Backward = true
+	q, err = Build(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "(created_at, id) > ($4::timestamptz, $5::int)", q.Keyset)
+	assert.Equal(t, "created_at, id", q.OrderBy)
+
+	req.Cursor.Sort = "name"
+	_, err = Build(req)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	req.Cursor = nil
+	req.Filter = Filter{ChangedSince: &from}
+	q, err = Build(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated_at > $1", q.Where)
+
+	req.Filter.Equals = map[string]string{"quality_score": "1"}
+	_, err = Build(req)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestPaginate(t *testing.T) {
+	rows := func(ids ...int) []models.Person {
+		var persons []models.Person
+		for _, id := range ids {
+			persons = append(persons, models.Person{Id: id})
+		}
+		return persons
+	}
+	req := Request{Sort: Sort{Field: "id"}, Limit: 2}
+
+	page, next, prev := Paginate(rows(1, 2, 3), req)
+	assert.Equal(t, rows(1, 2), page)
+	assert.Empty(t, prev)
+	cursor, err := DecodeCursor(next)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, cursor.Values)
+
+	req.Cursor = &cursor
+	page, next, prev = Paginate(rows(3), req)
+	assert.Equal(t, rows(3), page)
+	assert.Empty(t, next)
+	cursor, err = DecodeCursor(prev)
+	assert.NoError(t, err)
+	assert.True(t, cursor.Backward)
+	assert.Equal(t, []string{"3"}, cursor.Values)
+
+	// Назад строки приходят в обратном порядке
+	req.Cursor = &cursor
+	page, next, prev = Paginate(rows(2, 1), req)
+	assert.Equal(t, rows(1, 2), page)
+	assert.NotEmpty(t, next)
+	assert.Empty(t, prev)
+}
@@ -0,0 +1,75 @@
+package mapping
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AttributeKey normalizes a source header into an attribute key:
+// lower case, letters and digits joined by "_" ("Номер заявки" -> "номер_заявки")
+func AttributeKey(header string) string {
+	words := strings.FieldsFunc(strings.ToLower(header), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "_")
+}
+
+// AddAttributes maps every column that is not stored in a person field to an attribute.
+// The application number (FieldID) has no column in persons, so it is kept as an attribute too.
+// Columns without a usable header are named by position: column_1, column_2, ...
+func (m *Mapping) AddAttributes(headers []string, width int) {
+	used := make(map[int]bool)
+	for field, index := range m.Fields {
+		if field != FieldID {
+			used[index] = true
+		}
+	}
+	for _, composite := range m.Composites {
+		for _, part := range composite.Parts {
+			used[part.Column] = true
+		}
+	}
+
+	m.Attributes = make(map[string]int)
+	for i := 0; i < width; i++ {
+		if used[i] {
+			continue
+		}
+		key := ""
+		if i < len(headers) {
+			key = AttributeKey(headers[i])
+		}
+		if key == "" {
+			key = "column_" + strconv.Itoa(i+1)
+		}
+		// Одинаковые заголовки различаем порядковым суффиксом
+		unique := key
+		for n := 2; ; n++ {
+			if _, taken := m.Attributes[unique]; !taken {
+				break
+			}
+			unique = key + "_" + strconv.Itoa(n)
+		}
+		m.Attributes[unique] = i
+	}
+}
+
+// AttributeValues returns the non-empty attribute values of a record
+func (m Mapping) AttributeValues(record []string) map[string]string {
+	var values map[string]string
+	for key, index := range m.Attributes {
+		if index >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[index])
+		if value == "" {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		values[key] = value
+	}
+	return values
+}
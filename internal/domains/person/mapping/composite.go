@@ -0,0 +1,139 @@
+package mapping
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Part is one source column of a composite field
+type Part struct {
+	Column int    `json:"column"`
+	Role   string `json:"role"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Composite assembles a field from several source columns in the order of Parts
+type Composite struct {
+	Parts     []Part `json:"parts"`
+	Separator string `json:"separator"`
+}
+
+type role struct {
+	field     string
+	name      string
+	order     int
+	prefix    string
+	variants  []string
+	separator string
+}
+
+// Роли столбцов, на которые источники разбивают ФИО, паспорт и адрес.
+// Порядок order задает порядок частей при сборке значения
+var roles = []role{
+	{field: FieldFio, name: "surname", order: 0, separator: " ", variants: []string{"фамилия", "surname", "last name", "family name", "lastname"}},
+	{field: FieldFio, name: "first_name", order: 1, separator: " ", variants: []string{"имя", "first name", "given name", "firstname"}},
+	{field: FieldFio, name: "patronymic", order: 2, separator: " ", variants: []string{"отчество", "patronymic", "middle name", "middlename"}},
+
+	{field: FieldPassport, name: "series", order: 0, separator: " ", variants: []string{"серия", "серия паспорта", "паспорт серия", "passport series", "series"}},
+	{field: FieldPassport, name: "number", order: 1, separator: " ", variants: []string{"номер паспорта", "паспорт номер", "passport number", "passport no"}},
+
+	{field: FieldAddress, name: "postal_code", order: 0, separator: ", ", variants: []string{"индекс", "почтовый индекс", "postal code", "zip", "zip code", "postcode"}},
+	{field: FieldAddress, name: "region", order: 1, separator: ", ", variants: []string{"регион", "область", "субъект", "субъект рф", "region", "state"}},
+	{field: FieldAddress, name: "district", order: 2, separator: ", ", variants: []string{"район", "district"}},
+	{field: FieldAddress, name: "city", order: 3, separator: ", ", variants: []string{"город", "населенный пункт", "населённый пункт", "city", "town", "settlement"}},
+	{field: FieldAddress, name: "street", order: 4, separator: ", ", variants: []string{"улица", "street"}},
+	{field: FieldAddress, name: "house", order: 5, separator: ", ", prefix: "д. ", variants: []string{"дом", "house", "house number", "номер дома"}},
+	{field: FieldAddress, name: "building", order: 6, separator: ", ", prefix: "к. ", variants: []string{"корпус", "building"}},
+	{field: FieldAddress, name: "structure", order: 7, separator: ", ", prefix: "стр. ", variants: []string{"строение"}},
+	{field: FieldAddress, name: "flat", order: 8, separator: ", ", prefix: "кв. ", variants: []string{"квартира", "кв", "flat", "apartment", "apt"}},
+}
+
+// Минимальное число частей, по которому раскладка считается разбитой
+var minParts = map[string]int{FieldFio: 2, FieldPassport: 2, FieldAddress: 2}
+
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	header = strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' || unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, header)
+	return strings.Join(strings.Fields(header), " ")
+}
+
+func roleOf(header string) (role, bool) {
+	header = normalizeHeader(header)
+	for _, r := range roles {
+		for _, variant := range r.variants {
+			if header == variant {
+				return r, true
+			}
+		}
+	}
+	return role{}, false
+}
+
+// detectComposites finds split layouts in the header: FIO in surname/first name/patronymic
+// columns, passport in series/number columns and address in region/city/street/house columns.
+// Fields that have a whole column of their own are not assembled
+func detectComposites(headers []string) map[string]Composite {
+	wholeColumn := make(map[string]bool)
+	found := make(map[string][]role)
+	columns := make(map[string][]int)
+
+	for i, header := range headers {
+		if r, ok := roleOf(header); ok {
+			found[r.field] = append(found[r.field], r)
+			columns[r.field] = append(columns[r.field], i)
+			continue
+		}
+		for field, score := range HeaderScores(header) {
+			if score == 1 {
+				wholeColumn[field] = true
+			}
+		}
+	}
+
+	composites := make(map[string]Composite)
+	for field, fieldRoles := range found {
+		if wholeColumn[field] || len(fieldRoles) < minParts[field] {
+			continue
+		}
+
+		composite := Composite{Separator: fieldRoles[0].separator}
+		for i, r := range fieldRoles {
+			composite.Parts = append(composite.Parts, Part{Column: columns[field][i], Role: r.name, Prefix: r.prefix})
+		}
+		order := make(map[string]int)
+		for _, r := range fieldRoles {
+			order[r.name] = r.order
+		}
+		sort.SliceStable(composite.Parts, func(a, b int) bool {
+			return order[composite.Parts[a].Role] < order[composite.Parts[b].Role]
+		})
+		composites[field] = composite
+	}
+	return composites
+}
+
+// Assemble joins the non-empty parts of a record. A prefix ("д. ") is added only if the
+// value does not start with a letter already, so "д. 5" is not turned into "д. д. 5"
+func (c Composite) Assemble(record []string) string {
+	values := make([]string, 0, len(c.Parts))
+	for _, part := range c.Parts {
+		if part.Column >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[part.Column])
+		if value == "" {
+			continue
+		}
+		if part.Prefix != "" && strings.IndexFunc(value, unicode.IsLetter) != 0 {
+			value = part.Prefix + value
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, c.Separator)
+}
@@ -0,0 +1,260 @@
+package mapping
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fields of models.Person that imported columns are mapped to
+const (
+	FieldID       = "ID"
+	FieldFio      = "Fio"
+	FieldPhone    = "Phone"
+	FieldSnils    = "Snils"
+	FieldInn      = "Inn"
+	FieldPassport = "Passport"
+	FieldBirth    = "Birth"
+	FieldAddress  = "Address"
+)
+
+var Fields = []string{FieldID, FieldFio, FieldPhone, FieldSnils, FieldInn, FieldPassport, FieldBirth, FieldAddress}
+
+const (
+	// MinConfidence is the lowest combined score at which a column is mapped
+	MinConfidence = 0.5
+	// headerWeight is the share of the header score when both scores are available
+	headerWeight = 0.4
+	// sampleSize is the number of rows profiled per column
+	sampleSize = 50
+)
+
+// Ключевые слова для поиска столбцов по заголовку
+var keywords = map[string][]string{
+	FieldID:       {"номер заявки", "номер заявления", "идентификатор", "id", "application number", "identifier"},
+	FieldFio:      {"фамилия имя отчество", "фамилия имя", "фио", "имя", "fio", "full name", "name"},
+	FieldPhone:    {"телефон", "номер телефона", "phone", "phone number"},
+	FieldSnils:    {"снилс", "страховой номер индивидуального лицевого счёта", "индивидуальный лицевой счет", "страховой номер", "snils", "insurance number"},
+	FieldInn:      {"инн", "идентификационный номер налогоплательщика", "inn", "taxpayer identification number"},
+	FieldPassport: {"паспорт", "документ удостоверяющий личность", "passport", "identity document"},
+	FieldBirth:    {"дата рождения", "день рождения", "birth date", "birthday", "date of birth"},
+	FieldAddress:  {"адрес", "address"},
+}
+
+// Column describes how a source column was mapped
+type Column struct {
+	Index       int     `json:"index"`
+	Header      string  `json:"header"`
+	Field       string  `json:"field,omitempty"`
+	Role        string  `json:"role,omitempty"`
+	HeaderScore float64 `json:"header_score"`
+	ValueScore  float64 `json:"value_score"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// Mapping is the proposed assignment of source columns to person fields.
+// A field is taken either from one column (Fields) or assembled from several (Composites);
+// the remaining columns are kept as attributes
+type Mapping struct {
+	HasHeader  bool                 `json:"has_header"`
+	Fields     map[string]int       `json:"fields"`
+	Composites map[string]Composite `json:"composites,omitempty"`
+	Attributes map[string]int       `json:"attributes,omitempty"`
+	Columns    []Column             `json:"columns"`
+}
+
+// Value returns the value of the field in a record
+func (m Mapping) Value(record []string, field string) string {
+	if composite, ok := m.Composites[field]; ok {
+		return composite.Assemble(record)
+	}
+	if index, ok := m.Fields[field]; ok && index < len(record) {
+		return record[index]
+	}
+	return ""
+}
+
+// HeaderScores scores a header against the keywords of every field:
+// 1 for an exact keyword, 0.8 if the header contains a keyword
+func HeaderScores(header string) map[string]float64 {
+	header = strings.ToLower(strings.TrimSpace(header))
+	scores := make(map[string]float64)
+	if header == "" {
+		return scores
+	}
+
+	for field, keys := range keywords {
+		for _, key := range keys {
+			if header == key {
+				scores[field] = 1
+				break
+			}
+			if containsKeyword(header, key) && scores[field] < 0.8 {
+				scores[field] = 0.8
+			}
+		}
+	}
+	return scores
+}
+
+// containsKeyword matches short keywords ("id", "инн") only as separate words,
+// so that "David" is not taken for an identifier
+func containsKeyword(header, key string) bool {
+	if utf8.RuneCountInString(key) > 3 {
+		return strings.Contains(header, key)
+	}
+	words := strings.FieldsFunc(header, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, word := range words {
+		if word == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect proposes a mapping for a table. The first row is treated as a header unless
+// it looks like data; header scores are combined with value profiles of sampled rows
+func Detect(rows [][]string) Mapping {
+	m := Mapping{Fields: make(map[string]int), Composites: make(map[string]Composite)}
+	if len(rows) == 0 {
+		return m
+	}
+
+	m.HasHeader = looksLikeHeader(rows[0])
+	var headers []string
+	data := rows
+	if m.HasHeader {
+		headers = rows[0]
+		data = rows[1:]
+		m.Composites = detectComposites(headers)
+	}
+
+	// Столбцы, из которых собираются составные поля, не сопоставляются отдельно
+	composed := make(map[int]Part)
+	for _, composite := range m.Composites {
+		for _, part := range composite.Parts {
+			composed[part.Column] = part
+		}
+	}
+
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	type candidate struct {
+		column        int
+		field         string
+		score         float64
+		header, value float64
+	}
+	var candidates []candidate
+
+	for i := 0; i < width; i++ {
+		column := Column{Index: i}
+		var headerScores map[string]float64
+		if i < len(headers) {
+			column.Header = headers[i]
+			headerScores = HeaderScores(headers[i])
+		}
+		if part, ok := composed[i]; ok {
+			column.Role = part.Role
+			m.Columns = append(m.Columns, column)
+			continue
+		}
+		valueScores, sampled := ProfileColumn(sample(data, i))
+
+		for _, field := range Fields {
+			score := combine(headerScores[field], valueScores[field], len(headerScores) > 0, sampled > 0)
+			if score > column.Confidence {
+				column.Field = field
+				column.Confidence = score
+				column.HeaderScore = headerScores[field]
+				column.ValueScore = valueScores[field]
+			}
+			if score >= MinConfidence {
+				candidates = append(candidates, candidate{column: i, field: field, score: score, header: headerScores[field], value: valueScores[field]})
+			}
+		}
+		column.Field = ""
+		m.Columns = append(m.Columns, column)
+	}
+
+	// Жадно назначаем поля: сначала самые уверенные пары столбец-поле
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	usedColumns := make(map[int]bool)
+	for _, c := range candidates {
+		if _, taken := m.Fields[c.field]; taken || usedColumns[c.column] {
+			continue
+		}
+		if _, assembled := m.Composites[c.field]; assembled {
+			continue
+		}
+		m.Fields[c.field] = c.column
+		usedColumns[c.column] = true
+		m.Columns[c.column].Field = c.field
+		m.Columns[c.column].Confidence = c.score
+		m.Columns[c.column].HeaderScore = c.header
+		m.Columns[c.column].ValueScore = c.value
+	}
+
+	for field, composite := range m.Composites {
+		for _, part := range composite.Parts {
+			m.Columns[part.Column].Field = field
+			m.Columns[part.Column].HeaderScore = 1
+			m.Columns[part.Column].Confidence = 1
+		}
+	}
+	m.AddAttributes(headers, width)
+	return m
+}
+
+// combine merges header and value scores. An exact header keyword is always trusted,
+// values can only raise its confidence
+func combine(header, value float64, hasHeader, hasValues bool) float64 {
+	switch {
+	case header == 1 && hasValues:
+		return MinConfidence + (1-MinConfidence)*value
+	case hasHeader && hasValues:
+		return headerWeight*header + (1-headerWeight)*value
+	case hasValues:
+		return value
+	default:
+		return header
+	}
+}
+
+func sample(rows [][]string, column int) []string {
+	values := make([]string, 0, sampleSize)
+	for _, row := range rows {
+		if len(values) == sampleSize {
+			break
+		}
+		if column < len(row) {
+			values = append(values, row[column])
+		}
+	}
+	return values
+}
+
+// looksLikeHeader reports whether the first row is a header: it matches header keywords
+// or fewer than two of its cells are recognized as data values
+func looksLikeHeader(row []string) bool {
+	dataCells := 0
+	for _, cell := range row {
+		if _, ok := roleOf(cell); ok || len(HeaderScores(cell)) > 0 {
+			return true
+		}
+		scores, _ := ProfileColumn([]string{cell})
+		for _, field := range Fields {
+			if field != FieldID && scores[field] >= 0.9 {
+				dataCells++
+				break
+			}
+		}
+	}
+	return dataCells < 2
+}
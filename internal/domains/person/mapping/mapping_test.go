@@ -0,0 +1,93 @@
+package mapping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectByHeaders(t *testing.T) {
+	m := Detect([][]string{
+		{"номер заявки", "фамилия имя отчество", "номер телефона", "снилс", "инн", "паспорт", "дата рождения", "адрес"},
+		{"6095", "Дементьев Эммануил Елисеевич", "+7 793 414 2384", "521172896779", "985505170057", "6864 704987", "30.06.2003", "п. Двинской, ш. Овражное, д. 878 к. 48, 921506"},
+		{"8007", "Раиса Тимуровна Денисова", "+7 (734) 214-97-34", "894834358215", "802302844378", "4571 140061", "20.11.1970", "г. Мезень, алл. Промышленная, д. 625, 923891"},
+	})
+
+	assert.True(t, m.HasHeader)
+	assert.Equal(t, map[string]int{
+		FieldID: 0, FieldFio: 1, FieldPhone: 2, FieldSnils: 3, FieldInn: 4, FieldPassport: 5, FieldBirth: 6, FieldAddress: 7,
+	}, m.Fields)
+	// Номер заявки не хранится в полях Person и сохраняется как атрибут
+	assert.Equal(t, map[string]int{"номер_заявки": 0}, m.Attributes)
+}
+
+func TestDetectHeaderless(t *testing.T) {
+	m := Detect([][]string{
+		{"Лапина Нина Петровна", "89126182685", "30.06.2003", "ст. Цимлянск, пр. Осипенко, д. 3/4 к. 1, 475314", "112-233-445 95"},
+		{"Соболева Наталья Эдуардовна", "8 828 240 56 96", "12.10.1977", "с. Северобайкальск, ш. Воровского, д. 56 к. 320, 264613", "123-456-789 64"},
+	})
+
+	assert.False(t, m.HasHeader)
+	assert.Equal(t, map[string]int{FieldFio: 0, FieldPhone: 1, FieldBirth: 2, FieldAddress: 3, FieldSnils: 4}, m.Fields)
+	assert.Empty(t, m.Attributes)
+}
+
+func TestDetectJunkHeaders(t *testing.T) {
+	// Заголовки не соответствуют содержимому: определяем по значениям
+	m := Detect([][]string{
+		{"name", "city", "phone"},
+		{"+79991234567", "Иванов Иван Иванович", "01.01.1990"},
+		{"+79998765432", "Петров Петр Петрович", "15.05.1985"},
+	})
+
+	assert.True(t, m.HasHeader)
+	assert.Equal(t, map[string]int{FieldPhone: 0, FieldFio: 1, FieldBirth: 2}, m.Fields)
+
+	// Значения не распознаются: остается только точное совпадение заголовка
+	m = Detect([][]string{
+		{"name", "city", "phone"},
+		{"Mrs.", "Prof.", "Dr."},
+		{"Dr.", "Dr.", "Dr."},
+	})
+	assert.Equal(t, map[string]int{FieldFio: 0, FieldPhone: 2}, m.Fields)
+	assert.Equal(t, map[string]int{"city": 1}, m.Attributes)
+	assert.Equal(t, map[string]string{"city": "Prof."}, m.AttributeValues([]string{"Mrs.", "Prof.", "Dr."}))
+}
+
+func TestDetectComposites(t *testing.T) {
+	rows := [][]string{
+		{"Фамилия", "Имя", "Отчество", "Серия", "Номер паспорта", "Город", "Улица", "Дом", "Квартира", "Телефон"},
+		{"Иванов", "Иван", "Иванович", "1234", "567890", "г. Москва", "ул. Ленина", "1", "15", "+79991234567"},
+		{"Петрова", "Анна", "", "4321", "098765", "Тверь", "Советская", "д. 5", "", "+79998765432"},
+	}
+	m := Detect(rows)
+
+	assert.Equal(t, map[string]int{FieldPhone: 9}, m.Fields)
+	assert.Len(t, m.Composites, 3)
+
+	assert.Equal(t, "Иванов Иван Иванович", m.Value(rows[1], FieldFio))
+	assert.Equal(t, "1234 567890", m.Value(rows[1], FieldPassport))
+	assert.Equal(t, "г. Москва, ул. Ленина, д. 1, кв. 15", m.Value(rows[1], FieldAddress))
+
+	assert.Equal(t, "Петрова Анна", m.Value(rows[2], FieldFio))
+	assert.Equal(t, "Тверь, Советская, д. 5", m.Value(rows[2], FieldAddress))
+}
+
+func TestDetectCompositeOrder(t *testing.T) {
+	// Части собираются в естественном порядке независимо от порядка столбцов
+	rows := [][]string{
+		{"first_name", "last_name", "middle name"},
+		{"Иван", "Иванов", "Иванович"},
+	}
+	m := Detect(rows)
+	assert.Equal(t, "Иванов Иван Иванович", m.Value(rows[1], FieldFio))
+}
+
+func TestAddAttributes(t *testing.T) {
+	m := Mapping{Fields: map[string]int{FieldFio: 0}}
+	m.AddAttributes([]string{"ФИО", "Город проживания", " ", "Тег", "тег"}, 6)
+
+	assert.Equal(t, map[string]int{"город_проживания": 1, "column_3": 2, "тег": 3, "тег_2": 4, "column_6": 5}, m.Attributes)
+	assert.Equal(t, map[string]string{"город_проживания": "Тверь", "column_6": "x"},
+		m.AttributeValues([]string{"Иванов Иван", "Тверь", "", "  ", "", "x"}))
+}
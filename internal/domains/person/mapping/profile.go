@@ -0,0 +1,145 @@
+package mapping
+
+import (
+	"regexp"
+	"service/internal/domains/person/parser"
+	"strings"
+	"unicode"
+)
+
+var (
+	passportRegex = regexp.MustCompile(`^\d{2}\s?\d{2}\s?\d{6}$`)
+	phoneRegex    = regexp.MustCompile(`^(\+7|8|7)?[\s\-(]*\d{3}[\s\-)]*\d{3}[\s\-]*\d{2}[\s\-]*\d{2}$`)
+	idRegex       = regexp.MustCompile(`^\d{1,9}$`)
+	addressRegex  = regexp.MustCompile(`(?i)(^|[\s,])(г|ул|д|пр|ш|алл|пер|наб|бул|обл|респ|кв|с|п|ст|пос|мкр)\.\s?`)
+	fioWordRegex  = regexp.MustCompile(`^(\p{Lu}\p{Ll}+(-\p{Lu}\p{Ll}+)?|\p{Lu}\.(\p{Lu}\.)?|оглы|кызы)$`)
+)
+
+// ProfileColumn scores sampled values against the known value patterns of every field.
+// The score of a field is the average score of non-empty values; the second result
+// is the number of non-empty values taken into account
+func ProfileColumn(values []string) (map[string]float64, int) {
+	scores := make(map[string]float64)
+	count := 0
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		count++
+		for field, score := range scoreValue(value) {
+			scores[field] += score
+		}
+	}
+	if count > 0 {
+		for field := range scores {
+			scores[field] /= float64(count)
+		}
+	}
+	return scores, count
+}
+
+func scoreValue(value string) map[string]float64 {
+	scores := make(map[string]float64)
+	digits := parser.Digits(value)
+	onlyDigits := len(digits) == len(value)
+
+	if _, ok := parser.ParseDate(value); ok {
+		scores[FieldBirth] = 1
+		return scores
+	}
+
+	switch {
+	case parser.ValidSnils(value) && !onlyDigits:
+		scores[FieldSnils] = 1
+	case parser.ValidSnils(value):
+		scores[FieldSnils] = 0.8
+	case len(digits) == 11 && strings.Count(value, "-") == 2:
+		scores[FieldSnils] = 0.6
+	}
+
+	if parser.ValidInn(value) && onlyDigits {
+		scores[FieldInn] = 0.9
+	} else if _, ok := parser.NormalizeInn(value); ok && onlyDigits {
+		scores[FieldInn] = 0.4
+	}
+
+	if phoneRegex.MatchString(value) {
+		switch {
+		case strings.HasPrefix(value, "+7") || strings.ContainsAny(value, "()"):
+			scores[FieldPhone] = 1
+		case !onlyDigits || len(digits) == 11 && digits[0] == '8':
+			scores[FieldPhone] = 0.8
+		default:
+			scores[FieldPhone] = 0.4
+		}
+	}
+
+	if passportRegex.MatchString(value) {
+		if onlyDigits {
+			scores[FieldPassport] = 0.4
+		} else {
+			scores[FieldPassport] = 1
+		}
+	}
+
+	if idRegex.MatchString(value) {
+		scores[FieldID] = 0.6
+	}
+
+	if score := fioScore(value); score > 0 {
+		scores[FieldFio] = score
+	}
+
+	if score := addressScore(value); score > 0 {
+		scores[FieldAddress] = score
+	}
+	return scores
+}
+
+// fioScore checks that the value consists of 2-4 capitalized words or initials
+func fioScore(value string) float64 {
+	if strings.IndexFunc(value, unicode.IsDigit) >= 0 {
+		return 0
+	}
+	words := strings.Fields(value)
+	if len(words) < 2 || len(words) > 4 {
+		return 0
+	}
+	for _, word := range words {
+		if !fioWordRegex.MatchString(word) {
+			return 0
+		}
+	}
+
+	name := parser.ParseFio(value)
+	if name.Patronymic != "" || name.Gender != parser.GenderUnknown {
+		return 1
+	}
+	return 0.7
+}
+
+// addressScore checks for address abbreviations and postal codes
+func addressScore(value string) float64 {
+	if !strings.ContainsAny(value, ", ") {
+		return 0
+	}
+	score := 0.0
+	if addressRegex.MatchString(value) {
+		score += 0.6
+	}
+	address := parser.ParseAddress(value)
+	if address.PostalCode != "" {
+		score += 0.2
+	}
+	if address.House != "" {
+		score += 0.2
+	}
+	if address.SettlementType != "" || address.StreetType != "" {
+		score += 0.2
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score
+}
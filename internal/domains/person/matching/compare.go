@@ -0,0 +1,56 @@
+package matching
+
+import (
+	"service/internal/domains/person/models"
+	"strings"
+)
+
+// FieldComparison shows one field of two records side by side for a reviewer
+type FieldComparison struct {
+	Field      string  `json:"field"`
+	Left       string  `json:"left"`
+	Right      string  `json:"right"`
+	Similarity float64 `json:"similarity"`
+	Equal      bool    `json:"equal"`
+}
+
+// CompareFields compares the records field by field. Identifiers and dates are compared
+// in normalized form, so "8 999 123-45-67" equals "+79991234567"
+func CompareFields(a, b models.Person) []FieldComparison {
+	ia, ib := identifiersOf(a), identifiersOf(b)
+
+	fields := []struct {
+		name        string
+		left, right string
+		nl, nr      string
+	}{
+		{"fio", a.Fio, b.Fio, normalizeName(a), normalizeName(b)},
+		{"birth_date", a.BirthDate, b.BirthDate, birthDate(a), birthDate(b)},
+		{"phone", a.Phone, b.Phone, ia.phone, ib.phone},
+		{"snils", a.Snils, b.Snils, ia.snils, ib.snils},
+		{"inn", a.Inn, b.Inn, ia.inn, ib.inn},
+		{"passport", a.Passport, b.Passport, ia.passport, ib.passport},
+		{"address", a.Address, b.Address, "", ""},
+	}
+
+	comparisons := make([]FieldComparison, 0, len(fields))
+	for _, f := range fields {
+		// Значения, которые не удалось нормализовать, сравниваем как есть
+		nl, nr := f.nl, f.nr
+		if nl == "" {
+			nl = strings.ToLower(strings.TrimSpace(f.left))
+		}
+		if nr == "" {
+			nr = strings.ToLower(strings.TrimSpace(f.right))
+		}
+		similarity := Similarity(nl, nr)
+		comparisons = append(comparisons, FieldComparison{
+			Field:      f.name,
+			Left:       f.left,
+			Right:      f.right,
+			Similarity: similarity,
+			Equal:      nl != "" && similarity == 1,
+		})
+	}
+	return comparisons
+}
@@ -0,0 +1,82 @@
+package matching
+
+import (
+	"service/internal/domains/person/models"
+)
+
+// Golden builds the master record from its member source records with the default policy:
+// every field takes the value that most members agree on, ties go to the most recent record.
+// Members are expected in import order (ascending id)
+func Golden(members []models.Person) models.Person {
+	return Survive(members, Policy{})
+}
+
+// OverridableFields are the golden record fields an operator can set by hand
+var OverridableFields = []string{"fio", "surname", "first_name", "patronymic", "gender", "phone", "snils", "inn", "passport", "birth_date", "address"}
+
+// ApplyOverrides replaces golden record fields with the values chosen by an operator.
+// Unknown fields are ignored
+func ApplyOverrides(golden models.Person, overrides map[string]string) models.Person {
+	for field, value := range overrides {
+		for i := range golden.Conflicts {
+			if golden.Conflicts[i].Field == field {
+				golden.Conflicts[i].Chosen = value
+				golden.Conflicts[i].Strategy = string(StrategyManual)
+			}
+		}
+		switch field {
+		case "fio":
+			golden.Fio = value
+		case "surname":
+			golden.Surname = value
+		case "first_name":
+			golden.FirstName = value
+		case "patronymic":
+			golden.Patronymic = value
+		case "gender":
+			golden.Gender = value
+		case "phone":
+			golden.Phone = value
+		case "snils":
+			golden.Snils = value
+		case "inn":
+			golden.Inn = value
+		case "passport":
+			golden.Passport = value
+		case "birth_date":
+			golden.BirthDate = value
+		case "address":
+			golden.Address = value
+		}
+	}
+	return golden
+}
+
+// Value returns a golden record field by its name in OverridableFields
+func Value(golden models.Person, field string) string {
+	switch field {
+	case "fio":
+		return golden.Fio
+	case "surname":
+		return golden.Surname
+	case "first_name":
+		return golden.FirstName
+	case "patronymic":
+		return golden.Patronymic
+	case "gender":
+		return golden.Gender
+	case "phone":
+		return golden.Phone
+	case "snils":
+		return golden.Snils
+	case "inn":
+		return golden.Inn
+	case "passport":
+		return golden.Passport
+	case "birth_date":
+		return golden.BirthDate
+	case "address":
+		return golden.Address
+	}
+	return ""
+}
@@ -0,0 +1,251 @@
+package matching
+
+import (
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/parser"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MatchThreshold is the score from which two records are merged into one master
+	MatchThreshold = 0.8
+	// ReviewThreshold is the score from which a pair below MatchThreshold goes to manual review
+	ReviewThreshold = 0.6
+
+	// Веса вероятностного сравнения: совпадение ФИО, даты рождения и телефона дает 1
+	weightFio   = 0.45
+	weightBirth = 0.35
+	weightPhone = 0.2
+
+	// conflictPenalty is subtracted for every identifier present in both records with different values
+	conflictPenalty = 0.15
+)
+
+// Key kinds of the blocking index. Candidates for comparison are records sharing any key
+const (
+	KeySnils     = "snils"
+	KeyInn       = "inn"
+	KeyPassport  = "passport"
+	KeyPhone     = "phone"
+	KeyNameBirth = "name_birth"
+)
+
+// Key is a normalized value a record can be found by
+type Key struct {
+	Kind  string
+	Value string
+}
+
+// Match is the result of comparing two records
+type Match struct {
+	Score         float64  `json:"score"`
+	Deterministic bool     `json:"deterministic"`
+	Reasons       []string `json:"reasons"`
+}
+
+type identifiers struct {
+	snils, inn, passport, phone string
+	// все телефоны и паспорта записи, включая основные
+	phones, passports []string
+}
+
+func identifiersOf(p models.Person) identifiers {
+	var ids identifiers
+	if snils, ok := parser.NormalizeSnils(p.Snils); ok && parser.ValidSnils(snils) {
+		ids.snils = snils
+	}
+	// ИНН физического лица - 12 цифр
+	if inn, ok := parser.NormalizeInn(p.Inn); ok && len(inn) == 12 && parser.ValidInn(inn) {
+		ids.inn = inn
+	}
+	if passport, ok := parser.NormalizePassport(p.Passport); ok {
+		ids.passport = passport
+	}
+	if phone, ok := parser.NormalizePhone(p.Phone); ok {
+		ids.phone = phone
+	}
+
+	ids.phones = appendUnique(ids.phones, ids.phone)
+	for _, phone := range p.Phones {
+		if value, ok := parser.NormalizePhone(phone.Value); ok {
+			ids.phones = appendUnique(ids.phones, value)
+		}
+	}
+	ids.passports = appendUnique(ids.passports, ids.passport)
+	for _, doc := range p.Documents {
+		if doc.Kind != models.DocumentPassport {
+			continue
+		}
+		if value, ok := parser.NormalizePassport(doc.Number); ok {
+			ids.passports = appendUnique(ids.passports, value)
+		}
+	}
+	return ids
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// shared reports whether two value sets intersect
+func shared(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeName приводит ФИО к сравнимому виду: нижний регистр, ё -> е, порядок фамилия имя отчество
+func normalizeName(p models.Person) string {
+	name := strings.TrimSpace(strings.Join([]string{p.Surname, p.FirstName, p.Patronymic}, " "))
+	if name == "" {
+		name = p.Fio
+	}
+	name = strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func birthDate(p models.Person) string {
+	if t, ok := parser.ParseDate(p.BirthDate); ok {
+		return t.Format("2006-01-02")
+	}
+	return ""
+}
+
+// Keys returns the blocking keys of a record
+func Keys(p models.Person) []Key {
+	ids := identifiersOf(p)
+	var keys []Key
+	add := func(kind, value string) {
+		if value != "" {
+			keys = append(keys, Key{Kind: kind, Value: value})
+		}
+	}
+	add(KeySnils, ids.snils)
+	add(KeyInn, ids.inn)
+	for _, passport := range ids.passports {
+		add(KeyPassport, passport)
+	}
+	for _, phone := range ids.phones {
+		add(KeyPhone, phone)
+	}
+
+	// Фамилия и имя с датой рождения находят записи с опечатками в отчестве и телефоне
+	if birth := birthDate(p); birth != "" && p.Surname != "" && p.FirstName != "" {
+		name := strings.ReplaceAll(strings.ToLower(p.Surname+" "+p.FirstName), "ё", "е")
+		add(KeyNameBirth, name+"|"+birth)
+	}
+	return keys
+}
+
+// Compare scores two records. Equal SNILS, INN or passport is a deterministic match unless
+// the lifelong identifiers (SNILS, INN) contradict each other; otherwise FIO, birth date and
+// phone are weighed and every conflicting identifier lowers the score
+func Compare(a, b models.Person) Match {
+	ia, ib := identifiersOf(a), identifiersOf(b)
+	var m Match
+
+	conflict := func(x, y string) bool { return x != "" && y != "" && x != y }
+	lifelongConflict := conflict(ia.snils, ib.snils) || conflict(ia.inn, ib.inn)
+
+	if !lifelongConflict {
+		for _, id := range []struct{ name, x, y string }{
+			{"snils", ia.snils, ib.snils},
+			{"inn", ia.inn, ib.inn},
+		} {
+			if id.x != "" && id.x == id.y {
+				m.Score = 1
+				m.Deterministic = true
+				m.Reasons = append(m.Reasons, id.name+" equal")
+			}
+		}
+		// У человека может быть несколько паспортов, достаточно одного общего
+		if shared(ia.passports, ib.passports) {
+			m.Score = 1
+			m.Deterministic = true
+			m.Reasons = append(m.Reasons, "passport equal")
+		}
+		if m.Deterministic {
+			return m
+		}
+	}
+
+	if fio := Similarity(normalizeName(a), normalizeName(b)); fio > 0 {
+		m.Score += weightFio * fio
+		m.Reasons = append(m.Reasons, "fio similar")
+	}
+	if birth := birthDate(a); birth != "" && birth == birthDate(b) {
+		m.Score += weightBirth
+		m.Reasons = append(m.Reasons, "birth_date equal")
+	}
+	if shared(ia.phones, ib.phones) {
+		m.Score += weightPhone
+		m.Reasons = append(m.Reasons, "phone equal")
+	}
+
+	for _, id := range []struct {
+		name     string
+		conflict bool
+	}{
+		{"snils", conflict(ia.snils, ib.snils)},
+		{"inn", conflict(ia.inn, ib.inn)},
+		{"passport", len(ia.passports) > 0 && len(ib.passports) > 0 && !shared(ia.passports, ib.passports)},
+	} {
+		if id.conflict {
+			m.Score -= conflictPenalty
+			m.Reasons = append(m.Reasons, id.name+" differs")
+		}
+	}
+	if m.Score < 0 {
+		m.Score = 0
+	}
+	return m
+}
+
+// Similarity is 1 minus the edit distance divided by the length of the longer string
+func Similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	return 1 - float64(levenshtein([]rune(a), []rune(b)))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
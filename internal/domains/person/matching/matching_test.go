@@ -0,0 +1,196 @@
+package matching
+
+import (
+	"testing"
+
+	"service/internal/domains/person/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func person(fio, surname, firstName, patronymic, birth, phone, snils, passport string) models.Person {
+	return models.Person{Fio: fio, Surname: surname, FirstName: firstName, Patronymic: patronymic,
+		BirthDate: birth, Phone: phone, Snils: snils, Passport: passport}
+}
+
+func TestCompareDeterministic(t *testing.T) {
+	a := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "", "", "112-233-445 95", "")
+	b := person("Иванов И.И.", "Иванов", "И.", "И.", "", "", "11223344595", "")
+
+	m := Compare(a, b)
+	assert.True(t, m.Deterministic)
+	assert.Equal(t, 1.0, m.Score)
+}
+
+func TestCompareProbabilistic(t *testing.T) {
+	a := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "01.01.1990", "+7 999 123-45-67", "", "")
+	b := person("ИВАНОВ ИВАН ИВАНОВИЧ", "Иванов", "Иван", "Иванович", "1990-01-01", "89991234567", "", "")
+
+	m := Compare(a, b)
+	assert.False(t, m.Deterministic)
+	assert.InDelta(t, 1.0, m.Score, 0.001)
+
+	// Опечатка в отчестве: совпадение телефона удерживает запись выше порога
+	c := person("Иванов Иван Иваныч", "Иванов", "Иван", "Иваныч", "1990-01-01", "+79991234567", "", "")
+	assert.GreaterOrEqual(t, Compare(a, c).Score, MatchThreshold)
+	c.Phone = ""
+	assert.Less(t, Compare(a, c).Score, MatchThreshold)
+}
+
+func TestCompareConflictingPassports(t *testing.T) {
+	a := person("Петров Петр Петрович", "Петров", "Петр", "Петрович", "15.05.1985", "", "", "1234 567890")
+	b := person("Петров Петр Петрович", "Петров", "Петр", "Петрович", "15.05.1985", "", "", "4321 098765")
+
+	m := Compare(a, b)
+	assert.False(t, m.Deterministic)
+	assert.Less(t, m.Score, MatchThreshold)
+	assert.Contains(t, m.Reasons, "passport differs")
+}
+
+func TestCompareLifelongConflict(t *testing.T) {
+	// Одинаковый паспорт не объединяет записи с разными СНИЛС
+	a := person("Петров Петр", "Петров", "Петр", "", "", "", "112-233-445 95", "1234 567890")
+	b := person("Сидоров Олег", "Сидоров", "Олег", "", "", "", "123-456-789 64", "1234 567890")
+
+	m := Compare(a, b)
+	assert.False(t, m.Deterministic)
+	assert.Less(t, m.Score, MatchThreshold)
+}
+
+func TestKeys(t *testing.T) {
+	p := person("Ёлкин Пётр", "Ёлкин", "Пётр", "", "30.06.2003", "8 (912) 618-26-85", "", "6864704987")
+	assert.ElementsMatch(t, []Key{
+		{Kind: KeyPassport, Value: "6864 704987"},
+		{Kind: KeyPhone, Value: "+79126182685"},
+		{Kind: KeyNameBirth, Value: "елкин петр|2003-06-30"},
+	}, Keys(p))
+}
+
+func TestGolden(t *testing.T) {
+	members := []models.Person{
+		{Fio: "Иванов Иван", Phone: "+79991234567", Attributes: map[string]string{"city": "Москва"}},
+		{Fio: "Иванов Иван Иванович", Phone: "+79991234567", Address: "г. Москва"},
+		{Fio: "Иванов Иван Иванович", Phone: "+79990000000", Attributes: map[string]string{"номер_заявки": "123"}},
+	}
+
+	golden := Golden(members)
+	assert.Equal(t, "Иванов Иван Иванович", golden.Fio)
+	assert.Equal(t, "+79991234567", golden.Phone)
+	assert.Equal(t, "г. Москва", golden.Address)
+	assert.Equal(t, map[string]string{"city": "Москва", "номер_заявки": "123"}, golden.Attributes)
+}
+
+func TestCompareFields(t *testing.T) {
+	a := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "01.01.1990", "8 999 123-45-67", "", "1234 567890")
+	b := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "1990-01-01", "+79991234567", "", "4321 098765")
+
+	fields := make(map[string]FieldComparison)
+	for _, f := range CompareFields(a, b) {
+		fields[f.Field] = f
+	}
+	assert.True(t, fields["fio"].Equal)
+	assert.True(t, fields["birth_date"].Equal)
+	assert.True(t, fields["phone"].Equal)
+	assert.False(t, fields["passport"].Equal)
+	assert.False(t, fields["snils"].Equal)
+	assert.Equal(t, "8 999 123-45-67", fields["phone"].Left)
+
+	// Те же ФИО и дата рождения, но разные паспорта: пара уходит на проверку
+	b.Phone = ""
+	score := Compare(a, b).Score
+	assert.GreaterOrEqual(t, score, ReviewThreshold)
+	assert.Less(t, score, MatchThreshold)
+}
+
+func TestApplyOverrides(t *testing.T) {
+	golden := Golden([]models.Person{{Fio: "Иванов Иван", Phone: "+79991234567"}})
+	golden = ApplyOverrides(golden, map[string]string{"fio": "Иванов Иван Иванович", "unknown": "x"})
+
+	assert.Equal(t, "Иванов Иван Иванович", golden.Fio)
+	assert.Equal(t, "+79991234567", golden.Phone)
+
+	for _, field := range OverridableFields {
+		assert.Equal(t, Value(ApplyOverrides(models.Person{}, map[string]string{field: "x"}), field), "x", field)
+	}
+}
+
+func TestSurvive(t *testing.T) {
+	source := func(p models.Person, id int, sourceId string) models.Person {
+		p.Id = id
+		p.Provenance.SourceId = sourceId
+		return p
+	}
+	members := []models.Person{
+		source(models.Person{Fio: "Иванов Иван Иванович", Phone: "8 999 123-45-67", Address: "г. Москва, ул. Ленина, д. 1"}, 1, "registry"),
+		source(models.Person{Fio: "Иванов Иван", Phone: "+79991234567", Address: "Москва"}, 2, "crm"),
+		source(models.Person{Fio: "Иванов Иван", Phone: "+79990000000", Address: "г. Казань"}, 3, "crm"),
+	}
+	policy := Policy{
+		Strategies: map[string]Strategy{
+			"fio":     StrategyMostTrusted,
+			"phone":   StrategyMostFrequent,
+			"address": StrategyKeepAll,
+		},
+		Trust: map[string]int{"registry": 90, "crm": 30},
+	}
+
+	golden := Survive(members, policy)
+	assert.Equal(t, "Иванов Иван Иванович", golden.Fio)
+	// Разное написание одного номера считается одним значением
+	assert.Equal(t, "+79991234567", golden.Phone)
+	assert.Equal(t, "г. Казань", golden.Address)
+	assert.Len(t, golden.Values["address"], 3)
+
+	conflicts := make(map[string]models.FieldConflict)
+	for _, c := range golden.Conflicts {
+		conflicts[c.Field] = c
+	}
+	assert.Equal(t, string(StrategyMostTrusted), conflicts["fio"].Strategy)
+	assert.Len(t, conflicts["fio"].Alternatives, 2)
+	assert.Equal(t, []int{2, 3}, conflicts["fio"].Alternatives[1].PersonIds)
+	assert.Equal(t, []string{"crm"}, conflicts["fio"].Alternatives[1].Sources)
+	assert.Equal(t, 2, conflicts["phone"].Alternatives[0].Count)
+
+	policy.Strategies["fio"] = StrategyMostRecent
+	assert.Equal(t, "Иванов Иван", Survive(members, policy).Fio)
+	policy.Strategies["fio"] = StrategyLongest
+	assert.Equal(t, "Иванов Иван Иванович", Survive(members, policy).Fio)
+
+	golden = ApplyOverrides(golden, map[string]string{"phone": "+79990000000"})
+	for _, c := range golden.Conflicts {
+		if c.Field == "phone" {
+			assert.Equal(t, string(StrategyManual), c.Strategy)
+			assert.Equal(t, "+79990000000", c.Chosen)
+		}
+	}
+}
+
+func TestCompareMultipleContacts(t *testing.T) {
+	a := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "01.01.1990", "89161234567", "", "4510 123456")
+	a.Phones = []models.Phone{{Value: "+79161234567", Primary: true}, {Value: "+74951234567"}}
+	a.Documents = []models.Document{{Kind: models.DocumentPassport, Number: "4510 123456", Primary: true},
+		{Kind: models.DocumentPassport, Number: "4505 000111"}}
+	b := person("Иванов Иван Иванович", "Иванов", "Иван", "Иванович", "01.01.1990", "84951234567", "", "")
+
+	assert.Contains(t, Compare(a, b).Reasons, "phone equal")
+	assert.Contains(t, Keys(a), Key{Kind: KeyPhone, Value: "+74951234567"})
+	assert.Contains(t, Keys(a), Key{Kind: KeyPassport, Value: "4505 000111"})
+
+	// Старый паспорт во второй записи совпадает с одним из паспортов первой
+	b.Passport = "4505 000111"
+	m := Compare(a, b)
+	assert.True(t, m.Deterministic)
+}
+
+func TestSurviveContacts(t *testing.T) {
+	members := []models.Person{
+		{Id: 1, Phone: "89161234567", Phones: []models.Phone{{Value: "+79161234567"}, {Value: "+74951234567"}}},
+		{Id: 2, Phone: "+7 916 123-45-67", Phones: []models.Phone{{Value: "+79161234567"}}},
+	}
+
+	golden := Survive(members, Policy{})
+	assert.Len(t, golden.Phones, 2)
+	for _, phone := range golden.Phones {
+		assert.Equal(t, phone.Value == "+79161234567", phone.Primary, phone.Value)
+	}
+}
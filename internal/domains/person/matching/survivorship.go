@@ -0,0 +1,257 @@
+package matching
+
+import (
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/parser"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strategy decides which member value of a field survives into the golden record
+type Strategy string
+
+const (
+	StrategyMostTrusted  Strategy = "most_trusted"
+	StrategyMostRecent   Strategy = "most_recent"
+	StrategyMostFrequent Strategy = "most_frequent"
+	StrategyLongest      Strategy = "longest"
+	// StrategyKeepAll keeps every distinct value in Values; the field itself takes the most recent one
+	StrategyKeepAll Strategy = "keep_all"
+	// StrategyManual marks a value chosen by an operator
+	StrategyManual Strategy = "manual"
+)
+
+// DefaultStrategy is used for fields without a configured strategy
+const DefaultStrategy = StrategyMostFrequent
+
+// DefaultTrust is the trust level of sources without a configured one
+const DefaultTrust = 50
+
+var strategies = map[Strategy]bool{
+	StrategyMostTrusted: true, StrategyMostRecent: true, StrategyMostFrequent: true, StrategyLongest: true, StrategyKeepAll: true,
+}
+
+// ValidStrategy reports whether a strategy can be configured for a field
+func ValidStrategy(strategy Strategy) bool {
+	return strategies[strategy]
+}
+
+// Policy holds the survivorship strategies per field and the trust levels per source id
+type Policy struct {
+	Strategies map[string]Strategy
+	Trust      map[string]int
+}
+
+func (p Policy) strategy(field string) Strategy {
+	if strategy, ok := p.Strategies[field]; ok && ValidStrategy(strategy) {
+		return strategy
+	}
+	return DefaultStrategy
+}
+
+func (p Policy) trust(sourceId string) int {
+	if trust, ok := p.Trust[sourceId]; ok {
+		return trust
+	}
+	return DefaultTrust
+}
+
+// candidate is a group of member values that are equal after normalization
+type candidate struct {
+	value   string // значение самой поздней записи группы
+	count   int
+	last    int // индекс самой поздней записи
+	trust   int
+	sources []string
+	ids     []int
+}
+
+// comparableValue normalizes a value so that "8 999 123-45-67" and "+79991234567" form one group
+func comparableValue(field, value string) string {
+	switch field {
+	case "phone":
+		if v, ok := parser.NormalizePhone(value); ok {
+			return v
+		}
+	case "snils":
+		if v, ok := parser.NormalizeSnils(value); ok {
+			return v
+		}
+	case "inn":
+		if v, ok := parser.NormalizeInn(value); ok {
+			return v
+		}
+	case "passport":
+		if v, ok := parser.NormalizePassport(value); ok {
+			return v
+		}
+	case "birth_date":
+		if t, ok := parser.ParseDate(value); ok {
+			return t.Format("2006-01-02")
+		}
+	}
+	value = strings.ReplaceAll(strings.ToLower(value), "ё", "е")
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// Survive builds the golden record with the policy. Fields whose members disagree are
+// reported in Conflicts with all alternatives, so analysts can see what was not chosen
+func Survive(members []models.Person, policy Policy) models.Person {
+	var golden models.Person
+	if len(members) == 0 {
+		return golden
+	}
+
+	for _, field := range OverridableFields {
+		var groups []*candidate
+		byKey := make(map[string]*candidate)
+		for i, p := range members {
+			value := strings.TrimSpace(Value(p, field))
+			if value == "" {
+				continue
+			}
+			key := comparableValue(field, value)
+			c, ok := byKey[key]
+			if !ok {
+				c = &candidate{}
+				byKey[key] = c
+				groups = append(groups, c)
+			}
+			c.value = value
+			c.count++
+			c.last = i
+			c.ids = append(c.ids, p.Id)
+			source := p.Provenance.SourceId
+			if trust := policy.trust(source); trust > c.trust || c.count == 1 {
+				c.trust = trust
+			}
+			if source != "" && !containsString(c.sources, source) {
+				c.sources = append(c.sources, source)
+			}
+		}
+		if len(groups) == 0 {
+			continue
+		}
+
+		strategy := policy.strategy(field)
+		chosen := choose(groups, strategy)
+		golden = ApplyOverrides(golden, map[string]string{field: chosen.value})
+
+		if strategy == StrategyKeepAll {
+			if golden.Values == nil {
+				golden.Values = make(map[string][]string)
+			}
+			for _, c := range groups {
+				golden.Values[field] = append(golden.Values[field], c.value)
+			}
+		}
+
+		if len(groups) > 1 {
+			conflict := models.FieldConflict{Field: field, Chosen: chosen.value, Strategy: string(strategy)}
+			for _, c := range groups {
+				conflict.Alternatives = append(conflict.Alternatives, models.Alternative{
+					Value: c.value, Count: c.count, Trust: c.trust, Sources: c.sources, PersonIds: c.ids,
+				})
+			}
+			golden.Conflicts = append(golden.Conflicts, conflict)
+		}
+	}
+
+	golden.Phones, golden.Addresses, golden.Documents = mergeContacts(members)
+	for i := range golden.Phones {
+		golden.Phones[i].Primary = comparableValue("phone", golden.Phones[i].Value) == comparableValue("phone", golden.Phone)
+	}
+	for i := range golden.Addresses {
+		golden.Addresses[i].Primary = comparableValue("address", golden.Addresses[i].Raw) == comparableValue("address", golden.Address)
+	}
+	for i := range golden.Documents {
+		doc := &golden.Documents[i]
+		doc.Primary = comparableValue(doc.Kind, doc.Number) == comparableValue(doc.Kind, Value(golden, doc.Kind))
+	}
+
+	for _, p := range members {
+		for key, value := range p.Attributes {
+			if golden.Attributes == nil {
+				golden.Attributes = make(map[string]string)
+			}
+			golden.Attributes[key] = value
+		}
+	}
+	return golden
+}
+
+// choose picks the surviving group. Every strategy breaks ties by recency
+func choose(groups []*candidate, strategy Strategy) *candidate {
+	better := func(a, b *candidate) bool {
+		switch strategy {
+		case StrategyMostTrusted:
+			if a.trust != b.trust {
+				return a.trust > b.trust
+			}
+		case StrategyMostFrequent:
+			if a.count != b.count {
+				return a.count > b.count
+			}
+		case StrategyLongest:
+			la, lb := utf8.RuneCountInString(a.value), utf8.RuneCountInString(b.value)
+			if la != lb {
+				return la > lb
+			}
+		}
+		return a.last > b.last
+	}
+
+	best := groups[0]
+	for _, c := range groups[1:] {
+		if better(c, best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// mergeContacts collects the distinct phones, addresses and documents of the members.
+// The primary value of the golden record comes first
+func mergeContacts(members []models.Person) ([]models.Phone, []models.Address, []models.Document) {
+	var phones []models.Phone
+	var addresses []models.Address
+	var documents []models.Document
+	seen := make(map[string]bool)
+	for i := len(members) - 1; i >= 0; i-- {
+		p := members[i]
+		for _, phone := range p.Phones {
+			if key := "phone|" + comparableValue("phone", phone.Value); !seen[key] {
+				seen[key] = true
+				phone.Primary = false
+				phone.SourceId = p.Provenance.SourceId
+				phones = append(phones, phone)
+			}
+		}
+		for _, address := range p.Addresses {
+			if key := "address|" + comparableValue("address", address.Raw); !seen[key] {
+				seen[key] = true
+				address.Primary = false
+				address.SourceId = p.Provenance.SourceId
+				addresses = append(addresses, address)
+			}
+		}
+		for _, doc := range p.Documents {
+			if key := doc.Kind + "|" + comparableValue(doc.Kind, doc.Number); !seen[key] {
+				seen[key] = true
+				doc.Primary = false
+				doc.SourceId = p.Provenance.SourceId
+				documents = append(documents, doc)
+			}
+		}
+	}
+	return phones, addresses, documents
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package models
+
+// Address is a postal address split into components (table person_addresses).
+// A person may have several; the primary one is also kept in Person.Address
+type Address struct {
+	Id             int    `db:"id" json:"-"`
+	PersonId       int    `db:"person_id" json:"-"`
+	PostalCode     string `db:"postal_code" json:"postal_code,omitempty"`
+	Region         string `db:"region" json:"region,omitempty"`
+	District       string `db:"district" json:"district,omitempty"`
+	SettlementType string `db:"settlement_type" json:"settlement_type,omitempty"`
+	Settlement     string `db:"settlement" json:"settlement,omitempty"`
+	StreetType     string `db:"street_type" json:"street_type,omitempty"`
+	Street         string `db:"street" json:"street,omitempty"`
+	House          string `db:"house" json:"house,omitempty"`
+	Building       string `db:"building" json:"building,omitempty"`
+	Structure      string `db:"structure" json:"structure,omitempty"`
+	Flat           string `db:"flat" json:"flat,omitempty"`
+
+	// Raw is the address as written in the source
+	Raw       string `db:"raw" json:"raw,omitempty"`
+	Kind      string `db:"kind" json:"kind,omitempty"`
+	Primary   bool   `db:"is_primary" json:"is_primary,omitempty"`
+	ValidFrom string `db:"valid_from" json:"valid_from,omitempty"`
+	ValidTo   string `db:"valid_to" json:"valid_to,omitempty"`
+	SourceId  string `db:"source_id" json:"source_id,omitempty"`
+}
+
+func (a Address) IsEmpty() bool {
+	return a.PostalCode == "" && a.Region == "" && a.District == "" && a.Settlement == "" &&
+		a.Street == "" && a.House == "" && a.Building == "" && a.Structure == "" && a.Flat == ""
+}
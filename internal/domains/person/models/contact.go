@@ -0,0 +1,36 @@
+package models
+
+// Phone kinds
+const (
+	PhoneMobile   = "mobile"
+	PhoneLandline = "landline"
+)
+
+// Document kinds
+const (
+	DocumentPassport = "passport"
+	DocumentSnils    = "snils"
+	DocumentInn      = "inn"
+)
+
+// Phone is one of the person's phone numbers (table person_phones)
+type Phone struct {
+	Value     string `db:"value" json:"value"`
+	Raw       string `db:"raw" json:"raw,omitempty"`
+	Kind      string `db:"kind" json:"kind,omitempty"`
+	Primary   bool   `db:"is_primary" json:"is_primary"`
+	ValidFrom string `db:"valid_from" json:"valid_from,omitempty"`
+	ValidTo   string `db:"valid_to" json:"valid_to,omitempty"`
+	SourceId  string `db:"source_id" json:"source_id,omitempty"`
+}
+
+// Document is an identity document of the person (table person_documents)
+type Document struct {
+	Kind      string `db:"kind" json:"kind"`
+	Number    string `db:"number" json:"number"`
+	Raw       string `db:"raw" json:"raw,omitempty"`
+	Primary   bool   `db:"is_primary" json:"is_primary"`
+	ValidFrom string `db:"valid_from" json:"valid_from,omitempty"`
+	ValidTo   string `db:"valid_to" json:"valid_to,omitempty"`
+	SourceId  string `db:"source_id" json:"source_id,omitempty"`
+}
@@ -0,0 +1,82 @@
+package models
+
+// RuleViolation is a failed validation rule for an imported row
+type RuleViolation struct {
+	Rule     string `json:"rule"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type RowViolations struct {
+	Row        int             `json:"row"`
+	Violations []RuleViolation `json:"violations"`
+}
+
+// ImportResult summarizes processing of one uploaded file
+type ImportResult struct {
+	BatchId     string          `json:"batch_id,omitempty"`
+	Rows        int             `json:"rows"`
+	Saved       int             `json:"saved"`
+	Duplicates  int             `json:"duplicates"` // rows already stored by an earlier import
+	Merged      int             `json:"merged"`
+	Rejected    int             `json:"rejected"`
+	Quarantined int             `json:"quarantined"`
+	Warnings    int             `json:"warnings"`
+	Violations  []RowViolations `json:"violations,omitempty"`
+}
+
+// Import batch statuses
+const (
+	BatchRunning    = "running"
+	BatchCompleted  = "completed"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+)
+
+// Upload describes where an uploaded file comes from
+type Upload struct {
+	SourceId string
+	FileName string
+}
+
+// ImportBatch is one processed upload
+type ImportBatch struct {
+	Id          string `db:"id" json:"id"`
+	SourceId    string `db:"source_id" json:"source_id"`
+	FileName    string `db:"file_name" json:"file_name"`
+	Format      string `db:"format" json:"format"`
+	Status      string `db:"status" json:"status"`
+	Rows        int    `db:"row_count" json:"rows"`
+	Saved       int    `db:"saved" json:"saved"`
+	Duplicates  int    `db:"duplicates" json:"duplicates"`
+	Merged      int    `db:"merged" json:"merged"`
+	Rejected    int    `db:"rejected" json:"rejected"`
+	Quarantined int    `db:"quarantined" json:"quarantined"`
+	Warnings    int    `db:"warnings" json:"warnings"`
+	Persons     int    `db:"persons" json:"persons"`
+	CreatedAt   string `db:"created_at" json:"created_at"`
+	FinishedAt  string `db:"finished_at" json:"finished_at,omitempty"`
+	RolledBack  string `db:"rolled_back_at" json:"rolled_back_at,omitempty"`
+}
+
+// RollbackConflict is a manual edit made after the batch that a rollback would lose
+type RollbackConflict struct {
+	Kind      string `json:"kind"` // merge, unmerge, detach or review
+	Id        int    `json:"id"`
+	Operator  string `json:"operator"`
+	Status    string `json:"status,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// RollbackImpact shows what rolling back an import batch removes and recomputes
+type RollbackImpact struct {
+	BatchId           string             `json:"batch_id"`
+	DryRun            bool               `json:"dry_run"`
+	Applied           bool               `json:"applied"`
+	Persons           int                `json:"persons"`
+	Quarantined       int                `json:"quarantined"`
+	MastersDeleted    int                `json:"masters_deleted"`
+	MastersRecomputed int                `json:"masters_recomputed"`
+	Conflicts         []RollbackConflict `json:"conflicts,omitempty"`
+}
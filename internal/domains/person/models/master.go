@@ -0,0 +1,33 @@
+package models
+
+// Master is a resolved person: the cluster of source records that describe one citizen
+// and the golden record built from them
+type Master struct {
+	Id          int    `json:"id"`
+	Golden      Person `json:"golden"`
+	MemberCount int    `json:"member_count"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// PersonSources is a master together with its member source records
+type PersonSources struct {
+	Master  Master   `json:"master"`
+	Sources []Person `json:"sources"`
+}
+
+// Alternative is one of the disagreeing values of a golden record field
+type Alternative struct {
+	Value     string   `json:"value"`
+	Count     int      `json:"count"`
+	Trust     int      `json:"trust"`
+	Sources   []string `json:"sources,omitempty"`
+	PersonIds []int    `json:"person_ids"`
+}
+
+// FieldConflict shows the value chosen for a field whose source records disagree and the alternatives
+type FieldConflict struct {
+	Field        string        `json:"field"`
+	Chosen       string        `json:"chosen"`
+	Strategy     string        `json:"strategy"`
+	Alternatives []Alternative `json:"alternatives"`
+}
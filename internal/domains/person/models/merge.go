@@ -0,0 +1,45 @@
+package models
+
+// MergeRequest joins the masters of several source records into the master of Survivor.
+// Fields sets golden record values by hand, e.g. {"phone": "+79991234567"}
+type MergeRequest struct {
+	Ids      []int             `json:"ids" binding:"required"`
+	Survivor int               `json:"survivor"`
+	Fields   map[string]string `json:"fields"`
+	Operator string            `json:"operator" binding:"required"`
+	Reason   string            `json:"reason"`
+}
+
+// UnmergeRequest identifies who undoes a merge and why
+type UnmergeRequest struct {
+	Operator string `json:"operator" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+// MasterSnapshot is the state of a master before or after a manual operation
+type MasterSnapshot struct {
+	Id        int               `json:"id"`
+	Golden    Person            `json:"golden"`
+	Overrides map[string]string `json:"overrides"`
+	MemberIds []int             `json:"member_ids"`
+}
+
+// Audit actions
+const (
+	ActionMerge   = "merge"
+	ActionUnmerge = "unmerge"
+	ActionDetach  = "detach"
+)
+
+// MergeAudit is a recorded manual merge or unmerge
+type MergeAudit struct {
+	Id        int              `json:"id"`
+	Action    string           `json:"action"`
+	Operator  string           `json:"operator"`
+	Reason    string           `json:"reason,omitempty"`
+	MasterId  int              `json:"master_id"`
+	PersonIds []int            `json:"person_ids"`
+	Before    []MasterSnapshot `json:"before"`
+	After     []MasterSnapshot `json:"after"`
+	CreatedAt string           `json:"created_at"`
+}
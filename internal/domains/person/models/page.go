@@ -0,0 +1,13 @@
+package models
+
+// PersonPage is one page of a person listing
+type PersonPage struct {
+	Persons []Person `json:"persons"`
+	Limit   int      `json:"limit"`
+	Sort    string   `json:"sort"`
+	// Total is the number of matching persons, exact or estimated by the planner
+	Total      int64  `json:"total"`
+	TotalExact bool   `json:"total_exact"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
@@ -1,12 +1,53 @@
 package models
 
+import "encoding/json"
+
 type Person struct {
-	Id        int    `db:"id" json:"id"`
-	Fio       string `db:"fio" json:"fio"`
-	Phone     string `db:"phone" json:"phone"`
-	Snils     string `db:"snils" json:"snils"`
-	Inn       string `db:"inn" json:"inn"`
-	Passport  string `db:"passport" json:"passport"`
-	BirthDate string `db:"birth_date" json:"birth_date"`
-	Address   string `db:"address" json:"address"`
+	Id         int    `db:"id" json:"id"`
+	Fio        string `db:"fio" json:"fio"`
+	Surname    string `db:"surname" json:"surname"`
+	FirstName  string `db:"first_name" json:"first_name"`
+	Patronymic string `db:"patronymic" json:"patronymic"`
+	Gender     string `db:"gender" json:"gender"`
+	Phone      string `db:"phone" json:"phone"`
+	Snils      string `db:"snils" json:"snils"`
+	Inn        string `db:"inn" json:"inn"`
+	Passport   string `db:"passport" json:"passport"`
+	BirthDate  string `db:"birth_date" json:"birth_date"`
+	Address    string `db:"address" json:"address"`
+
+	// BirthDay is the birth date parsed from BirthDate as YYYY-MM-DD, empty if it could not be parsed
+	BirthDay string `db:"birth_day" json:"birth_day,omitempty"`
+
+	// Attributes holds source columns that have no person field, by normalized header
+	Attributes map[string]string `db:"attributes" json:"attributes,omitempty"`
+
+	MasterId      int            `db:"master_id" json:"master_id,omitempty"`
+	ImportBatchId string         `db:"import_batch_id" json:"import_batch_id,omitempty"`
+	QualityScore  float64        `db:"quality_score" json:"quality_score"`
+	QualityIssues []QualityIssue `db:"quality_issues" json:"quality_issues"`
+
+	Provenance Provenance `db:"-" json:"provenance"`
+
+	// Conflicts are the disagreements between the sources of the person's master
+	Conflicts []FieldConflict `db:"conflicts" json:"conflicts,omitempty"`
+	// Values holds all distinct values of golden record fields with the keep_all strategy
+	Values map[string][]string `db:"-" json:"values,omitempty"`
+
+	// Phones, Addresses and Documents hold every value of multi-value source cells.
+	// The flat Phone, Address and Passport fields keep the primary value for older clients
+	Phones    []Phone    `db:"phones" json:"phones"`
+	Addresses []Address  `db:"addresses" json:"addresses"`
+	Documents []Document `db:"documents" json:"documents"`
+
+	AddressParts *Address `db:"-" json:"address_parts,omitempty"`
+}
+
+// Provenance tells where a source record came from: the partner, the file and its original row
+type Provenance struct {
+	SourceId   string          `db:"source_id" json:"source_id,omitempty"`
+	SourceFile string          `db:"source_file" json:"source_file,omitempty"`
+	SourceRow  int             `db:"source_row" json:"source_row,omitempty"`
+	RawRow     json.RawMessage `db:"raw_row" json:"raw_row,omitempty"`
+	ImportedAt string          `db:"imported_at" json:"imported_at,omitempty"`
 }
@@ -0,0 +1,26 @@
+package models
+
+// QualityIssue is a single problem found in a person record
+type QualityIssue struct {
+	Field string `json:"field"`
+	Code  string `json:"code"`
+}
+
+// FieldQuality holds fill-rate of one field over all records and validity over the filled ones, in percent
+type FieldQuality struct {
+	Field  string  `json:"field"`
+	Filled float64 `json:"filled"`
+	Valid  float64 `json:"valid"`
+}
+
+type BatchQuality struct {
+	BatchId  string         `json:"batch_id"`
+	Rows     int64          `json:"rows"`
+	AvgScore float64        `json:"avg_score"`
+	Fields   []FieldQuality `json:"fields"`
+}
+
+type QualityReport struct {
+	Total   BatchQuality   `json:"total"`
+	Batches []BatchQuality `json:"batches"`
+}
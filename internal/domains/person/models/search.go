@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"service/internal/domains/person/names"
+)
+
+// SearchHit is a person found by a structured search with the share of the conditions it meets
+type SearchHit struct {
+	Person `db:"-"`
+	Score  float64 `db:"score" json:"score"`
+
+	// Match explains a name search hit word by word
+	Match *names.Result `db:"-" json:"match,omitempty"`
+}
+
+// SearchPage is one page of search results, the best matches first
+type SearchPage struct {
+	Hits       []SearchHit `json:"hits"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	TotalExact bool        `json:"total_exact"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// IdentifierLookup is the result of GET /persons/by-identifier: the value in its canonical form and its records
+type IdentifierLookup struct {
+	Type    string   `json:"type"`
+	Value   string   `json:"value"`
+	Persons []Person `json:"persons"`
+}
+
+// TextSearch is a search of GET /person/find with an indexed mode
+type TextSearch struct {
+	Mode      string
+	Field     string
+	Value     string
+	Threshold float64
+	Limit     int
+	Explain   bool
+	// MaxDistance is the edit distance a name search tolerates per word, negative picks it by word length
+	MaxDistance int
+}
+
+// Explain shows how a search was executed: the indexes it used and the tables read in full
+type Explain struct {
+	Mode        string          `json:"mode"`
+	Query       string          `json:"query"`
+	Args        []interface{}   `json:"args"`
+	Indexes     []string        `json:"indexes"`
+	SeqScans    []string        `json:"seq_scans"`
+	IndexUsed   bool            `json:"index_used"`
+	PlanningMs  float64         `json:"planning_ms"`
+	ExecutionMs float64         `json:"execution_ms"`
+	Plan        json.RawMessage `json:"plan"`
+}
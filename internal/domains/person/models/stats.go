@@ -0,0 +1,17 @@
+package models
+
+// StatsGroup counts the persons sharing the values of the grouping dimensions. People counts
+// the distinct golden records, so the duplicates of one person from several sources count once
+type StatsGroup struct {
+	Keys    map[string]string `json:"keys"`
+	Records int64             `json:"records"`
+	People  int64             `json:"people"`
+}
+
+// Stats is the response of GET /persons/stats, the largest groups first
+type Stats struct {
+	GroupBy          []string     `json:"group_by"`
+	Groups           []StatsGroup `json:"groups"`
+	MinGroupSize     int          `json:"min_group_size,omitempty"`
+	SuppressedGroups int          `json:"suppressed_groups,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Version is a value a golden record field had during [ValidFrom, ValidTo).
+// The period is when the service knew the value; an open version has an empty ValidTo
+type Version struct {
+	MasterId  int    `db:"master_id" json:"master_id"` // differs from the history's master for merged masters
+	Field     string `db:"field" json:"field"`
+	Value     string `db:"value" json:"value"`
+	ValidFrom string `db:"valid_from" json:"valid_from"`
+	ValidTo   string `db:"valid_to" json:"valid_to,omitempty"`
+}
+
+// PersonHistory is the version history of the master a person belongs to.
+// With as_of, Snapshot holds the field values known at that moment
+type PersonHistory struct {
+	MasterId int               `json:"master_id"`
+	AsOf     string            `json:"as_of,omitempty"`
+	Snapshot map[string]string `json:"snapshot,omitempty"`
+	Versions []Version         `json:"versions"`
+}
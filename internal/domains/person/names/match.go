@@ -0,0 +1,170 @@
+package names
+
+import (
+	"unicode/utf8"
+)
+
+// Ways a query word can match a name word, from the most to the least certain
+const (
+	MethodExact           = "exact"
+	MethodHomoglyph       = "homoglyph"
+	MethodTransliteration = "transliteration"
+	MethodPhonetic        = "phonetic"
+	MethodTypo            = "typo"
+	MethodInitial         = "initial"
+)
+
+// MaxDistanceLimit bounds the edit distance a caller may ask for
+const MaxDistanceLimit = 3
+
+// Field is a name field of a candidate record
+type Field struct {
+	Name  string
+	Value string
+}
+
+// WordMatch explains how a query word matched a word of the record
+type WordMatch struct {
+	Query      string  `json:"query"`
+	Matched    string  `json:"matched"`
+	Field      string  `json:"field"`
+	Method     string  `json:"method"`
+	Distance   int     `json:"distance"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Result is the match of a whole query against a record
+type Result struct {
+	Score float64     `json:"score"`
+	Words []WordMatch `json:"words"`
+}
+
+// MaxDistance is the edit distance tolerated for a word of the length: none for short words,
+// where one edit turns a name into another, one for up to six letters and two beyond
+func MaxDistance(length int) int {
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Match checks that every word of the query matches a distinct word of the record fields.
+// maxDistance < 0 picks the tolerance by word length
+func Match(query string, fields []Field, maxDistance int) (Result, bool) {
+	type target struct {
+		field string
+		word  Word
+		used  bool
+	}
+	var targets []*target
+	for _, f := range fields {
+		for _, w := range Split(f.Value) {
+			targets = append(targets, &target{field: f.Name, word: Normalize(w)})
+		}
+	}
+
+	queryWords := Split(query)
+	if len(queryWords) == 0 {
+		return Result{}, false
+	}
+
+	var result Result
+	for _, qw := range queryWords {
+		q := Normalize(qw)
+		var best *target
+		var bestMatch WordMatch
+		for _, t := range targets {
+			if t.used {
+				continue
+			}
+			m, ok := matchWord(q, t.word, maxDistance)
+			if ok && (best == nil || m.Similarity > bestMatch.Similarity) {
+				best, bestMatch = t, m
+			}
+		}
+		if best == nil {
+			return Result{}, false
+		}
+		best.used = true
+		bestMatch.Field = best.field
+		result.Words = append(result.Words, bestMatch)
+		result.Score += bestMatch.Similarity
+	}
+	result.Score /= float64(len(queryWords))
+	return result, true
+}
+
+func matchWord(q, t Word, maxDistance int) (WordMatch, bool) {
+	m := WordMatch{Query: q.Original, Matched: t.Original}
+	qLen, tLen := utf8.RuneCountInString(q.Canonical), utf8.RuneCountInString(t.Canonical)
+	if qLen == 0 || tLen == 0 {
+		return m, false
+	}
+
+	switch {
+	case q.Canonical == t.Canonical:
+		m.Similarity = 1
+		switch {
+		case q.Transliterated != t.Transliterated:
+			m.Method = MethodTransliteration
+		case q.Homoglyphs || t.Homoglyphs:
+			m.Method = MethodHomoglyph
+		default:
+			m.Method = MethodExact
+		}
+		return m, true
+	case qLen == 1 || tLen == 1:
+		// Инициал совпадает с первой буквой полного имени
+		first, _ := utf8.DecodeRuneInString(q.Canonical)
+		other, _ := utf8.DecodeRuneInString(t.Canonical)
+		m.Method, m.Similarity = MethodInitial, 0.7
+		return m, first == other
+	case Phonetic(q.Canonical) == Phonetic(t.Canonical):
+		m.Method, m.Similarity = MethodPhonetic, 0.9
+		m.Distance = Distance(q.Canonical, t.Canonical)
+		return m, true
+	}
+
+	limit := maxDistance
+	if limit < 0 {
+		limit = MaxDistance(qLen)
+	}
+	m.Distance = Distance(q.Canonical, t.Canonical)
+	if m.Distance > limit {
+		return m, false
+	}
+	m.Method = MethodTypo
+	m.Similarity = 1 - float64(m.Distance)/float64(max(qLen, tLen))
+	return m, true
+}
+
+// Distance is the Damerau-Levenshtein distance (optimal string alignment) between two words in letters:
+// a swap of neighbouring letters counts as one edit
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
@@ -0,0 +1,98 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Латинские буквы, неотличимые на глаз от кириллических
+var latinToCyrillic = map[rune]rune{
+	'A': 'А', 'B': 'В', 'C': 'С', 'E': 'Е', 'H': 'Н', 'K': 'К', 'M': 'М', 'O': 'О', 'P': 'Р', 'T': 'Т', 'X': 'Х', 'Y': 'У',
+	'a': 'а', 'c': 'с', 'e': 'е', 'o': 'о', 'p': 'р', 'x': 'х', 'y': 'у',
+}
+
+// Word is a name word reduced to its comparison form
+type Word struct {
+	// Original is the word as written
+	Original string `json:"original"`
+	// Canonical is lowercase Cyrillic with ё, э -> е, й -> и and without ъ, ь, so that
+	// "Сергей", "Sergey" and "Sergei" compare equal
+	Canonical string `json:"canonical"`
+	// Homoglyphs is set when Latin look-alike letters were replaced inside a Cyrillic word
+	Homoglyphs bool `json:"homoglyphs,omitempty"`
+	// Transliterated is set when the word was written in Latin
+	Transliterated bool `json:"transliterated,omitempty"`
+}
+
+// Split breaks a name into words: "Петров-Водкин П.П." -> Петров, Водкин, П, П
+func Split(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// Normalize reduces a name word to its canonical form. A Cyrillic word keeps its script and only
+// its Latin look-alikes are replaced; a Latin word is transliterated back to Cyrillic
+func Normalize(word string) Word {
+	w := Word{Original: word}
+	cyrillic, latin := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	fixed := word
+	if cyrillic > 0 && latin > 0 {
+		fixed = strings.Map(func(r rune) rune {
+			if c, ok := latinToCyrillic[r]; ok {
+				w.Homoglyphs = true
+				return c
+			}
+			return r
+		}, word)
+	}
+	fixed = strings.ToLower(fixed)
+	if latin > 0 && hasLatin(fixed) {
+		fixed = ToCyrillic(fixed)
+		w.Transliterated = cyrillic == 0
+	}
+	w.Canonical = fold(fixed)
+	return w
+}
+
+// Canonical returns the canonical forms of all words of a name joined by spaces
+func Canonical(name string) string {
+	words := Split(name)
+	for i, word := range words {
+		words[i] = Normalize(word).Canonical
+	}
+	return strings.Join(words, " ")
+}
+
+func hasLatin(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// fold merges the letters transliteration does not keep apart
+func fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'ё', 'э':
+			return 'е'
+		case 'й':
+			return 'и'
+		case 'ъ', 'ь':
+			return -1
+		}
+		return r
+	}, s)
+}
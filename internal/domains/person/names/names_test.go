@@ -0,0 +1,93 @@
+package names
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeHomoglyphs(t *testing.T) {
+	// "Петрo" с латинской o из test_data/test2.csv
+	w := Normalize("Петрo")
+	assert.Equal(t, "петро", w.Canonical)
+	assert.True(t, w.Homoglyphs)
+	assert.False(t, w.Transliterated)
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, "елкин петр", Canonical("Ёлкин Пётр"))
+	assert.Equal(t, Canonical("Сергей"), Canonical("Sergey"))
+	assert.Equal(t, Canonical("Сергей"), Canonical("Sergei"))
+	assert.Equal(t, Canonical("Юлия"), Canonical("Iuliia"))
+	assert.Equal(t, Canonical("Юлия"), Canonical("Yuliya"))
+	assert.Equal(t, Canonical("Щукин"), Canonical("Shchukin"))
+	assert.Equal(t, Canonical("Щукин"), Canonical("Shhukin"))
+	assert.Equal(t, Canonical("Хабибуллин"), Canonical("Khabibullin"))
+	assert.Equal(t, Canonical("Цветков"), Canonical("Tsvetkov"))
+	assert.Equal(t, Canonical("Егор"), Canonical("Yegor"))
+	assert.Equal(t, Canonical("Мария"), Canonical("Mariia"))
+	assert.Equal(t, Canonical("Александр"), Canonical("Alexandr"))
+}
+
+func TestToLatin(t *testing.T) {
+	assert.Equal(t, "iuliia", ToLatin("Юлия", SystemICAO))
+	assert.Equal(t, "yuliya", ToLatin("Юлия", SystemMVD))
+	assert.Equal(t, "shhukin", ToLatin("Щукин", SystemGOST))
+
+	for _, name := range []string{"Андрей", "Юлия", "Щукин", "Хабибуллин", "Царёв", "Жуков"} {
+		for _, system := range []string{SystemICAO, SystemMVD, SystemGOST} {
+			variant := ToLatin(name, system)
+			assert.Equal(t, Canonical(name), Canonical(variant), variant)
+		}
+	}
+}
+
+func TestPhonetic(t *testing.T) {
+	assert.Equal(t, Phonetic("Дубков"), Phonetic("Дупкоф"))
+	assert.Equal(t, Phonetic("Иванов"), Phonetic("Ивонов"))
+	assert.Equal(t, Phonetic("Аннушкина"), Phonetic("Анушкина"))
+	assert.NotEqual(t, Phonetic("Иванов"), Phonetic("Иванова"))
+	assert.Equal(t, []string{Phonetic("петров")}, Phonetics("Петров Петрoв"))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance("иван", "иван"))
+	assert.Equal(t, 1, Distance("иван", "иаван"))
+	assert.Equal(t, 1, Distance("петров", "петорв"))
+	assert.Equal(t, 2, Distance("сидоров", "сидрова"))
+	assert.Equal(t, 4, Distance("", "петр"))
+}
+
+func TestMatch(t *testing.T) {
+	fields := []Field{{Name: "surname", Value: "Петров"}, {Name: "first_name", Value: "Петрo"}, {Name: "patronymic", Value: "Петрович"}}
+
+	r, ok := Match("Петро Петрович", fields, -1)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, r.Score)
+	assert.Equal(t, MethodHomoglyph, r.Words[0].Method)
+	assert.Equal(t, "first_name", r.Words[0].Field)
+	assert.Equal(t, "Петрo", r.Words[0].Matched)
+	assert.Equal(t, MethodExact, r.Words[1].Method)
+
+	r, ok = Match("Petrov Petro", fields, -1)
+	assert.True(t, ok)
+	assert.Equal(t, MethodTransliteration, r.Words[0].Method)
+
+	r, ok = Match("Петорв П.", fields, -1)
+	assert.True(t, ok)
+	assert.Equal(t, MethodTypo, r.Words[0].Method)
+	assert.Equal(t, 1, r.Words[0].Distance)
+	assert.Equal(t, MethodInitial, r.Words[1].Method)
+
+	r, ok = Match("Петрафф", fields, 0)
+	assert.True(t, ok)
+	assert.Equal(t, MethodPhonetic, r.Words[0].Method)
+
+	_, ok = Match("Петров Сидор", fields, -1)
+	assert.False(t, ok)
+	_, ok = Match("Петор", fields, 0)
+	assert.False(t, ok)
+	// Одно слово записи не засчитывается двум словам запроса
+	_, ok = Match("Петров Петров", []Field{{Name: "fio", Value: "Петров Иван"}}, -1)
+	assert.False(t, ok)
+}
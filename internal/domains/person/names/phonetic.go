@@ -0,0 +1,57 @@
+package names
+
+import "strings"
+
+// Phonetic returns a sound key of a canonical Cyrillic word: unstressed vowels are merged,
+// voiced consonants are devoiced at the end and before voiceless ones, doubled letters collapse:
+// "Дубков", "Дупков" and "Дубкоф" share a key
+func Phonetic(word string) string {
+	runes := []rune(fold(strings.ToLower(word)))
+	var key []rune
+	for i, r := range runes {
+		switch r {
+		case 'о', 'ы', 'я':
+			r = 'а'
+		case 'е', 'и':
+			r = 'и'
+		case 'ю':
+			r = 'у'
+		case 'б', 'в', 'г', 'д', 'ж', 'з':
+			if i+1 == len(runes) || voiceless(runes[i+1]) {
+				r = devoiced[r]
+			}
+		}
+		if len(key) > 0 && key[len(key)-1] == r {
+			continue
+		}
+		key = append(key, r)
+	}
+	return string(key)
+}
+
+var devoiced = map[rune]rune{'б': 'п', 'в': 'ф', 'г': 'к', 'д': 'т', 'ж': 'ш', 'з': 'с'}
+
+func voiceless(r rune) bool {
+	return strings.ContainsRune("пфктшсхцчщ", r)
+}
+
+// Phonetics returns the distinct sound keys of all words of a name
+func Phonetics(name string) []string {
+	var keys []string
+	for _, word := range Split(name) {
+		key := Phonetic(Normalize(word).Canonical)
+		if key != "" && !contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
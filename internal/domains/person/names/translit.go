@@ -0,0 +1,127 @@
+package names
+
+import (
+	"strings"
+)
+
+// Transliteration systems met in the sources
+const (
+	// SystemICAO is ICAO Doc 9303, used in Russian passports since 2013: Юлия -> IULIIA
+	SystemICAO = "icao"
+	// SystemGOST is GOST 7.79-2000 system B: Щукин -> Shhukin
+	SystemGOST = "gost"
+	// SystemMVD is the older passport rule: Юлия -> YULIYA
+	SystemMVD = "mvd"
+)
+
+var systems = map[string]map[rune]string{
+	SystemICAO: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y",
+		'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	},
+	SystemGOST: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "", 'ы': "y",
+		'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	},
+	SystemMVD: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+		'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	},
+}
+
+// ToLatin transliterates lowercase Cyrillic text with the system; other characters are kept
+func ToLatin(text, system string) string {
+	table, ok := systems[system]
+	if !ok {
+		table = systems[SystemICAO]
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if latin, ok := table[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Сочетания латинских букв всех систем, от длинных к коротким
+var toCyrillic = []struct{ latin, cyrillic string }{
+	{"shch", "щ"}, {"shh", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"cz", "ц"}, {"ch", "ч"}, {"sh", "ш"}, {"ph", "ф"},
+	{"yu", "ю"}, {"iu", "ю"}, {"ya", "я"}, {"ia", "я"}, {"yo", "ё"}, {"jo", "ё"}, {"ju", "ю"}, {"ja", "я"},
+	{"a", "а"}, {"b", "б"}, {"v", "в"}, {"w", "в"}, {"g", "г"}, {"d", "д"}, {"e", "е"}, {"z", "з"},
+	{"i", "и"}, {"j", "й"}, {"k", "к"}, {"q", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"}, {"o", "о"},
+	{"p", "п"}, {"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"}, {"f", "ф"}, {"h", "х"}, {"x", "кс"},
+}
+
+// ToCyrillic transliterates lowercase Latin letters back to Cyrillic, accepting the spellings of
+// every system. Ambiguous letters are resolved by position: "y" after a vowel at the end is "й"
+// (Sergey), otherwise "ы"; "c" is "ц" before e, i, y and "к" elsewhere; "x" is "х" at the start
+// (GOST Xabibullin) and "кс" elsewhere (Alexandr)
+func ToCyrillic(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		rest := string(runes[i:])
+		switch {
+		case i == 0 && strings.HasPrefix(rest, "ye"):
+			// Yegor, Yelena
+			b.WriteString("е")
+			i += 2
+			continue
+		case runes[i] == 'y' && !strings.HasPrefix(rest, "yu") && !strings.HasPrefix(rest, "ya") && !strings.HasPrefix(rest, "yo"):
+			if i > 0 && isVowel(runes[i-1]) && (i+1 == len(runes) || !isLetter(runes[i+1])) {
+				b.WriteString("й")
+			} else if i == 0 || !isLetter(runes[i-1]) {
+				b.WriteString("й")
+			} else {
+				b.WriteString("ы")
+			}
+			i++
+			continue
+		case i == 0 && runes[i] == 'x':
+			b.WriteString("х")
+			i++
+			continue
+		case runes[i] == 'c' && !strings.HasPrefix(rest, "ch") && !strings.HasPrefix(rest, "cz"):
+			if i+1 < len(runes) && strings.ContainsRune("eiyj", runes[i+1]) {
+				b.WriteString("ц")
+			} else {
+				b.WriteString("к")
+			}
+			i++
+			continue
+		}
+
+		matched := false
+		for _, pair := range toCyrillic {
+			if strings.HasPrefix(rest, pair.latin) {
+				b.WriteString(pair.cyrillic)
+				i += len([]rune(pair.latin))
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteRune(runes[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
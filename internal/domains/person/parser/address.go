@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"regexp"
+	"service/internal/domains/person/models"
+	"strings"
+	"unicode"
+)
+
+var (
+	settlementTypes = map[string]string{
+		"г": "город", "гор": "город", "город": "город",
+		"п": "поселок", "пос": "поселок", "поселок": "поселок", "посёлок": "поселок",
+		"пгт": "поселок городского типа", "рп": "рабочий поселок",
+		"с": "село", "село": "село",
+		"д": "деревня", "дер": "деревня", "деревня": "деревня",
+		"ст": "станица", "ст-ца": "станица", "станица": "станица",
+		"х": "хутор", "хут": "хутор", "хутор": "хутор",
+		"клх": "колхоз", "к": "кишлак", "аул": "аул", "снт": "снт",
+	}
+
+	streetTypes = map[string]string{
+		"ул": "улица", "улица": "улица",
+		"пр": "проспект", "пр-т": "проспект", "просп": "проспект", "проспект": "проспект",
+		"ш": "шоссе", "шоссе": "шоссе",
+		"алл": "аллея", "ал": "аллея", "аллея": "аллея",
+		"бул": "бульвар", "б-р": "бульвар", "бульвар": "бульвар",
+		"пер": "переулок", "переулок": "переулок",
+		"наб": "набережная", "набережная": "набережная",
+		"пл": "площадь", "площадь": "площадь",
+		"пр-д": "проезд", "проезд": "проезд",
+		"туп": "тупик", "тупик": "тупик",
+		"мкр": "микрорайон", "мкр-н": "микрорайон", "микрорайон": "микрорайон",
+		"тракт": "тракт", "линия": "линия",
+	}
+
+	regionTypes = map[string]string{
+		"обл": "область", "область": "область",
+		"респ": "республика", "республика": "республика",
+		"край": "край", "ао": "автономный округ",
+	}
+
+	districtTypes = map[string]string{"р-н": "район", "район": "район"}
+
+	// Части дома: "д. 3/4 к. 1 стр. 2 кв. 5"
+	houseParts = map[string]string{
+		"д": "house", "дом": "house",
+		"к": "building", "корп": "building", "корпус": "building",
+		"стр": "structure", "строение": "structure",
+		"кв": "flat", "квартира": "flat", "оф": "flat", "офис": "flat",
+	}
+
+	postalCodeRegex = regexp.MustCompile(`^\d{6}$`)
+	gluedDotRegex   = regexp.MustCompile(`\.([\p{L}\d])`)
+)
+
+// ParseAddress splits an address like "п. Двинской, ш. Овражное, д. 878 к. 48, 921506"
+// into components, expanding abbreviations of settlement and street types
+func ParseAddress(raw string) models.Address {
+	var address models.Address
+
+	raw = gluedDotRegex.ReplaceAllString(raw, ". $1")
+	for _, segment := range strings.Split(raw, ",") {
+		words := strings.Fields(segment)
+		if len(words) == 0 {
+			continue
+		}
+
+		if postalCodeRegex.MatchString(words[0]) {
+			address.PostalCode = words[0]
+			words = words[1:]
+			if len(words) == 0 {
+				continue
+			}
+		}
+
+		if isHouseSegment(words) {
+			parseHouse(words, &address)
+			continue
+		}
+
+		kind, typ, name := classifySegment(words)
+		switch kind {
+		case "region":
+			address.Region = joinType(typ, name)
+		case "district":
+			address.District = name
+		case "settlement":
+			address.SettlementType, address.Settlement = typ, name
+		case "street":
+			address.StreetType, address.Street = typ, name
+		default:
+			// Сегмент без типа: сначала населенный пункт, потом улица, потом дом
+			if len(words) == 1 && startsWithDigit(name) && address.House == "" {
+				address.House = name
+			} else if address.Settlement == "" && address.Street == "" {
+				address.Settlement = name
+			} else if address.Street == "" {
+				address.Street = name
+			}
+		}
+	}
+	return address
+}
+
+func abbreviation(word string) string {
+	return strings.TrimSuffix(strings.ToLower(word), ".")
+}
+
+func startsWithDigit(word string) bool {
+	for _, r := range word {
+		return unicode.IsDigit(r)
+	}
+	return false
+}
+
+// isHouseSegment reports whether the segment describes a house ("д. 5", "к. 2", "кв. 10"),
+// telling "д. 5" (дом) apart from "д. Ржев" (деревня)
+func isHouseSegment(words []string) bool {
+	if len(words) < 2 {
+		return false
+	}
+	_, ok := houseParts[abbreviation(words[0])]
+	return ok && startsWithDigit(words[1])
+}
+
+func parseHouse(words []string, address *models.Address) {
+	for i := 0; i < len(words)-1; i++ {
+		part, ok := houseParts[abbreviation(words[i])]
+		if !ok {
+			continue
+		}
+		value := words[i+1]
+		switch part {
+		case "house":
+			address.House = value
+		case "building":
+			address.Building = value
+		case "structure":
+			address.Structure = value
+		case "flat":
+			address.Flat = value
+		}
+		i++
+	}
+}
+
+// classifySegment detects the segment type by an abbreviation before ("ул. Ленина")
+// or after ("Московская обл.") the name
+func classifySegment(words []string) (kind, typ, name string) {
+	lookup := func(word string) (string, string) {
+		abbr := abbreviation(word)
+		if full, ok := regionTypes[abbr]; ok {
+			return "region", full
+		}
+		if full, ok := districtTypes[abbr]; ok {
+			return "district", full
+		}
+		if full, ok := streetTypes[abbr]; ok {
+			return "street", full
+		}
+		if full, ok := settlementTypes[abbr]; ok {
+			return "settlement", full
+		}
+		return "", ""
+	}
+
+	if len(words) > 1 {
+		if kind, typ = lookup(words[0]); kind != "" {
+			return kind, typ, strings.Join(words[1:], " ")
+		}
+		if kind, typ = lookup(words[len(words)-1]); kind != "" {
+			return kind, typ, strings.Join(words[:len(words)-1], " ")
+		}
+	}
+	return "", "", strings.Join(words, " ")
+}
+
+func joinType(typ, name string) string {
+	if typ == "" {
+		return name
+	}
+	return name + " " + typ
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"service/internal/domains/person/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		in   string
+		want models.Address
+	}{
+		{
+			"п. Двинской, ш. Овражное, д. 878 к. 48, 921506",
+			models.Address{PostalCode: "921506", SettlementType: "поселок", Settlement: "Двинской", StreetType: "шоссе", Street: "Овражное", House: "878", Building: "48"},
+		},
+		{
+			"ст. Цимлянск, пр. Осипенко, д. 3/4 к. 1, 475314",
+			models.Address{PostalCode: "475314", SettlementType: "станица", Settlement: "Цимлянск", StreetType: "проспект", Street: "Осипенко", House: "3/4", Building: "1"},
+		},
+		{
+			"д. Ржев, пер. Беляева, д. 167, 935692",
+			models.Address{PostalCode: "935692", SettlementType: "деревня", Settlement: "Ржев", StreetType: "переулок", Street: "Беляева", House: "167"},
+		},
+		{
+			"клх Киржач, пер. Карьерный, д. 7/5 стр. 1/3, 623930",
+			models.Address{PostalCode: "623930", SettlementType: "колхоз", Settlement: "Киржач", StreetType: "переулок", Street: "Карьерный", House: "7/5", Structure: "1/3"},
+		},
+		{
+			"г. Снежногорск (Мурм.), алл. 40 лет Октября, д. 57 к. 7, 846376",
+			models.Address{PostalCode: "846376", SettlementType: "город", Settlement: "Снежногорск (Мурм.)", StreetType: "аллея", Street: "40 лет Октября", House: "57", Building: "7"},
+		},
+		{
+			"123456, Московская обл., г.Химки, ул.Ленина, д.1, кв.15",
+			models.Address{PostalCode: "123456", Region: "Московская область", SettlementType: "город", Settlement: "Химки", StreetType: "улица", Street: "Ленина", House: "1", Flat: "15"},
+		},
+		{
+			"Москва, Тверская, 7",
+			models.Address{Settlement: "Москва", Street: "Тверская", House: "7"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			assert.Equal(t, c.want, ParseAddress(c.in))
+		})
+	}
+}
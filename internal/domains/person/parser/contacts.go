@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"regexp"
+	"service/internal/domains/person/models"
+	"strings"
+)
+
+var (
+	// Номера телефонов и документов разделяются запятыми, точками с запятой, косой чертой, переводом строки или "или"
+	valueSeparators = regexp.MustCompile(`\s*(?:[,;/|\n]|\sили\s)\s*`)
+	// В адресе запятые разделяют его части, поэтому адреса разделяются только ; | и переводом строки
+	addressSeparators = regexp.MustCompile(`\s*[;|\n]\s*`)
+)
+
+// SplitPhones splits a cell like "89161234567, 8 (495) 123-45-67" into separate numbers.
+// Numbers glued with spaces ("89161234567 84951234567") are split by their length
+func SplitPhones(cell string) []string {
+	var phones []string
+	for _, part := range splitValues(cell, valueSeparators) {
+		phones = append(phones, splitGluedPhones(part)...)
+	}
+	return phones
+}
+
+func splitGluedPhones(part string) []string {
+	digits := Digits(part)
+	if len(digits) <= 11 || len(digits)%11 != 0 {
+		return []string{part}
+	}
+	var phones []string
+	for i := 0; i < len(digits); i += 11 {
+		if digits[i] != '7' && digits[i] != '8' {
+			return []string{part}
+		}
+		phones = append(phones, digits[i:i+11])
+	}
+	return phones
+}
+
+// SplitAddresses splits a cell with several addresses
+func SplitAddresses(cell string) []string {
+	return splitValues(cell, addressSeparators)
+}
+
+// SplitDocuments splits a cell with several document numbers ("4510 123456; 4512 654321")
+func SplitDocuments(cell string) []string {
+	return splitValues(cell, valueSeparators)
+}
+
+func splitValues(cell string, separators *regexp.Regexp) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range separators.Split(cell, -1) {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	return values
+}
+
+// PhoneKind tells a mobile number (+79...) from a landline one by the normalized number
+func PhoneKind(phone string) string {
+	if strings.HasPrefix(phone, "+79") {
+		return models.PhoneMobile
+	}
+	if strings.HasPrefix(phone, "+7") {
+		return models.PhoneLandline
+	}
+	return ""
+}
@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Gender string
+
+const (
+	GenderUnknown Gender = ""
+	GenderMale    Gender = "male"
+	GenderFemale  Gender = "female"
+)
+
+// FullName is a FIO string split into its parts
+type FullName struct {
+	Surname    string `json:"surname"`
+	FirstName  string `json:"first_name"`
+	Patronymic string `json:"patronymic"`
+	Gender     Gender `json:"gender"`
+}
+
+var (
+	malePatronymicSuffixes   = []string{"ович", "евич", "ич"}
+	femalePatronymicSuffixes = []string{"овна", "евна", "ична", "инична"}
+
+	// Тюркские отчества пишутся отдельным словом: "Гейдар оглы"
+	malePatronymicWords   = map[string]bool{"оглы": true, "улы": true, "уулу": true}
+	femalePatronymicWords = map[string]bool{"кызы": true, "гызы": true}
+
+	maleSurnameSuffixes    = []string{"ов", "ев", "ёв", "ин", "ын", "ский", "цкий", "ской", "цкой"}
+	femaleSurnameSuffixes  = []string{"ова", "ева", "ёва", "ина", "ына", "ская", "цкая"}
+	neutralSurnameSuffixes = []string{"ых", "их", "ко", "енко", "ук", "юк", "дзе", "швили", "ян", "янц"}
+
+	// Имена, которые по окончанию легко принять за фамилию
+	surnameLikeFirstNames = map[string]bool{
+		"валентин": true, "вениамин": true, "константин": true, "мартин": true,
+		"ростислав": true, "святослав": true, "владислав": true, "ярослав": true,
+		"алина": true, "ангелина": true, "арина": true, "валентина": true, "галина": true, "екатерина": true,
+		"ирина": true, "карина": true, "кристина": true, "марина": true, "нина": true, "полина": true,
+		"регина": true, "христина": true,
+	}
+
+	// Мужские имена, оканчивающиеся на -а/-я
+	maleNamesEndingInA = map[string]bool{
+		"илья": true, "никита": true, "кузьма": true, "фома": true, "лука": true,
+		"савва": true, "данила": true, "гаврила": true, "миша": true,
+	}
+)
+
+// ParseFio splits a FIO string into surname, first name and patronymic.
+// The order of the parts is detected from patronymic suffixes and surname endings,
+// so both "Иванов Иван Иванович" and "Иван Иванович Иванов" are supported,
+// as well as initials ("Иванов И.И.") and double surnames.
+func ParseFio(fio string) FullName {
+	tokens := tokenizeFio(fio)
+	if len(tokens) == 0 {
+		return FullName{}
+	}
+
+	var name FullName
+	p := patronymicIndex(tokens)
+
+	switch {
+	case p > 0 && p == len(tokens)-1:
+		// Фамилия Имя Отчество
+		name.FirstName = tokens[p-1]
+		name.Surname = strings.Join(tokens[:p-1], " ")
+		name.Patronymic = tokens[p]
+	case p > 0:
+		// Имя Отчество Фамилия
+		name.FirstName = tokens[p-1]
+		name.Patronymic = tokens[p]
+		surname := append(append([]string{}, tokens[:p-1]...), tokens[p+1:]...)
+		name.Surname = strings.Join(surname, " ")
+	default:
+		name = parseWithoutPatronymic(tokens)
+	}
+
+	name.Gender = detectGender(name)
+	return name
+}
+
+// tokenizeFio splits the string into normalized words, expanding glued initials
+// ("И.И." -> "И.", "И.") and attaching turkic patronymic words to the previous token
+func tokenizeFio(fio string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(fio) {
+		word = strings.Trim(word, ",;")
+		if word == "" {
+			continue
+		}
+		if isInitials(word) {
+			for _, part := range strings.SplitAfter(word, ".") {
+				if part != "" {
+					tokens = append(tokens, strings.ToUpper(part))
+				}
+			}
+			continue
+		}
+		lower := strings.ToLower(word)
+		if (malePatronymicWords[lower] || femalePatronymicWords[lower]) && len(tokens) > 0 {
+			tokens[len(tokens)-1] += " " + lower
+			continue
+		}
+		tokens = append(tokens, capitalize(word))
+	}
+	return tokens
+}
+
+func isInitials(word string) bool {
+	if !strings.HasSuffix(word, ".") {
+		return false
+	}
+	for _, part := range strings.Split(strings.TrimSuffix(word, "."), ".") {
+		if utf8.RuneCountInString(part) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func isInitial(token string) bool {
+	return strings.HasSuffix(token, ".") && utf8.RuneCountInString(token) == 2
+}
+
+// capitalize приводит каждую часть (в том числе через дефис) к виду "Иванов-Петров"
+func capitalize(word string) string {
+	parts := strings.Split(word, "-")
+	for i, part := range parts {
+		runes := []rune(strings.ToLower(part))
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		parts[i] = string(runes)
+	}
+	return strings.Join(parts, "-")
+}
+
+// patronymicIndex returns the index of the token that looks like a patronymic, or -1
+func patronymicIndex(tokens []string) int {
+	// "Иван Петрович Зинкевич": фамилия тоже выглядит как отчество
+	if len(tokens) == 3 && isPatronymic(tokens[1]) && !looksLikeSurname(tokens[0]) {
+		return 1
+	}
+	for i := len(tokens) - 1; i > 0; i-- {
+		if isPatronymic(tokens[i]) {
+			return i
+		}
+	}
+	// "Иванов И. И." — второй инициал считается отчеством
+	if len(tokens) >= 3 && isInitial(tokens[len(tokens)-1]) && isInitial(tokens[len(tokens)-2]) {
+		return len(tokens) - 1
+	}
+	if len(tokens) >= 3 && isInitial(tokens[0]) && isInitial(tokens[1]) {
+		return 1
+	}
+	return -1
+}
+
+func isPatronymic(token string) bool {
+	lower := strings.ToLower(token)
+	if fields := strings.Fields(lower); len(fields) == 2 {
+		return malePatronymicWords[fields[1]] || femalePatronymicWords[fields[1]]
+	}
+	if utf8.RuneCountInString(lower) < 5 {
+		return false
+	}
+	return hasAnySuffix(lower, malePatronymicSuffixes) || hasAnySuffix(lower, femalePatronymicSuffixes)
+}
+
+func looksLikeSurname(token string) bool {
+	lower := strings.ToLower(token)
+	if utf8.RuneCountInString(lower) < 4 || surnameLikeFirstNames[lower] {
+		return false
+	}
+	return hasAnySuffix(lower, maleSurnameSuffixes) ||
+		hasAnySuffix(lower, femaleSurnameSuffixes) ||
+		hasAnySuffix(lower, neutralSurnameSuffixes)
+}
+
+func parseWithoutPatronymic(tokens []string) FullName {
+	switch len(tokens) {
+	case 1:
+		return FullName{Surname: tokens[0]}
+	case 2:
+		// "Иван Иванов" / "И. Иванов"
+		if isInitial(tokens[0]) || (!looksLikeSurname(tokens[0]) && looksLikeSurname(tokens[1])) {
+			return FullName{Surname: tokens[1], FirstName: tokens[0]}
+		}
+		return FullName{Surname: tokens[0], FirstName: tokens[1]}
+	default:
+		last := len(tokens) - 1
+		if !looksLikeSurname(tokens[0]) && looksLikeSurname(tokens[last]) {
+			return FullName{
+				FirstName:  tokens[0],
+				Patronymic: strings.Join(tokens[1:last], " "),
+				Surname:    tokens[last],
+			}
+		}
+		return FullName{
+			Surname:    tokens[0],
+			FirstName:  tokens[1],
+			Patronymic: strings.Join(tokens[2:], " "),
+		}
+	}
+}
+
+func detectGender(name FullName) Gender {
+	patronymic := strings.ToLower(name.Patronymic)
+	if fields := strings.Fields(patronymic); len(fields) == 2 {
+		if malePatronymicWords[fields[1]] {
+			return GenderMale
+		}
+		if femalePatronymicWords[fields[1]] {
+			return GenderFemale
+		}
+	}
+	if hasAnySuffix(patronymic, femalePatronymicSuffixes) {
+		return GenderFemale
+	}
+	if hasAnySuffix(patronymic, malePatronymicSuffixes) {
+		return GenderMale
+	}
+
+	// Двойная фамилия: ориентируемся на последнюю часть
+	surnameParts := strings.FieldsFunc(strings.ToLower(name.Surname), func(r rune) bool { return r == '-' || r == ' ' })
+	if len(surnameParts) > 0 {
+		surname := surnameParts[len(surnameParts)-1]
+		if hasAnySuffix(surname, femaleSurnameSuffixes) {
+			return GenderFemale
+		}
+		if hasAnySuffix(surname, maleSurnameSuffixes) {
+			return GenderMale
+		}
+	}
+
+	firstName := strings.ToLower(name.FirstName)
+	if firstName == "" || isInitial(name.FirstName) {
+		return GenderUnknown
+	}
+	if maleNamesEndingInA[firstName] {
+		return GenderMale
+	}
+	if strings.HasSuffix(firstName, "а") || strings.HasSuffix(firstName, "я") {
+		return GenderFemale
+	}
+	return GenderUnknown
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFio(t *testing.T) {
+	cases := []struct {
+		in   string
+		want FullName
+	}{
+		{"Дементьев Эммануил Елисеевич", FullName{Surname: "Дементьев", FirstName: "Эммануил", Patronymic: "Елисеевич", Gender: GenderMale}},
+		{"Раиса Тимуровна Денисова", FullName{Surname: "Денисова", FirstName: "Раиса", Patronymic: "Тимуровна", Gender: GenderFemale}},
+		{"иванов  иван иванович", FullName{Surname: "Иванов", FirstName: "Иван", Patronymic: "Иванович", Gender: GenderMale}},
+		{"Иванов И.И.", FullName{Surname: "Иванов", FirstName: "И.", Patronymic: "И.", Gender: GenderMale}},
+		{"И. И. Петрова", FullName{Surname: "Петрова", FirstName: "И.", Patronymic: "И.", Gender: GenderFemale}},
+		{"Римский-Корсаков Николай Андреевич", FullName{Surname: "Римский-Корсаков", FirstName: "Николай", Patronymic: "Андреевич", Gender: GenderMale}},
+		{"Петрова Водкина Анна Ивановна", FullName{Surname: "Петрова Водкина", FirstName: "Анна", Patronymic: "Ивановна", Gender: GenderFemale}},
+		{"Мамедов Рашид Гейдар оглы", FullName{Surname: "Мамедов", FirstName: "Рашид", Patronymic: "Гейдар оглы", Gender: GenderMale}},
+		{"Иван Петрович Зинкевич", FullName{Surname: "Зинкевич", FirstName: "Иван", Patronymic: "Петрович", Gender: GenderMale}},
+		{"Мария Иванова", FullName{Surname: "Иванова", FirstName: "Мария", Gender: GenderFemale}},
+		{"Валентин Сидоренко", FullName{Surname: "Сидоренко", FirstName: "Валентин"}},
+		{"Ирина Иванова", FullName{Surname: "Иванова", FirstName: "Ирина", Gender: GenderFemale}},
+		{"Иванова Ирина", FullName{Surname: "Иванова", FirstName: "Ирина", Gender: GenderFemale}},
+		{"Екатерина Смирнова", FullName{Surname: "Смирнова", FirstName: "Екатерина", Gender: GenderFemale}},
+		{"", FullName{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			assert.Equal(t, c.want, ParseFio(c.in))
+		})
+	}
+}
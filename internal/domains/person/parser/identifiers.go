@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"service/internal/domains/person/models"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"02.01.2006",
+	"2.1.2006",
+	"02/01/2006",
+	"02-01-2006",
+	"2006.01.02",
+	"2006/01/02",
+	"20060102",
+	"02.01.06",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// Digits returns only the digits of the string
+func Digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizePhone приводит российский номер к виду +7XXXXXXXXXX
+func NormalizePhone(phone string) (string, bool) {
+	digits := Digits(phone)
+	switch {
+	case len(digits) == 11 && (digits[0] == '7' || digits[0] == '8'):
+		return "+7" + digits[1:], true
+	case len(digits) == 10:
+		return "+7" + digits, true
+	}
+	return "", false
+}
+
+// NormalizeSnils приводит СНИЛС к виду XXX-XXX-XXX YY
+func NormalizeSnils(snils string) (string, bool) {
+	digits := Digits(snils)
+	if len(digits) != 11 {
+		return "", false
+	}
+	return digits[0:3] + "-" + digits[3:6] + "-" + digits[6:9] + " " + digits[9:], true
+}
+
+// ValidSnils checks the SNILS control number
+func ValidSnils(snils string) bool {
+	digits := Digits(snils)
+	if len(digits) != 11 {
+		return false
+	}
+	// Контрольное число проверяется только для номеров больше 001-001-998
+	if digits[:9] <= "001001998" {
+		return true
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (9 - i)
+	}
+	for sum > 101 {
+		sum %= 101
+	}
+	if sum == 100 || sum == 101 {
+		sum = 0
+	}
+
+	return sum == int(digits[9]-'0')*10+int(digits[10]-'0')
+}
+
+// NormalizeInn returns the digits of a 10 (organization) or 12 (individual) digit INN
+func NormalizeInn(inn string) (string, bool) {
+	digits := Digits(inn)
+	if len(digits) != 10 && len(digits) != 12 {
+		return "", false
+	}
+	return digits, true
+}
+
+// ValidInn checks the INN control digits
+func ValidInn(inn string) bool {
+	digits, ok := NormalizeInn(inn)
+	if !ok {
+		return false
+	}
+
+	control := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += int(digits[i]-'0') * w
+		}
+		return sum % 11 % 10
+	}
+
+	if len(digits) == 10 {
+		return control([]int{2, 4, 10, 3, 5, 9, 4, 6, 8}) == int(digits[9]-'0')
+	}
+	return control([]int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) == int(digits[10]-'0') &&
+		control([]int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) == int(digits[11]-'0')
+}
+
+// NormalizePassport приводит паспорт РФ к виду "SSSS NNNNNN"
+func NormalizePassport(passport string) (string, bool) {
+	digits := Digits(passport)
+	if len(digits) != 10 {
+		return "", false
+	}
+	return digits[:4] + " " + digits[4:], true
+}
+
+// IdentifierPhone is the identifier type of phones; documents use their kinds (models.DocumentSnils etc.)
+const IdentifierPhone = "phone"
+
+var identifierNormalizers = map[string]func(string) (string, bool){
+	IdentifierPhone:         NormalizePhone,
+	models.DocumentSnils:    NormalizeSnils,
+	models.DocumentInn:      NormalizeInn,
+	models.DocumentPassport: NormalizePassport,
+}
+
+// IdentifierTypes lists the identifier types NormalizeIdentifier accepts
+func IdentifierTypes() []string {
+	return []string{IdentifierPhone, models.DocumentSnils, models.DocumentInn, models.DocumentPassport}
+}
+
+// NormalizeIdentifier brings an identifier to the canonical form it is stored in at import,
+// so "9126182685" and "+7 912 618-26-85" give the same value. ok is false for an unknown type
+// or a value that is not an identifier of the type
+func NormalizeIdentifier(kind, value string) (string, bool) {
+	normalize, ok := identifierNormalizers[kind]
+	if !ok {
+		return "", false
+	}
+	return normalize(value)
+}
+
+// ParseDate parses a date in any of the formats seen in the imported files
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"testing"
+
+	"service/internal/domains/person/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for _, in := range []string{"+7 912 618-26-85", "89126182685", "9126182685", "8 (912) 618 26 85"} {
+		phone, ok := NormalizePhone(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, "+79126182685", phone, in)
+	}
+
+	_, ok := NormalizePhone("Dr.")
+	assert.False(t, ok)
+}
+
+func TestSnils(t *testing.T) {
+	snils, ok := NormalizeSnils("11223344595")
+	assert.True(t, ok)
+	assert.Equal(t, "112-233-445 95", snils)
+
+	assert.True(t, ValidSnils("112-233-445 95"))
+	assert.False(t, ValidSnils("112-233-445 96"))
+	assert.False(t, ValidSnils("123"))
+}
+
+func TestInn(t *testing.T) {
+	assert.True(t, ValidInn("7707083893"))
+	assert.True(t, ValidInn("500100732259"))
+	assert.False(t, ValidInn("7707083894"))
+	assert.False(t, ValidInn("123456789012"))
+	assert.False(t, ValidInn("12345"))
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for kind, in := range map[string][2]string{
+		IdentifierPhone:         {"9126182685", "+79126182685"},
+		models.DocumentSnils:    {"123456789 01", "123-456-789 01"},
+		models.DocumentInn:      {"7707-083-893", "7707083893"},
+		models.DocumentPassport: {"45 10 123456", "4510 123456"},
+	} {
+		value, ok := NormalizeIdentifier(kind, in[0])
+		assert.True(t, ok, kind)
+		assert.Equal(t, in[1], value, kind)
+	}
+
+	_, ok := NormalizeIdentifier(models.DocumentSnils, "123")
+	assert.False(t, ok)
+	_, ok = NormalizeIdentifier("email", "a@b.c")
+	assert.False(t, ok)
+}
+
+func TestParseDate(t *testing.T) {
+	for _, in := range []string{"1990-01-01", "01.01.1990", "01/01/1990"} {
+		date, ok := ParseDate(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, "1990-01-01", date.Format("2006-01-02"), in)
+	}
+
+	_, ok := ParseDate("Prof.")
+	assert.False(t, ok)
+}
+
+func TestSplitPhones(t *testing.T) {
+	assert.Equal(t, []string{"89161234567", "84951234567"}, SplitPhones("89161234567, 84951234567"))
+	assert.Equal(t, []string{"89161234567", "84951234567"}, SplitPhones("89161234567 84951234567"))
+	assert.Equal(t, []string{"+7 (916) 123-45-67", "8 495 123 45 67"}, SplitPhones("+7 (916) 123-45-67; 8 495 123 45 67"))
+	assert.Equal(t, []string{"8 916 123-45-67"}, SplitPhones("8 916 123-45-67"))
+	assert.Equal(t, []string{"89161234567", "84951234567"}, SplitPhones("89161234567 или 84951234567"))
+	assert.Empty(t, SplitPhones(" "))
+
+	assert.Equal(t, "mobile", PhoneKind("+79161234567"))
+	assert.Equal(t, "landline", PhoneKind("+74951234567"))
+}
+
+func TestSplitAddresses(t *testing.T) {
+	assert.Equal(t, []string{"г. Москва, ул. Ленина, д. 1", "г. Тула, ул. Мира, д. 2"},
+		SplitAddresses("г. Москва, ул. Ленина, д. 1; г. Тула, ул. Мира, д. 2"))
+	assert.Equal(t, []string{"4510 123456", "4512 654321"}, SplitDocuments("4510 123456, 4512 654321"))
+}
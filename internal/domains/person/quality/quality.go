@@ -0,0 +1,113 @@
+package quality
+
+import (
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/parser"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	CodeMissing       = "missing"
+	CodePlaceholder   = "placeholder"
+	CodeInvalid       = "invalid_format"
+	CodeChecksum      = "checksum_failed"
+	CodeUnparseable   = "unparseable"
+	CodeOutOfRange    = "out_of_range"
+	CodeIncompleteFio = "incomplete"
+)
+
+// Fields are the person fields taking part in the score, in response order
+var Fields = []string{"fio", "phone", "snils", "inn", "passport", "birth_date", "address"}
+
+// Значения-заглушки, которые встречаются в выгрузках вместо реальных данных (см. test.csv)
+var placeholders = map[string]bool{
+	"dr": true, "prof": true, "mr": true, "mrs": true, "miss": true, "ms": true,
+	"n/a": true, "na": true, "null": true, "none": true, "nil": true, "-": true, "--": true,
+	"test": true, "тест": true, "xxx": true, "нет": true, "неизвестно": true, "не указан": true,
+}
+
+// Evaluate checks every field of the person and returns the score (0-100)
+// together with the list of found issues
+func Evaluate(person models.Person) (float64, []models.QualityIssue) {
+	values := map[string]string{
+		"fio":        person.Fio,
+		"phone":      person.Phone,
+		"snils":      person.Snils,
+		"inn":        person.Inn,
+		"passport":   person.Passport,
+		"birth_date": person.BirthDate,
+		"address":    person.Address,
+	}
+
+	issues := []models.QualityIssue{}
+	for _, field := range Fields {
+		if code := check(field, strings.TrimSpace(values[field])); code != "" {
+			issues = append(issues, models.QualityIssue{Field: field, Code: code})
+		}
+	}
+
+	score := 100 * float64(len(Fields)-len(issues)) / float64(len(Fields))
+	return score, issues
+}
+
+func IsPlaceholder(value string) bool {
+	return placeholders[strings.Trim(strings.ToLower(value), ". ")]
+}
+
+func check(field, value string) string {
+	if value == "" {
+		return CodeMissing
+	}
+	if IsPlaceholder(value) {
+		return CodePlaceholder
+	}
+
+	switch field {
+	case "fio":
+		if strings.IndexFunc(value, unicode.IsDigit) >= 0 {
+			return CodeInvalid
+		}
+		name := parser.ParseFio(value)
+		if name.Surname == "" || name.FirstName == "" {
+			return CodeIncompleteFio
+		}
+	case "phone":
+		if _, ok := parser.NormalizePhone(value); !ok {
+			return CodeInvalid
+		}
+	case "snils":
+		if _, ok := parser.NormalizeSnils(value); !ok {
+			return CodeInvalid
+		}
+		if !parser.ValidSnils(value) {
+			return CodeChecksum
+		}
+	case "inn":
+		if _, ok := parser.NormalizeInn(value); !ok {
+			return CodeInvalid
+		}
+		if !parser.ValidInn(value) {
+			return CodeChecksum
+		}
+	case "passport":
+		if _, ok := parser.NormalizePassport(value); !ok {
+			return CodeInvalid
+		}
+	case "birth_date":
+		date, ok := parser.ParseDate(value)
+		if !ok {
+			return CodeUnparseable
+		}
+		if date.Year() < 1900 || date.After(time.Now()) {
+			return CodeOutOfRange
+		}
+	case "address":
+		address := parser.ParseAddress(value)
+		if address.Settlement == "" && address.Street == "" {
+			return CodeUnparseable
+		}
+	}
+	return ""
+}
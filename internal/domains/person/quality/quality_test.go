@@ -0,0 +1,34 @@
+package quality
+
+import (
+	"service/internal/domains/person/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	score, issues := Evaluate(models.Person{
+		Fio:       "Иванов Иван Иванович",
+		Phone:     "+79991234567",
+		Snils:     "112-233-445 95",
+		Inn:       "7707083893",
+		Passport:  "1234 567890",
+		BirthDate: "1990-01-01",
+		Address:   "г. Москва, ул. Ленина, д. 1",
+	})
+	assert.Equal(t, float64(100), score)
+	assert.Empty(t, issues)
+
+	score, issues = Evaluate(models.Person{Fio: "Dr.", Phone: "Prof.", Snils: "112-233-445 96", BirthDate: "31.02.1990"})
+	assert.InDelta(t, 0, score, 0.001)
+	assert.Equal(t, []models.QualityIssue{
+		{Field: "fio", Code: CodePlaceholder},
+		{Field: "phone", Code: CodePlaceholder},
+		{Field: "snils", Code: CodeChecksum},
+		{Field: "inn", Code: CodeMissing},
+		{Field: "passport", Code: CodeMissing},
+		{Field: "birth_date", Code: CodeUnparseable},
+		{Field: "address", Code: CodeMissing},
+	}, issues)
+}
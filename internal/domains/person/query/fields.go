@@ -0,0 +1,142 @@
+package query
+
+import (
+	"fmt"
+	"service/internal/domains/person/parser"
+	"strconv"
+	"strings"
+)
+
+type kind int
+
+const (
+	// kindText is free text compared case-insensitively, ё = е
+	kindText kind = iota
+	// kindIdentifier is a phone or document number compared by its normalized form, partially by digits
+	kindIdentifier
+	kindDate
+	kindYear
+	// kindExact is compared as is
+	kindExact
+)
+
+// Operators supported by each kind of field
+var operators = map[kind][]string{
+	kindText:       {OpEquals, OpPrefix, OpSuffix, OpContains, OpFuzzy, OpIn},
+	kindIdentifier: {OpEquals, OpPrefix, OpSuffix, OpContains, OpIn},
+	kindDate:       {OpEquals, OpRange, OpIn},
+	kindYear:       {OpEquals, OpRange, OpIn},
+	kindExact:      {OpEquals, OpIn},
+}
+
+// field describes how a search field is stored. Fields of child tables are matched with EXISTS
+// over the rows of the person (alias c)
+type field struct {
+	kind   kind
+	column string
+	// digits is the column reduced to digits for partial matches of identifiers
+	digits string
+	// table is the child table, empty for persons columns
+	table string
+	// where restricts the child rows, e.g. to one document kind
+	where string
+	// normalize brings an equals/in value to the stored form
+	normalize func(string) (string, bool)
+}
+
+func text(column string) string {
+	return fmt.Sprintf("lower(replace(COALESCE(%s, ''), 'ё', 'е'))", column)
+}
+
+func document(kind string, normalize func(string) (string, bool)) field {
+	return field{kind: kindIdentifier, column: "c.number", digits: `regexp_replace(c.number, '\D', '', 'g')`,
+		table: "person_documents", where: "c.kind = '" + kind + "'", normalize: normalize}
+}
+
+var fields = map[string]field{
+	"fio":        {kind: kindText, column: text("fio")},
+	"surname":    {kind: kindText, column: text("surname")},
+	"first_name": {kind: kindText, column: text("first_name")},
+	"patronymic": {kind: kindText, column: text("patronymic")},
+	"address":    {kind: kindText, column: text("c.raw"), table: "person_addresses"},
+	"region":     {kind: kindText, column: text("c.region"), table: "person_addresses"},
+	"city":       {kind: kindText, column: text("c.settlement"), table: "person_addresses"},
+	"street":     {kind: kindText, column: text("c.street"), table: "person_addresses"},
+	"phone": {kind: kindIdentifier, column: "c.value", digits: `regexp_replace(c.value, '\D', '', 'g')`,
+		table: "person_phones", normalize: parser.NormalizePhone},
+	"snils":           document("snils", parser.NormalizeSnils),
+	"inn":             document("inn", parser.NormalizeInn),
+	"passport":        document("passport", parser.NormalizePassport),
+	"birth_date":      {kind: kindDate, column: "birth_day"},
+	"birth_year":      {kind: kindYear, column: "date_part('year', birth_day)::int"},
+	"gender":          {kind: kindExact, column: "gender"},
+	"source":          {kind: kindExact, column: "source_id"},
+	"import_batch_id": {kind: kindExact, column: "import_batch_id::text"},
+}
+
+// AttributePrefix marks an attribute field: "attr.city"
+const AttributePrefix = "attr."
+
+// Fields lists the searchable fields with their operators, attributes aside
+func Fields() map[string][]string {
+	result := make(map[string][]string, len(fields))
+	for name, f := range fields {
+		result[name] = operators[f.kind]
+	}
+	return result
+}
+
+// normalizeText prepares free text the way the text columns are compared
+func normalizeText(value string) string {
+	value = strings.ReplaceAll(strings.ToLower(value), "ё", "е")
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// normalize brings a value to the form it is compared in. partial is set for prefix/suffix/contains
+func (f field) normalizeValue(value string, partial bool) (interface{}, error) {
+	switch f.kind {
+	case kindText:
+		return normalizeText(value), nil
+	case kindIdentifier:
+		if partial {
+			digits := parser.Digits(value)
+			if digits == "" {
+				return nil, fmt.Errorf("%w: %q has no digits", ErrInvalid, value)
+			}
+			return digits, nil
+		}
+		if normalized, ok := f.normalize(value); ok {
+			return normalized, nil
+		}
+		return strings.TrimSpace(value), nil
+	case kindDate:
+		date, ok := parser.ParseDate(value)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid date %q", ErrInvalid, value)
+		}
+		return date.Format("2006-01-02"), nil
+	case kindYear:
+		year, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid year %q", ErrInvalid, value)
+		}
+		return year, nil
+	case kindExact:
+		if f.column == "gender" {
+			return strings.ToLower(strings.TrimSpace(value)), nil
+		}
+		return strings.TrimSpace(value), nil
+	}
+	return value, nil
+}
+
+// cast is the SQL type a parameter of the field is compared as
+func (f field) cast() string {
+	switch f.kind {
+	case kindDate:
+		return "::date"
+	case kindYear:
+		return "::int"
+	}
+	return "::text"
+}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"fmt"
+	"service/internal/domains/person/listing"
+	"strconv"
+)
+
+// SortScore is the order of search results: the best matches first
+const SortScore = "score"
+
+// Request is the body of POST /persons/search
+type Request struct {
+	Query  Node   `json:"query"`
+	Limit  int    `json:"limit"`
+	Cursor string `json:"cursor"`
+	// Count is estimated (default), exact or none
+	Count string `json:"count"`
+	AsOf  string `json:"as_of"`
+}
+
+// Page is the SQL of one page of results: rows matching Where and Keyset,
+// ordered by score and id descending, at most Limit rows
+type Page struct {
+	Compiled
+	Keyset string
+	// PageArgs are the compiled args followed by the cursor values
+	PageArgs []interface{}
+	Limit    int
+}
+
+// BuildPage adds the cursor condition to a compiled query. One row more than the page
+// is requested to know if there is a next page
+func BuildPage(compiled Compiled, cursor *listing.Cursor, limit int) (Page, error) {
+	page := Page{Compiled: compiled, PageArgs: append([]interface{}{}, compiled.Args...), Limit: limit + 1}
+	if cursor == nil {
+		return page, nil
+	}
+	if cursor.Sort != SortScore || len(cursor.Values) != 2 || cursor.Backward {
+		return Page{}, fmt.Errorf("%w: cursor does not belong to a search", ErrInvalid)
+	}
+	score, err := strconv.ParseFloat(cursor.Values[0], 64)
+	if err != nil {
+		return Page{}, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+	id, err := strconv.Atoi(cursor.Values[1])
+	if err != nil {
+		return Page{}, fmt.Errorf("%w: malformed cursor", ErrInvalid)
+	}
+
+	page.PageArgs = append(page.PageArgs, score, id)
+	page.Keyset = fmt.Sprintf("(score, id) < ($%d::float8, $%d::int)", len(page.PageArgs)-1, len(page.PageArgs))
+	return page, nil
+}
+
+// NextCursor points after the last row of a page
+func NextCursor(score float64, id int) string {
+	return listing.Cursor{
+		Sort:   SortScore,
+		Values: []string{strconv.FormatFloat(score, 'g', -1, 64), strconv.Itoa(id)},
+	}.Encode()
+}
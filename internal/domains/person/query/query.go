@@ -0,0 +1,278 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operators of a condition
+const (
+	OpEquals   = "equals"
+	OpPrefix   = "prefix"
+	OpSuffix   = "suffix"
+	OpContains = "contains"
+	OpRange    = "range"
+	OpFuzzy    = "fuzzy"
+	OpIn       = "in"
+)
+
+// Limits of a query, so that a request cannot produce an unbounded statement
+const (
+	MaxDepth      = 8
+	MaxConditions = 50
+	MaxValues     = 200
+	// DefaultThreshold is the trigram similarity a fuzzy match needs unless set in the condition
+	DefaultThreshold = 0.4
+)
+
+// ErrInvalid is returned for a query that cannot be compiled
+var ErrInvalid = errors.New("invalid search query")
+
+// Node is a boolean group (and, or, not) or a condition on a field.
+//
+//	{"and": [
+//	  {"field": "surname", "op": "equals", "value": "Иванов"},
+//	  {"field": "birth_year", "op": "equals", "value": "1990"},
+//	  {"field": "phone", "op": "suffix", "value": "4567"},
+//	  {"not": {"field": "source", "op": "in", "values": ["legacy"]}}
+//	]}
+type Node struct {
+	And []Node `json:"and,omitempty"`
+	Or  []Node `json:"or,omitempty"`
+	Not *Node  `json:"not,omitempty"`
+
+	Field  string   `json:"field,omitempty"`
+	Op     string   `json:"op,omitempty"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+	// From and To bound a range, both inclusive; either may be empty
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Threshold is the minimal similarity of a fuzzy match (0..1)
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// Compiled is a query compiled to SQL over the persons table. Score is between 0 and 1:
+// the share of the positive conditions the row meets, fuzzy conditions count by their similarity
+type Compiled struct {
+	Where string
+	Score string
+	Args  []interface{}
+}
+
+type compiler struct {
+	args       []interface{}
+	conditions int
+	scores     []string
+}
+
+func (c *compiler) param(value interface{}) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+// Compile turns the query into a parameterized condition and a score expression.
+// Values never get into the SQL text, field names are taken from a fixed list
+func Compile(node Node, asOf *time.Time) (Compiled, error) {
+	c := &compiler{}
+	where, err := c.node(node, 0, false)
+	if err != nil {
+		return Compiled{}, err
+	}
+	if asOf != nil {
+		where = fmt.Sprintf("%s AND created_at <= %s", where, c.param(*asOf))
+	}
+
+	score := "1.0"
+	if len(c.scores) > 0 {
+		score = fmt.Sprintf("(%s) / %d.0", strings.Join(c.scores, " + "), len(c.scores))
+	}
+	return Compiled{Where: where, Score: score, Args: c.args}, nil
+}
+
+func (c *compiler) node(n Node, depth int, negated bool) (string, error) {
+	if depth > MaxDepth {
+		return "", fmt.Errorf("%w: nesting deeper than %d", ErrInvalid, MaxDepth)
+	}
+
+	groups := 0
+	for _, set := range []bool{len(n.And) > 0, len(n.Or) > 0, n.Not != nil, n.Field != ""} {
+		if set {
+			groups++
+		}
+	}
+	if groups != 1 {
+		return "", fmt.Errorf("%w: a node must be exactly one of and, or, not or a field condition", ErrInvalid)
+	}
+
+	switch {
+	case n.Not != nil:
+		inner, err := c.node(*n.Not, depth+1, !negated)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + inner, nil
+	case len(n.And) > 0 || len(n.Or) > 0:
+		children, joiner := n.And, " AND "
+		if len(n.Or) > 0 {
+			children, joiner = n.Or, " OR "
+		}
+		parts := make([]string, 0, len(children))
+		for _, child := range children {
+			part, err := c.node(child, depth+1, negated)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, joiner) + ")", nil
+	}
+	return c.condition(n, negated)
+}
+
+func (c *compiler) condition(n Node, negated bool) (string, error) {
+	c.conditions++
+	if c.conditions > MaxConditions {
+		return "", fmt.Errorf("%w: more than %d conditions", ErrInvalid, MaxConditions)
+	}
+
+	f, err := c.field(n.Field)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(operators[f.kind], n.Op) {
+		return "", fmt.Errorf("%w: field %s does not support %q, use one of %s",
+			ErrInvalid, n.Field, n.Op, strings.Join(operators[f.kind], ", "))
+	}
+
+	var match, similarity string
+	switch n.Op {
+	case OpEquals:
+		value, err := c.value(f, n.Value, false)
+		if err != nil {
+			return "", err
+		}
+		match = fmt.Sprintf("%s = %s%s", f.column, c.param(value), f.cast())
+	case OpIn:
+		if len(n.Values) == 0 || len(n.Values) > MaxValues {
+			return "", fmt.Errorf("%w: in takes 1 to %d values", ErrInvalid, MaxValues)
+		}
+		values := make([]string, 0, len(n.Values))
+		for _, raw := range n.Values {
+			value, err := f.normalizeValue(raw, false)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, fmt.Sprint(value))
+		}
+		match = fmt.Sprintf("%s = ANY(%s%s[])", f.column, c.param(values), f.cast())
+	case OpPrefix, OpSuffix, OpContains:
+		value, err := c.value(f, n.Value, true)
+		if err != nil {
+			return "", err
+		}
+		pattern := escapeLike(fmt.Sprint(value))
+		switch n.Op {
+		case OpPrefix:
+			pattern += "%"
+		case OpSuffix:
+			pattern = "%" + pattern
+		default:
+			pattern = "%" + pattern + "%"
+		}
+		column := f.column
+		if f.kind == kindIdentifier {
+			column = f.digits
+		}
+		match = fmt.Sprintf("%s LIKE %s", column, c.param(pattern))
+	case OpRange:
+		if n.From == "" && n.To == "" {
+			return "", fmt.Errorf("%w: range on %s needs from or to", ErrInvalid, n.Field)
+		}
+		var bounds []string
+		if n.From != "" {
+			from, err := f.normalizeValue(n.From, false)
+			if err != nil {
+				return "", err
+			}
+			bounds = append(bounds, fmt.Sprintf("%s >= %s%s", f.column, c.param(from), f.cast()))
+		}
+		if n.To != "" {
+			to, err := f.normalizeValue(n.To, false)
+			if err != nil {
+				return "", err
+			}
+			bounds = append(bounds, fmt.Sprintf("%s <= %s%s", f.column, c.param(to), f.cast()))
+		}
+		match = strings.Join(bounds, " AND ")
+	case OpFuzzy:
+		value, err := c.value(f, n.Value, false)
+		if err != nil {
+			return "", err
+		}
+		threshold := n.Threshold
+		if threshold == 0 {
+			threshold = DefaultThreshold
+		}
+		if threshold < 0 || threshold > 1 {
+			return "", fmt.Errorf("%w: threshold must be between 0 and 1", ErrInvalid)
+		}
+		similarity = fmt.Sprintf("similarity(%s, %s)", f.column, c.param(value))
+		match = fmt.Sprintf("%s >= %s", similarity, strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+
+	condition := "(" + match + ")"
+	if f.table != "" {
+		link := "c.person_id = persons.id"
+		if f.where != "" {
+			link += " AND " + f.where
+		}
+		condition = fmt.Sprintf("EXISTS (SELECT 1 FROM %s c WHERE %s AND %s)", f.table, link, match)
+		if similarity != "" {
+			similarity = fmt.Sprintf("COALESCE((SELECT max(%s) FROM %s c WHERE %s AND %s), 0)", similarity, f.table, link, match)
+		}
+	}
+
+	// Условия под NOT на оценку не влияют
+	if !negated {
+		if similarity != "" && f.table == "" {
+			similarity = fmt.Sprintf("CASE WHEN %s THEN %s ELSE 0 END", condition, similarity)
+		}
+		if similarity == "" {
+			similarity = fmt.Sprintf("CASE WHEN %s THEN 1 ELSE 0 END", condition)
+		}
+		c.scores = append(c.scores, similarity)
+	}
+	return condition, nil
+}
+
+func (c *compiler) field(name string) (field, error) {
+	if key, ok := strings.CutPrefix(name, AttributePrefix); ok {
+		if key == "" {
+			return field{}, fmt.Errorf("%w: attribute key is required: %s<key>", ErrInvalid, AttributePrefix)
+		}
+		// Ключ атрибута передается параметром
+		return field{kind: kindText, column: text("attributes->>" + c.param(key) + "::text")}, nil
+	}
+	f, ok := fields[name]
+	if !ok {
+		return field{}, fmt.Errorf("%w: unknown field %q", ErrInvalid, name)
+	}
+	return f, nil
+}
+
+func (c *compiler) value(f field, value string, partial bool) (interface{}, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	return f.normalizeValue(value, partial)
+}
+
+// escapeLike escapes the LIKE wildcards of a user value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"service/internal/domains/person/listing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	node := Node{And: []Node{
+		{Field: "surname", Op: OpEquals, Value: " Семёнов "},
+		{Field: "birth_year", Op: OpEquals, Value: "1990"},
+		{Field: "phone", Op: OpSuffix, Value: "45-67"},
+		{Not: &Node{Field: "source", Op: OpIn, Values: []string{"legacy"}}},
+	}}
+
+	compiled, err := Compile(node, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "((lower(replace(COALESCE(surname, ''), 'ё', 'е')) = $1::text) AND "+
+		"(date_part('year', birth_day)::int = $2::int) AND "+
+		`EXISTS (SELECT 1 FROM person_phones c WHERE c.person_id = persons.id AND regexp_replace(c.value, '\D', '', 'g') LIKE $3) AND `+
+		"NOT (source_id = ANY($4::text[])))", compiled.Where)
+	assert.Equal(t, []interface{}{"семенов", 1990, "%4567", []string{"legacy"}}, compiled.Args)
+	// Три положительных условия, условие под NOT в оценке не участвует
+	assert.Contains(t, compiled.Score, "/ 3.0")
+}
+
+func TestCompileNormalization(t *testing.T) {
+	compiled, err := Compile(Node{Or: []Node{
+		{Field: "phone", Op: OpEquals, Value: "8 (916) 123-45-67"},
+		{Field: "snils", Op: OpIn, Values: []string{"11223344595"}},
+		{Field: "birth_date", Op: OpRange, From: "01.01.1990", To: "31.12.1990"},
+		{Field: "attr.city", Op: OpPrefix, Value: "Моск_"},
+	}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"+79161234567", []string{"112-233-445 95"}, "1990-01-01", "1990-12-31",
+		"city", `моск\_%`}, compiled.Args)
+	assert.Contains(t, compiled.Where, "c.kind = 'snils'")
+	assert.Contains(t, compiled.Where, "birth_day >= $3::date AND birth_day <= $4::date")
+}
+
+func TestCompileFuzzy(t *testing.T) {
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	compiled, err := Compile(Node{Field: "fio", Op: OpFuzzy, Value: "Иванов Иван", Threshold: 0.5}, &asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, "(similarity(lower(replace(COALESCE(fio, ''), 'ё', 'е')), $1) >= 0.5) AND created_at <= $2", compiled.Where)
+	assert.Contains(t, compiled.Score, "THEN similarity(")
+}
+
+func TestCompileErrors(t *testing.T) {
+	for name, node := range map[string]Node{
+		"unknown field":    {Field: "quality_score", Op: OpEquals, Value: "1"},
+		"unsupported op":   {Field: "birth_date", Op: OpFuzzy, Value: "1990"},
+		"two kinds":        {Field: "fio", Op: OpEquals, Value: "x", And: []Node{{Field: "fio", Op: OpEquals, Value: "y"}}},
+		"empty node":       {},
+		"bad date":         {Field: "birth_date", Op: OpEquals, Value: "вчера"},
+		"empty in":         {Field: "gender", Op: OpIn},
+		"no digits":        {Field: "phone", Op: OpContains, Value: "abc"},
+		"open range":       {Field: "birth_year", Op: OpRange},
+		"empty attr key":   {Field: "attr.", Op: OpEquals, Value: "x"},
+		"threshold over 1": {Field: "fio", Op: OpFuzzy, Value: "x", Threshold: 2},
+	} {
+		_, err := Compile(node, nil)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+
+	deep := Node{Field: "fio", Op: OpEquals, Value: "x"}
+	for i := 0; i <= MaxDepth; i++ {
+		deep = Node{Not: &deep}
+	}
+	_, err := Compile(deep, nil)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestBuildPage(t *testing.T) {
+	compiled := Compiled{Where: "(gender = $1::text)", Score: "1.0", Args: []interface{}{"male"}}
+
+	page, err := BuildPage(compiled, nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Keyset)
+	assert.Equal(t, 11, page.Limit)
+
+	cursor, err := listing.DecodeCursor(NextCursor(0.6666666666666666, 42))
+	assert.NoError(t, err)
+	page, err = BuildPage(compiled, &cursor, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "(score, id) < ($2::float8, $3::int)", page.Keyset)
+	assert.Equal(t, []interface{}{"male", 0.6666666666666666, 42}, page.PageArgs)
+	assert.Equal(t, []interface{}{"male"}, page.Args)
+
+	_, err = BuildPage(compiled, &listing.Cursor{Sort: "name", Values: []string{"1"}}, 10)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
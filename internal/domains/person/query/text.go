@@ -0,0 +1,175 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"service/internal/domains/person/names"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Text search modes of GET /person/find. Each is served by an index: the trigram GIN indexes
+// of the text columns or the GIN index of the Russian search_vector over FIO and address
+const (
+	// ModeFullText is ranked full-text search with Russian morphology: "Ивановой" finds "Иванова"
+	ModeFullText = "fts"
+	// ModeTrigram finds values similar to the query above a threshold, typos included
+	ModeTrigram = "trigram"
+	// ModePrefix finds values starting with the query; without a field every word is a prefix
+	ModePrefix = "prefix"
+	// ModeName finds names written in another script, with look-alike letters, phonetic spelling or typos.
+	// The index only selects candidates by name_canonical and name_phonetic, see names.Match
+	ModeName = "name"
+)
+
+// DefaultTrigramThreshold is the trigram similarity a match needs unless set in the request
+const DefaultTrigramThreshold = 0.3
+
+// DefaultNameThreshold is the word similarity a name search candidate needs unless set in the request
+const DefaultNameThreshold = 0.3
+
+// Columns with trigram indexes
+var textColumns = []string{"fio", "surname", "first_name", "patronymic", "address"}
+
+// Text is a compiled text search: rows matching Where, ranked by Rank.
+// A trigram search runs with the pg_trgm setting ThresholdSetting set to Threshold
+type Text struct {
+	Mode             string
+	Where            string
+	Rank             string
+	Args             []interface{}
+	Threshold        float64
+	ThresholdSetting string
+}
+
+// CompileText compiles a text search. An empty field searches FIO and address
+func CompileText(mode, field, value string, threshold float64) (Text, error) {
+	value = strings.TrimSpace(strings.ReplaceAll(value, "ё", "е"))
+	if value == "" {
+		return Text{}, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	if field != "" && !slices.Contains(textColumns, field) {
+		return Text{}, fmt.Errorf("%w: text search supports fields %s", ErrInvalid, strings.Join(textColumns, ", "))
+	}
+
+	t := Text{Mode: mode}
+	switch mode {
+	case ModeFullText:
+		if field != "" {
+			return Text{}, fmt.Errorf("%w: full-text search covers FIO and address, omit the field", ErrInvalid)
+		}
+		t.Args = []interface{}{value}
+		t.Where = "search_vector @@ websearch_to_tsquery('russian', $1)"
+		t.Rank = "ts_rank_cd(search_vector, websearch_to_tsquery('russian', $1))"
+	case ModeTrigram:
+		if threshold == 0 {
+			threshold = DefaultTrigramThreshold
+		}
+		if threshold < 0 || threshold > 1 {
+			return Text{}, fmt.Errorf("%w: threshold must be between 0 and 1", ErrInvalid)
+		}
+		t.Threshold = threshold
+		t.ThresholdSetting = "pg_trgm.similarity_threshold"
+		t.Args = []interface{}{value}
+		// Оператор % использует GIN-индекс и порог pg_trgm.similarity_threshold
+		if field == "" {
+			t.Where = "(fio % $1 OR address % $1)"
+			t.Rank = "greatest(similarity(COALESCE(fio, ''), $1), similarity(COALESCE(address, ''), $1))"
+		} else {
+			t.Where = field + " % $1"
+			t.Rank = fmt.Sprintf("similarity(COALESCE(%s, ''), $1)", field)
+		}
+	case ModePrefix:
+		if field == "" {
+			words := prefixQuery(value)
+			if words == "" {
+				return Text{}, fmt.Errorf("%w: no words in %q", ErrInvalid, value)
+			}
+			t.Args = []interface{}{words}
+			t.Where = "search_vector @@ to_tsquery('russian', $1)"
+			t.Rank = "ts_rank_cd(search_vector, to_tsquery('russian', $1))"
+		} else {
+			t.Args = []interface{}{escapeLike(value) + "%", value}
+			t.Where = field + " ILIKE $1"
+			t.Rank = fmt.Sprintf("similarity(COALESCE(%s, ''), $2)", field)
+		}
+	case ModeName:
+		if field != "" && field != "fio" {
+			return Text{}, fmt.Errorf("%w: name search covers the whole FIO, omit the field", ErrInvalid)
+		}
+		if threshold == 0 {
+			threshold = DefaultNameThreshold
+		}
+		if threshold < 0 || threshold > 1 {
+			return Text{}, fmt.Errorf("%w: threshold must be between 0 and 1", ErrInvalid)
+		}
+		canonical := names.Canonical(value)
+		if canonical == "" {
+			return Text{}, fmt.Errorf("%w: no words in %q", ErrInvalid, value)
+		}
+		t.Threshold = threshold
+		t.ThresholdSetting = "pg_trgm.word_similarity_threshold"
+		t.Args = []interface{}{canonical, names.Phonetics(value)}
+		// Оператор %> сравнивает запрос со словами имени и использует GIN-индекс name_canonical
+		t.Where = "(name_canonical %> $1 OR name_phonetic && $2::text[])"
+		t.Rank = "word_similarity($1, COALESCE(name_canonical, ''))"
+	default:
+		return Text{}, fmt.Errorf("%w: unknown mode %q, use %s, %s, %s or %s", ErrInvalid, mode,
+			ModeFullText, ModeTrigram, ModePrefix, ModeName)
+	}
+	return t, nil
+}
+
+// prefixQuery turns "Иван Петр" into the tsquery "иван:* & петр:*". Only letters and digits
+// are kept, so the value cannot break the tsquery syntax
+func prefixQuery(value string) string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// PlanUsage summarizes a query plan: the indexes used and the tables read sequentially
+type PlanUsage struct {
+	Indexes  []string `json:"indexes"`
+	SeqScans []string `json:"seq_scans"`
+}
+
+// ReadPlan walks the output of EXPLAIN (FORMAT JSON)
+func ReadPlan(plan json.RawMessage) (PlanUsage, error) {
+	var roots []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &roots); err != nil {
+		return PlanUsage{}, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	usage := PlanUsage{Indexes: []string{}, SeqScans: []string{}}
+	var walk func(n planNode)
+	walk = func(n planNode) {
+		if n.IndexName != "" && !slices.Contains(usage.Indexes, n.IndexName) {
+			usage.Indexes = append(usage.Indexes, n.IndexName)
+		}
+		if n.NodeType == "Seq Scan" && !slices.Contains(usage.SeqScans, n.Relation) {
+			usage.SeqScans = append(usage.SeqScans, n.Relation)
+		}
+		for _, child := range n.Plans {
+			walk(child)
+		}
+	}
+	for _, root := range roots {
+		walk(root.Plan)
+	}
+	return usage, nil
+}
+
+type planNode struct {
+	NodeType  string     `json:"Node Type"`
+	Relation  string     `json:"Relation Name"`
+	IndexName string     `json:"Index Name"`
+	Plans     []planNode `json:"Plans"`
+}
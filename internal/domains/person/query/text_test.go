@@ -0,0 +1,62 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileText(t *testing.T) {
+	text, err := CompileText(ModeFullText, "", "Семёнова Москва", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "search_vector @@ websearch_to_tsquery('russian', $1)", text.Where)
+	assert.Equal(t, []interface{}{"Семенова Москва"}, text.Args)
+
+	text, err = CompileText(ModeTrigram, "surname", "Ивонов", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "surname % $1", text.Where)
+	assert.Equal(t, DefaultTrigramThreshold, text.Threshold)
+
+	text, err = CompileText(ModePrefix, "", "Ив-Пет' | !", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"ив:* & пет:*"}, text.Args)
+
+	text, err = CompileText(ModePrefix, "fio", "Иванов_", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "fio ILIKE $1", text.Where)
+	assert.Equal(t, []interface{}{`Иванов\_%`, "Иванов_"}, text.Args)
+
+	// Латинская o в имени и латинская транслитерация дают один и тот же ключ
+	text, err = CompileText(ModeName, "", "Петрo Petrovich", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "петро петрович", text.Args[0])
+	assert.Equal(t, "pg_trgm.word_similarity_threshold", text.ThresholdSetting)
+
+	for name, args := range map[string][3]string{
+		"unknown mode":   {"regex", "", "x"},
+		"unknown field":  {ModeTrigram, "phone; --", "x"},
+		"field with fts": {ModeFullText, "fio", "x"},
+		"empty value":    {ModePrefix, "fio", " "},
+		"no words":       {ModePrefix, "", "!!"},
+		"name field":     {ModeName, "address", "x"},
+	} {
+		_, err := CompileText(args[0], args[1], args[2], 0)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+	_, err = CompileText(ModeTrigram, "", "x", 1.5)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestReadPlan(t *testing.T) {
+	plan := json.RawMessage(`[{"Plan": {"Node Type": "Limit", "Plans": [
+		{"Node Type": "Sort", "Plans": [
+			{"Node Type": "Bitmap Heap Scan", "Relation Name": "persons", "Plans": [
+				{"Node Type": "Bitmap Index Scan", "Index Name": "idx_persons_search_vector"}]},
+			{"Node Type": "Seq Scan", "Relation Name": "person_masters"}]}]}}]`)
+
+	usage, err := ReadPlan(plan)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"idx_persons_search_vector"}, usage.Indexes)
+	assert.Equal(t, []string{"person_masters"}, usage.SeqScans)
+}
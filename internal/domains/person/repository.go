@@ -2,57 +2,360 @@ package person
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
-	"log"
+	"github.com/jackc/pgx/v5"
+	"service/internal/domains/person/listing"
+	"service/internal/domains/person/matching"
 	"service/internal/domains/person/models"
+	"service/internal/domains/person/names"
+	"service/internal/domains/person/parser"
+	"service/internal/domains/person/quality"
+	"service/internal/domains/person/query"
+	"service/internal/domains/person/stats"
+	"service/internal/infrastructure/config"
 	"service/internal/infrastructure/storage/redis"
+	"strconv"
 	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrNothingToUnmerge is returned for a person that is the only member of its master
+	ErrNothingToUnmerge = errors.New("person is not merged with other records")
+	// ErrBatchNotFinished is returned for a batch that is still running or already rolled back
+	ErrBatchNotFinished = errors.New("import batch is running or already rolled back")
+	// ErrRollbackConflict is returned when a rollback would lose manual edits made after the batch
+	ErrRollbackConflict = errors.New("import batch has later manual edits")
 )
 
 type Repository struct {
-	db  *postgres.Wrapper
-	rdb *redis.RDB
+	db    *postgres.Wrapper
+	rdb   *redis.RDB
+	cache *redis.Cache
+	ttl   config.CacheConfig
 }
 
-func NewRepository(db *postgres.Wrapper, rdb *redis.RDB) *Repository {
+func NewRepository(db *postgres.Wrapper, rdb *redis.RDB, cacheConfig *config.CacheConfig) *Repository {
+	var ttl config.CacheConfig
+	if cacheConfig != nil {
+		ttl = *cacheConfig
+	}
 	return &Repository{
-		db:  db,
-		rdb: rdb,
+		db:    db,
+		rdb:   rdb,
+		cache: redis.NewCache(rdb, "persons", max(ttl.SearchTTL, ttl.PersonTTL)),
+		ttl:   ttl,
 	}
 }
 
-func (r *Repository) SavePerson(ctx context.Context, person models.Person) error {
+// personColumns is the select list for scanning rows into models.Person
+const personColumns = `
+    id,
+    COALESCE(fio, '') AS fio,
+    COALESCE(surname, '') AS surname,
+    COALESCE(first_name, '') AS first_name,
+    COALESCE(patronymic, '') AS patronymic,
+    COALESCE(gender, '') AS gender,
+    COALESCE(phone, '') AS phone,
+    COALESCE(snils, '') AS snils,
+    COALESCE(inn, '') AS inn,
+    COALESCE(passport, '') AS passport,
+    COALESCE(birth_date, '') AS birth_date,
+    COALESCE(address, '') AS address,
+    COALESCE(master_id, 0) AS master_id,
+    COALESCE(import_batch_id::text, '') AS import_batch_id,
+    COALESCE(quality_score, 0) AS quality_score,
+    quality_issues,
+    attributes,
+    COALESCE(source_id, '') AS source_id,
+    COALESCE(source_file, '') AS source_file,
+    COALESCE(source_row, 0) AS source_row,
+    raw_row,
+    created_at::text AS imported_at,
+    COALESCE(birth_day::text, '') AS birth_day,
+    COALESCE((SELECT m.golden -> 'conflicts' FROM person_masters m WHERE m.id = persons.master_id), '[]') AS conflicts,
+    COALESCE((SELECT jsonb_agg(to_jsonb(c) - 'id' - 'person_id' ORDER BY c.is_primary DESC, c.id)
+              FROM person_phones c WHERE c.person_id = persons.id), '[]') AS phones,
+    COALESCE((SELECT jsonb_agg(to_jsonb(c) - 'id' - 'person_id' ORDER BY c.is_primary DESC, c.id)
+              FROM person_addresses c WHERE c.person_id = persons.id), '[]') AS addresses,
+    COALESCE((SELECT jsonb_agg(to_jsonb(c) - 'id' - 'person_id' ORDER BY c.kind, c.is_primary DESC, c.id)
+              FROM person_documents c WHERE c.person_id = persons.id), '[]') AS documents`
+
+// SavePerson stores a source record with its matching keys and returns its id,
+// or 0 if exactly the same record already exists
+func (r *Repository) SavePerson(ctx context.Context, person models.Person, keys []matching.Key) (int, error) {
 	query := `
-        INSERT INTO persons (fio, phone, snils, inn, passport, birth_date,address)
-        VALUES ($1, $2, $3, $4, $5, $6,$7)
-        ON CONFLICT (fio, phone, snils, inn, passport, birth_date,address) DO NOTHING`
+        INSERT INTO persons (fio, phone, snils, inn, passport, birth_date, address, surname, first_name, patronymic, gender,
+                             import_batch_id, quality_score, quality_issues, attributes,
+                             source_id, source_file, source_row, raw_row, birth_day, name_canonical, name_phonetic)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULLIF($20, '')::date,
+                $21, $22)
+        ON CONFLICT (fio, phone, snils, inn, passport, birth_date,address) DO NOTHING
+        RETURNING id`
 
 	var birthDate interface{} = person.BirthDate
 	if person.BirthDate == "" {
 		birthDate = nil
 	}
+	var batchId interface{} = person.ImportBatchId
+	if person.ImportBatchId == "" {
+		batchId = nil
+	}
+	issues := person.QualityIssues
+	if issues == nil {
+		issues = []models.QualityIssue{}
+	}
+	attributes := person.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	source := person.Provenance
+	var sourceId, sourceFile, sourceRow interface{}
+	if source.SourceId != "" {
+		sourceId = source.SourceId
+	}
+	if source.SourceFile != "" {
+		sourceFile = source.SourceFile
+	}
+	if source.SourceRow != 0 {
+		sourceRow = source.SourceRow
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	_, err := r.db.Exec(ctx, query, person.Fio, person.Phone, person.Snils, person.Inn, person.Passport, birthDate, person.Address)
+	var id int
+	err = tx.QueryRow(ctx, query, person.Fio, person.Phone, person.Snils, person.Inn, person.Passport, birthDate, person.Address,
+		person.Surname, person.FirstName, person.Patronymic, person.Gender, batchId, person.QualityScore, issues, attributes,
+		sourceId, sourceFile, sourceRow, source.RawRow, person.BirthDay,
+		names.Canonical(personName(person)), nameKeys(person)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Такая запись уже есть
+		return 0, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to save person: %w", err)
+		return 0, fmt.Errorf("failed to save person: %w", err)
+	}
+
+	if err := saveContacts(ctx, tx, id, person); err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		_, err := tx.Exec(ctx, `INSERT INTO person_match_keys (person_id, kind, value) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			id, key.Kind, key.Value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save match key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return id, nil
+}
+
+// personName is the name the name search keys are built from: the parsed parts or the whole FIO
+func personName(person models.Person) string {
+	name := strings.TrimSpace(strings.Join([]string{person.Surname, person.FirstName, person.Patronymic}, " "))
+	if name == "" {
+		name = person.Fio
+	}
+	return name
+}
+
+func nameKeys(person models.Person) []string {
+	keys := names.Phonetics(personName(person))
+	if keys == nil {
+		keys = []string{}
+	}
+	return keys
+}
+
+// ParseNames splits the FIO of up to limit records imported before the name parts existed
+// and returns the number of records updated. Their name search keys are reset to be rebuilt from the parts
+func (r *Repository) ParseNames(ctx context.Context, limit int) (int, error) {
+	query := `
+        SELECT id, fio FROM persons
+        WHERE surname IS NULL AND COALESCE(fio, '') <> '' ORDER BY id LIMIT $1`
+
+	var persons []models.Person
+	if err := r.db.Select(ctx, &persons, query, limit); err != nil {
+		return 0, fmt.Errorf("failed to select persons without name parts: %w", err)
+	}
+
+	if len(persons) == 0 {
+		return 0, nil
+	}
+
+	// Пустая фамилия сохраняется как '', чтобы запись не выбиралась повторно
+	ids := make([]int, len(persons))
+	surnames := make([]string, len(persons))
+	firstNames := make([]string, len(persons))
+	patronymics := make([]string, len(persons))
+	genders := make([]string, len(persons))
+	for i, person := range persons {
+		name := parser.ParseFio(person.Fio)
+		ids[i] = person.Id
+		surnames[i] = name.Surname
+		firstNames[i] = name.FirstName
+		patronymics[i] = name.Patronymic
+		genders[i] = string(name.Gender)
+	}
+	query = `
+        UPDATE persons p
+        SET surname = u.surname, first_name = u.first_name, patronymic = u.patronymic, gender = u.gender,
+            name_canonical = NULL, name_phonetic = NULL
+        FROM unnest($1::int[], $2::text[], $3::text[], $4::text[], $5::text[])
+                 AS u (id, surname, first_name, patronymic, gender)
+        WHERE p.id = u.id`
+	if _, err := r.db.Exec(ctx, query, ids, surnames, firstNames, patronymics, genders); err != nil {
+		return 0, fmt.Errorf("failed to save name parts: %w", err)
+	}
+	return len(persons), nil
+}
+
+// IndexNames fills the name search keys of up to limit records imported before the keys existed
+// and returns the number of records updated
+func (r *Repository) IndexNames(ctx context.Context, limit int) (int, error) {
+	query := `
+        SELECT id, COALESCE(fio, '') AS fio, COALESCE(surname, '') AS surname,
+               COALESCE(first_name, '') AS first_name, COALESCE(patronymic, '') AS patronymic
+        FROM persons WHERE name_canonical IS NULL ORDER BY id LIMIT $1`
+
+	var persons []models.Person
+	if err := r.db.Select(ctx, &persons, query, limit); err != nil {
+		return 0, fmt.Errorf("failed to select persons without name keys: %w", err)
+	}
+
+	if len(persons) == 0 {
+		return 0, nil
+	}
+
+	// Массив массивов разной длины не передать в unnest, поэтому фонетические ключи склеиваются пробелом:
+	// ключи строятся из отдельных слов и пробелов не содержат
+	ids := make([]int, len(persons))
+	canonical := make([]string, len(persons))
+	phonetic := make([]string, len(persons))
+	for i, person := range persons {
+		ids[i] = person.Id
+		canonical[i] = names.Canonical(personName(person))
+		phonetic[i] = strings.Join(nameKeys(person), " ")
+	}
+	query = `
+        UPDATE persons p
+        SET name_canonical = u.canonical, name_phonetic = string_to_array(u.phonetic, ' ')
+        FROM unnest($1::int[], $2::text[], $3::text[]) AS u (id, canonical, phonetic)
+        WHERE p.id = u.id`
+	if _, err := r.db.Exec(ctx, query, ids, canonical, phonetic); err != nil {
+		return 0, fmt.Errorf("failed to save name keys: %w", err)
+	}
+	return len(persons), nil
+}
+
+// saveContacts stores the phones, addresses and documents of a source record.
+// A record prepared without them keeps only its parsed primary address
+func saveContacts(ctx context.Context, tx *postgres.TxWrapper, personId int, person models.Person) error {
+	sourceId := person.Provenance.SourceId
+
+	for _, phone := range person.Phones {
+		query := `
+            INSERT INTO person_phones (person_id, value, raw, kind, is_primary, valid_from, valid_to, source_id)
+            VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, '')::date, NULLIF($7, '')::date, NULLIF($8, ''))`
+		_, err := tx.Exec(ctx, query, personId, phone.Value, phone.Raw, phone.Kind, phone.Primary,
+			phone.ValidFrom, phone.ValidTo, sourceId)
+		if err != nil {
+			return fmt.Errorf("failed to save phone: %w", err)
+		}
+	}
+
+	addresses := person.Addresses
+	if len(addresses) == 0 && person.AddressParts != nil && !person.AddressParts.IsEmpty() {
+		address := *person.AddressParts
+		address.Raw = person.Address
+		address.Primary = true
+		addresses = []models.Address{address}
+	}
+	for _, address := range addresses {
+		address.SourceId = sourceId
+		if err := saveAddress(ctx, tx, personId, address); err != nil {
+			return err
+		}
+	}
+
+	for _, doc := range person.Documents {
+		query := `
+            INSERT INTO person_documents (person_id, kind, number, raw, is_primary, valid_from, valid_to, source_id)
+            VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, NULLIF($7, '')::date, NULLIF($8, ''))`
+		_, err := tx.Exec(ctx, query, personId, doc.Kind, doc.Number, doc.Raw, doc.Primary, doc.ValidFrom, doc.ValidTo, sourceId)
+		if err != nil {
+			return fmt.Errorf("failed to save document: %w", err)
+		}
 	}
 	return nil
 }
 
-func (r *Repository) FindPerson(ctx context.Context, field, value string) ([]models.Person, error) {
+func saveAddress(ctx context.Context, tx *postgres.TxWrapper, personId int, address models.Address) error {
+	query := `
+        INSERT INTO person_addresses (person_id, postal_code, region, district, settlement_type, settlement,
+                                      street_type, street, house, building, structure, flat,
+                                      raw, kind, is_primary, valid_from, valid_to, source_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
+                NULLIF($13, ''), NULLIF($14, ''), $15, NULLIF($16, '')::date, NULLIF($17, '')::date, NULLIF($18, ''))`
+
+	_, err := tx.Exec(ctx, query, personId, address.PostalCode, address.Region, address.District, address.SettlementType,
+		address.Settlement, address.StreetType, address.Street, address.House, address.Building, address.Structure, address.Flat,
+		address.Raw, address.Kind, address.Primary, address.ValidFrom, address.ValidTo, address.SourceId)
+	if err != nil {
+		return fmt.Errorf("failed to save address: %w", err)
+	}
+	return nil
+}
+
+// AttributePrefix marks a search field as an attribute key: field=attr.city
+const AttributePrefix = "attr."
+
+// FindPerson searches source records; with asOf only the records imported by then are searched
+func (r *Repository) FindPerson(ctx context.Context, field, value string, asOf *time.Time) ([]models.Person, error) {
 	var persons []models.Person
 
+	query, args, err := findQuery(field, value, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.cache.Fetch(ctx, redis.Key("find", query, args), r.ttl.SearchTTL, &persons, func() ([]string, error) {
+		if err := r.db.Select(ctx, &persons, query, args...); err != nil {
+			return nil, fmt.Errorf("query error: %w", err)
+		}
+		return []string{tagSearch}, nil
+	})
+	return persons, err
+}
+
+// findQuery builds the substring search of FindPerson
+func findQuery(field, value string, asOf *time.Time) (string, []interface{}, error) {
 	// Очищаем значение от лишних кавычек и пробелов
 	value = strings.Trim(value, `"' `)
 
 	// Формируем шаблон поиска
 	searchPattern := "%" + value + "%"
-	log.Printf("Search pattern: '%s'", searchPattern)
 
 	// Допустимые поля для поиска
 	validFields := map[string]bool{
 		"fio":        true,
+		"surname":    true,
+		"first_name": true,
+		"patronymic": true,
+		"gender":     true,
 		"phone":      true,
 		"snils":      true,
 		"inn":        true,
@@ -61,97 +364,1368 @@ func (r *Repository) FindPerson(ctx context.Context, field, value string) ([]mod
 		"birth_date": true,
 	}
 
+	// Поля разобранного адреса ищутся точным совпадением по индексам person_addresses
+	addressFields := map[string]string{
+		"city":        "lower(settlement) = lower($1)",
+		"street":      "lower(street) = lower($1)",
+		"region":      "lower(region) = lower($1)",
+		"postal_code": "postal_code = $1",
+	}
+
+	// Все значения многозначных полей лежат в дочерних таблицах
+	contactFields := map[string]string{
+		"phone":    "id IN (SELECT person_id FROM person_phones WHERE raw ILIKE $1 OR value ILIKE $1)",
+		"address":  "id IN (SELECT person_id FROM person_addresses WHERE raw ILIKE $1)",
+		"passport": "id IN (SELECT person_id FROM person_documents WHERE kind = 'passport' AND (raw ILIKE $1 OR number ILIKE $1))",
+		"snils":    "id IN (SELECT person_id FROM person_documents WHERE kind = 'snils' AND (raw ILIKE $1 OR number ILIKE $1))",
+		"inn":      "id IN (SELECT person_id FROM person_documents WHERE kind = 'inn' AND (raw ILIKE $1 OR number ILIKE $1))",
+	}
+
 	var query string
 	var args []interface{}
 
 	if field != "" {
 		// Проверяем, что указано допустимое поле
-		log.Println(field)
-		if !validFields[field] {
-			return nil, fmt.Errorf("invalid field: %s", field)
-		}
-
-		// Особая обработка для поля birth_date
-		if field == "birth_date" {
-			query = `
-                SELECT
-                    id,
-                    fio,
-                    phone,
-                    snils,
-                    inn,
-                    passport,
-                    birth_date,
-                    address
-                FROM persons
-                WHERE birth_date ILIKE $1`
-		} else {
+		condition, isAddressField := addressFields[field]
+		attribute, isAttribute := strings.CutPrefix(field, AttributePrefix)
+		if !validFields[field] && !isAddressField && !isAttribute {
+			return "", nil, fmt.Errorf("invalid field: %s", field)
+		}
+		if isAttribute && attribute == "" {
+			return "", nil, fmt.Errorf("attribute key is required: %s<key>", AttributePrefix)
+		}
+
+		if isAttribute {
+			// Ключ передается параметром, поэтому любые атрибуты безопасны
+			query = fmt.Sprintf(`SELECT %s FROM persons WHERE attributes->>$2 ILIKE $1`, personColumns)
+			args = []interface{}{searchPattern, attribute}
+		} else if isAddressField {
 			query = fmt.Sprintf(`
-                SELECT
-                    id,
-                    fio,
-                    phone,
-                    snils,
-                    inn,
-                    passport,
-                    birth_date,
-                    address
-                FROM persons
-                WHERE %s ILIKE $1`, field)
+                SELECT %s FROM persons
+                WHERE id IN (SELECT person_id FROM person_addresses WHERE %s)`, personColumns, condition)
+			args = []interface{}{value}
+		} else if field == "gender" {
+			// Пол сравниваем точно, иначе "male" совпадет с "female"
+			query = fmt.Sprintf(`SELECT %s FROM persons WHERE gender = $1`, personColumns)
+			args = []interface{}{strings.ToLower(value)}
+		} else if contact, ok := contactFields[field]; ok {
+			args = []interface{}{searchPattern}
+			// Номер в другом формате находится по каноническому значению
+			if canonical, ok := parser.NormalizeIdentifier(field, value); ok {
+				args = append(args, canonical)
+				contact += " OR " + identifierCondition(field, len(args))
+			}
+			query = fmt.Sprintf(`SELECT %s FROM persons WHERE (%s)`, personColumns, contact)
+		} else if field == "birth_date" {
+			// Дата ищется по разобранному birth_day в любом из форматов импорта
+			birth, ok := parser.ParseDate(value)
+			if !ok {
+				return "", nil, fmt.Errorf("invalid birth date: %s", value)
+			}
+			query = fmt.Sprintf(`SELECT %s FROM persons WHERE birth_day = $1::date`, personColumns)
+			args = []interface{}{birth.Format("2006-01-02")}
+		} else {
+			query = fmt.Sprintf(`SELECT %s FROM persons WHERE %s ILIKE $1`, personColumns, field)
+			args = []interface{}{searchPattern}
 		}
-		args = []interface{}{searchPattern}
 	} else {
 		// Поиск по всем полям
 		conditions := []string{
 			"fio ILIKE $1",
-			"phone ILIKE $1",
-			"snils ILIKE $1",
-			"inn ILIKE $1",
-			"passport ILIKE $1",
 			"address ILIKE $1",
-			"birth_date ILIKE $1",
-		}
-
-		query = fmt.Sprintf(`
-            SELECT
-                id,
-                fio,
-                phone,
-                snils,
-                inn,
-                passport,
-			 	COALESCE(birth_date, '') as birth_date,
-                address
-            FROM persons
-            WHERE %s`, strings.Join(conditions, " OR "))
-
+			"EXISTS (SELECT 1 FROM jsonb_each_text(attributes) WHERE value ILIKE $1)",
+			contactFields["phone"],
+			contactFields["address"],
+			"id IN (SELECT person_id FROM person_documents WHERE raw ILIKE $1 OR number ILIKE $1)",
+		}
 		args = []interface{}{searchPattern}
+		for _, kind := range parser.IdentifierTypes() {
+			if canonical, ok := parser.NormalizeIdentifier(kind, value); ok {
+				args = append(args, canonical)
+				conditions = append(conditions, identifierCondition(kind, len(args)))
+			}
+		}
+		if birth, ok := parser.ParseDate(value); ok {
+			args = append(args, birth.Format("2006-01-02"))
+			conditions = append(conditions, fmt.Sprintf("birth_day = $%d::date", len(args)))
+		}
+
+		query = fmt.Sprintf(`SELECT %s FROM persons WHERE (%s)`, personColumns, strings.Join(conditions, " OR "))
 	}
+	if asOf != nil {
+		args = append(args, *asOf)
+		query += fmt.Sprintf(" AND created_at <= $%d", len(args))
+	}
+	query += " ORDER BY surname, first_name, patronymic, id"
+	return query, args, nil
+}
 
-	log.Printf("Executing query:\n%s\nWith params: %v", query, args)
+// identifierCondition matches the canonical value of an identifier (parser.NormalizeIdentifier) in parameter n
+// by the btree indexes of person_phones.value and person_documents (kind, number)
+func identifierCondition(kind string, n int) string {
+	if kind == parser.IdentifierPhone {
+		return fmt.Sprintf("id IN (SELECT person_id FROM person_phones WHERE value = $%d)", n)
+	}
+	return fmt.Sprintf("id IN (SELECT person_id FROM person_documents WHERE kind = '%s' AND number = $%d)", kind, n)
+}
+
+// FindByIdentifier returns the records having the identifier of the kind, canonical as stored at import
+func (r *Repository) FindByIdentifier(ctx context.Context, kind, canonical string, asOf *time.Time) ([]models.Person, error) {
+	query := fmt.Sprintf(`SELECT %s FROM persons WHERE %s`, personColumns, identifierCondition(kind, 1))
+	args := []interface{}{canonical}
+	if asOf != nil {
+		args = append(args, *asOf)
+		query += fmt.Sprintf(" AND created_at <= $%d", len(args))
+	}
+	query += " ORDER BY id"
+
+	var persons []models.Person
+	err := r.cache.Fetch(ctx, redis.Key("identifier", query, args), r.ttl.SearchTTL, &persons, func() ([]string, error) {
+		if err := r.db.Select(ctx, &persons, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to find persons by %s: %w", kind, err)
+		}
+		return []string{tagSearch}, nil
+	})
+	return persons, err
+}
+
+// ExplainFind runs the substring search of FindPerson under EXPLAIN ANALYZE
+func (r *Repository) ExplainFind(ctx context.Context, field, value string, asOf *time.Time) (*models.Explain, error) {
+	sql, args, err := findQuery(field, value, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	return explain(ctx, tx, "like", sql, args)
+}
+
+// TextSearch runs a text search served by the trigram and full-text indexes, the best matches first.
+// With explain the query runs under EXPLAIN ANALYZE and only the diagnostics are returned
+func (r *Repository) TextSearch(ctx context.Context, t query.Text, asOf *time.Time, limit int, explainOnly bool) ([]models.SearchHit, *models.Explain, error) {
+	if explainOnly {
+		return r.textSearch(ctx, t, asOf, limit, true)
+	}
+
+	var hits []models.SearchHit
+	err := r.cache.Fetch(ctx, redis.Key("text", t, asOf, limit), r.ttl.SearchTTL, &hits, func() ([]string, error) {
+		var err error
+		hits, _, err = r.textSearch(ctx, t, asOf, limit, false)
+		return []string{tagSearch}, err
+	})
+	return hits, nil, err
+}
+
+func (r *Repository) textSearch(ctx context.Context, t query.Text, asOf *time.Time, limit int, explainOnly bool) ([]models.SearchHit, *models.Explain, error) {
+	args := append([]interface{}{}, t.Args...)
+	where := t.Where
+	if asOf != nil {
+		args = append(args, *asOf)
+		where += fmt.Sprintf(" AND created_at <= $%d", len(args))
+	}
+	sql := fmt.Sprintf(`SELECT %s, %s AS score FROM persons WHERE %s ORDER BY score DESC, id LIMIT %d`,
+		personColumns, t.Rank, where, limit)
 
-	// Выполняем запрос
-	if err := r.db.Select(ctx, &persons, query, args...); err != nil {
-		log.Printf("Query error: %v", err)
-		return nil, fmt.Errorf("query error: %w", err)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	log.Printf("Found %d records", len(persons))
-	for _, p := range persons {
-		log.Printf("Person: %+v", p)
+	if t.Threshold > 0 {
+		// Порог действует только в этой транзакции
+		threshold := strconv.FormatFloat(t.Threshold, 'f', -1, 64)
+		if _, err := tx.Exec(ctx, `SELECT set_config($1, $2, true)`, t.ThresholdSetting, threshold); err != nil {
+			return nil, nil, fmt.Errorf("failed to set similarity threshold: %w", err)
+		}
 	}
 
+	if explainOnly {
+		diagnostics, err := explain(ctx, tx, t.Mode, sql, args)
+		return nil, diagnostics, err
+	}
+
+	var hits []models.SearchHit
+	if err := tx.Select(ctx, &hits, sql, args...); err != nil {
+		return nil, nil, fmt.Errorf("failed to search persons: %w", err)
+	}
+	return hits, nil, nil
+}
+
+// explain runs a query under EXPLAIN ANALYZE and reports the indexes it used
+func explain(ctx context.Context, tx *postgres.TxWrapper, mode, sql string, args []interface{}) (*models.Explain, error) {
+	var plan json.RawMessage
+	if err := tx.QueryRow(ctx, "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+sql, args...).Scan(&plan); err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	usage, err := query.ReadPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var timing []struct {
+		Planning  float64 `json:"Planning Time"`
+		Execution float64 `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(plan, &timing); err != nil {
+		return nil, fmt.Errorf("failed to read plan timing: %w", err)
+	}
+	if len(timing) == 0 {
+		return nil, fmt.Errorf("failed to read plan timing: empty plan %s", plan)
+	}
+
+	return &models.Explain{
+		Mode:        mode,
+		Query:       sql,
+		Args:        args,
+		Indexes:     usage.Indexes,
+		SeqScans:    usage.SeqScans,
+		IndexUsed:   len(usage.Indexes) > 0,
+		PlanningMs:  timing[0].Planning,
+		ExecutionMs: timing[0].Execution,
+		Plan:        plan,
+	}, nil
+}
+
+// ListPersons returns the rows of a page compiled by listing.Build
+func (r *Repository) ListPersons(ctx context.Context, q listing.Query) ([]models.Person, error) {
+	var conditions []string
+	if q.Where != "" {
+		conditions = append(conditions, q.Where)
+	}
+	if q.Keyset != "" {
+		conditions = append(conditions, q.Keyset)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM persons", personColumns)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", q.OrderBy, q.Limit)
+
+	var persons []models.Person
+	err := r.cache.Fetch(ctx, redis.Key("list", query, q.Args), r.ttl.SearchTTL, &persons, func() ([]string, error) {
+		if err := r.db.Select(ctx, &persons, query, q.Args...); err != nil {
+			return nil, fmt.Errorf("failed to query persons: %w", err)
+		}
+		return []string{tagSearch}, nil
+	})
+	return persons, err
+}
+
+// exportBatch is the number of rows fetched from the export cursor at once
+const exportBatch = 500
+
+// ExportPersons streams the persons matching the condition in id order to fn. The rows are read
+// from a server-side cursor, so the whole result never sits in memory
+func (r *Repository) ExportPersons(ctx context.Context, where string, args []interface{}, fn func(models.Person) error) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("DECLARE person_export NO SCROLL CURSOR FOR SELECT %s FROM persons", personColumns)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY id"
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	total := 0
+	for {
+		var batch []models.Person
+		if err := tx.Select(ctx, &batch, fmt.Sprintf("FETCH %d FROM person_export", exportBatch)); err != nil {
+			return total, fmt.Errorf("failed to fetch exported persons: %w", err)
+		}
+		for _, person := range batch {
+			if err := fn(person); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(batch) < exportBatch {
+			return total, nil
+		}
+	}
+}
+
+// SearchPersons returns the rows of a search page, the best matches first
+func (r *Repository) SearchPersons(ctx context.Context, page query.Page) ([]models.SearchHit, error) {
+	sql := fmt.Sprintf(`
+        SELECT * FROM (
+            SELECT %s, %s AS score FROM persons WHERE %s
+        ) hits`, personColumns, page.Score, page.Where)
+	if page.Keyset != "" {
+		sql += " WHERE " + page.Keyset
+	}
+	sql += fmt.Sprintf(" ORDER BY score DESC, id DESC LIMIT %d", page.Limit)
+
+	var hits []models.SearchHit
+	err := r.cache.Fetch(ctx, redis.Key("search", sql, page.PageArgs), r.ttl.SearchTTL, &hits, func() ([]string, error) {
+		if err := r.db.Select(ctx, &hits, sql, page.PageArgs...); err != nil {
+			return nil, fmt.Errorf("failed to search persons: %w", err)
+		}
+		return []string{tagSearch}, nil
+	})
+	return hits, err
+}
+
+// CountPersons counts the persons matching the page filter. The estimate is taken from
+// the table statistics or the query plan, so it does not scan millions of rows
+func (r *Repository) CountPersons(ctx context.Context, q listing.Query, exact bool) (int64, bool, error) {
+	var count struct {
+		Total int64
+		Exact bool
+	}
+	err := r.cache.Fetch(ctx, redis.Key("count", q.Where, q.CountArgs, exact), r.ttl.SearchTTL, &count, func() ([]string, error) {
+		var err error
+		count.Total, count.Exact, err = r.countPersons(ctx, q, exact)
+		return []string{tagSearch}, err
+	})
+	return count.Total, count.Exact, err
+}
+
+func (r *Repository) countPersons(ctx context.Context, q listing.Query, exact bool) (int64, bool, error) {
+	from := "FROM persons"
+	if q.Where != "" {
+		from += " WHERE " + q.Where
+	}
+
+	if !exact {
+		var estimate float64
+		if q.Where == "" {
+			err := r.db.QueryRow(ctx, `SELECT reltuples FROM pg_class WHERE oid = 'persons'::regclass`).Scan(&estimate)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to estimate persons: %w", err)
+			}
+		} else {
+			var plan []struct {
+				Plan struct {
+					Rows float64 `json:"Plan Rows"`
+				} `json:"Plan"`
+			}
+			if err := r.db.QueryRow(ctx, "EXPLAIN (FORMAT JSON) SELECT 1 "+from, q.CountArgs...).Scan(&plan); err != nil {
+				return 0, false, fmt.Errorf("failed to estimate persons: %w", err)
+			}
+			estimate = -1
+			if len(plan) > 0 {
+				estimate = plan[0].Plan.Rows
+			}
+		}
+		// Таблица еще не анализировалась - считаем точно
+		if estimate >= 0 {
+			return int64(estimate), false, nil
+		}
+	}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, "SELECT count(*) "+from, q.CountArgs...).Scan(&total); err != nil {
+		return 0, false, fmt.Errorf("failed to count persons: %w", err)
+	}
+	return total, true, nil
+}
+
+// StartBatch registers the source if it is new and opens an import batch
+func (r *Repository) StartBatch(ctx context.Context, batch models.ImportBatch) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `INSERT INTO sources (id, name) VALUES ($1, $1) ON CONFLICT (id) DO NOTHING`, batch.SourceId); err != nil {
+		return fmt.Errorf("failed to save source: %w", err)
+	}
+	query := `
+        INSERT INTO import_batches (id, source_id, file_name, format, status)
+        VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(ctx, query, batch.Id, batch.SourceId, batch.FileName, batch.Format, models.BatchRunning); err != nil {
+		return fmt.Errorf("failed to start import batch: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FinishBatch stores the counters and the final status of an import batch
+func (r *Repository) FinishBatch(ctx context.Context, result *models.ImportResult, status string) error {
+	query := `
+        UPDATE import_batches
+        SET status = $2, row_count = $3, saved = $4, merged = $5, rejected = $6, quarantined = $7, warnings = $8,
+            duplicates = $9, finished_at = now()
+        WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, result.BatchId, status, result.Rows, result.Saved, result.Merged, result.Rejected,
+		result.Quarantined, result.Warnings, result.Duplicates)
+	if err != nil {
+		return fmt.Errorf("failed to finish import batch: %w", err)
+	}
+	return nil
+}
+
+// SaveQuarantined stores a row held back by validation rules for manual review
+func (r *Repository) SaveQuarantined(ctx context.Context, batchId string, row int, person models.Person, violations []models.RuleViolation) error {
+	query := `
+        INSERT INTO quarantined_persons (import_batch_id, row_number, data, violations)
+        VALUES ($1, $2, $3, $4)`
+
+	if _, err := r.db.Exec(ctx, query, batchId, row, person, violations); err != nil {
+		return fmt.Errorf("failed to quarantine person: %w", err)
+	}
+	return nil
+}
+
+// QualityReport computes fill-rate and validity of every field over all persons and per import batch
+func (r *Repository) QualityReport(ctx context.Context, batchLimit int) (models.QualityReport, error) {
+	var report models.QualityReport
+
+	columns := make([]string, 0, len(quality.Fields)*2)
+	for _, field := range quality.Fields {
+		columns = append(columns,
+			fmt.Sprintf("count(*) FILTER (WHERE COALESCE(%s, '') <> '')", field),
+			fmt.Sprintf(`count(*) FILTER (WHERE COALESCE(%s, '') <> '' AND NOT quality_issues @> '[{"field": "%s"}]')`, field, field),
+		)
+	}
+	aggregates := strings.Join(columns, ",\n    ")
+
+	totals, err := r.queryQuality(ctx, fmt.Sprintf(`
+        SELECT 'total', count(*), COALESCE(avg(quality_score), 0)::float8,
+            %s
+        FROM persons`, aggregates))
+	if err != nil {
+		return report, err
+	}
+	if len(totals) > 0 {
+		report.Total = totals[0]
+	}
+
+	report.Batches, err = r.queryQuality(ctx, fmt.Sprintf(`
+        SELECT import_batch_id::text, count(*), COALESCE(avg(quality_score), 0)::float8,
+            %s
+        FROM persons
+        WHERE import_batch_id IS NOT NULL
+        GROUP BY import_batch_id
+        ORDER BY max(id) DESC
+        LIMIT $1`, aggregates), batchLimit)
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+func (r *Repository) queryQuality(ctx context.Context, query string, args ...interface{}) ([]models.BatchQuality, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quality: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.BatchQuality
+	for rows.Next() {
+		var batch models.BatchQuality
+		counts := make([]int64, len(quality.Fields)*2)
+		dest := []interface{}{&batch.BatchId, &batch.Rows, &batch.AvgScore}
+		for i := range counts {
+			dest = append(dest, &counts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan quality: %w", err)
+		}
+
+		for i, field := range quality.Fields {
+			fq := models.FieldQuality{Field: field}
+			if batch.Rows > 0 {
+				fq.Filled = 100 * float64(counts[i*2]) / float64(batch.Rows)
+			}
+			// Валидность считается только по заполненным значениям, пропуски учитывает fill-rate
+			if counts[i*2] > 0 {
+				fq.Valid = 100 * float64(counts[i*2+1]) / float64(counts[i*2])
+			}
+			batch.Fields = append(batch.Fields, fq)
+		}
+		result = append(result, batch)
+	}
+	return result, rows.Err()
+}
+
+// PersonStats counts the persons matching the condition by the values of the dimension columns,
+// the largest groups first. Groups are counted in the database, persons are never loaded
+func (r *Repository) PersonStats(ctx context.Context, columns []stats.Column, where string, args []interface{}) ([]models.StatsGroup, error) {
+	selects := make([]string, len(columns))
+	groupBy := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = fmt.Sprintf("%s AS d%d", column.Expr, i)
+		groupBy[i] = fmt.Sprintf("d%d", i)
+	}
+	sql := fmt.Sprintf("SELECT %s, COALESCE(p.master_id, p.id) AS person FROM persons p", strings.Join(selects, ", "))
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	sql = fmt.Sprintf(`
+        SELECT %s, count(*) AS records, count(DISTINCT person) AS people
+        FROM (%s) g
+        GROUP BY %s
+        ORDER BY people DESC, records DESC, %s`,
+		strings.Join(groupBy, ", "), sql, strings.Join(groupBy, ", "), strings.Join(groupBy, ", "))
+
+	var groups []models.StatsGroup
+	err := r.cache.Fetch(ctx, redis.Key("stats", sql, args), r.ttl.SearchTTL, &groups, func() ([]string, error) {
+		rows, err := r.db.Query(ctx, sql, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query person stats: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			values := make([]string, len(columns))
+			group := models.StatsGroup{Keys: make(map[string]string, len(columns))}
+			dest := make([]interface{}, 0, len(columns)+2)
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err := rows.Scan(append(dest, &group.Records, &group.People)...); err != nil {
+				return nil, fmt.Errorf("failed to scan person stats: %w", err)
+			}
+			for i, column := range columns {
+				group.Keys[column.Name] = values[i]
+			}
+			groups = append(groups, group)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read person stats: %w", err)
+		}
+		return []string{tagSearch}, nil
+	})
+	return groups, err
+}
+
+// MatchCandidates returns the records sharing at least one matching key with the person
+func (r *Repository) MatchCandidates(ctx context.Context, personId int, keys []matching.Key, limit int) ([]models.Person, error) {
+	var persons []models.Person
+	if len(keys) == 0 {
+		return persons, nil
+	}
+
+	kinds := make([]string, len(keys))
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		kinds[i], values[i] = key.Kind, key.Value
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s FROM persons
+        WHERE id IN (
+            SELECT k.person_id FROM person_match_keys k
+            JOIN unnest($2::text[], $3::text[]) AS q(kind, value) ON k.kind = q.kind AND k.value = q.value
+            WHERE k.person_id <> $1)
+        ORDER BY id
+        LIMIT $4`, personColumns)
+	if err := r.db.Select(ctx, &persons, query, personId, kinds, values, limit); err != nil {
+		return nil, fmt.Errorf("failed to query match candidates: %w", err)
+	}
 	return persons, nil
 }
 
-func (r *Repository) GetAllPersons(ctx context.Context) ([]models.Person, error) {
+// AttachToMaster puts the person into a master. Without masters a new one is created; with several
+// the clusters are joined into the oldest master, since the person links them together.
+// Returns the master and the masters absorbed into it
+func (r *Repository) AttachToMaster(ctx context.Context, personId int, masterIds []int) (int, []int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var target int
+	var absorbed []int
+	if len(masterIds) == 0 {
+		if err := tx.QueryRow(ctx, `INSERT INTO person_masters DEFAULT VALUES RETURNING id`).Scan(&target); err != nil {
+			return 0, nil, fmt.Errorf("failed to create master: %w", err)
+		}
+	} else if target, absorbed, err = joinMasters(ctx, tx, masterIds); err != nil {
+		return 0, nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE persons SET master_id = $1 WHERE id = $2`, target, personId); err != nil {
+		return 0, nil, fmt.Errorf("failed to attach person to master: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return target, absorbed, nil
+}
+
+// JoinMasters moves the members of all given masters into the oldest one and returns its id
+func (r *Repository) JoinMasters(ctx context.Context, masterIds []int) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	target, _, err := joinMasters(ctx, tx, masterIds)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return target, nil
+}
+
+// joinMasters returns the surviving master and the masters deleted after moving their members into it
+func joinMasters(ctx context.Context, tx *postgres.TxWrapper, masterIds []int) (int, []int, error) {
+	// Блокируем объединяемые мастер-записи от параллельного импорта
+	rows, err := tx.Query(ctx, `SELECT id FROM person_masters WHERE id = ANY($1) ORDER BY id FOR UPDATE`, masterIds)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to lock masters: %w", err)
+	}
+	var locked []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, nil, fmt.Errorf("failed to scan master: %w", err)
+		}
+		locked = append(locked, id)
+	}
+	rows.Close()
+	if len(locked) == 0 {
+		return 0, nil, fmt.Errorf("masters %v not found", masterIds)
+	}
+
+	target := locked[0]
+	others := locked[1:]
+	if len(others) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE persons SET master_id = $1 WHERE master_id = ANY($2)`, target, others); err != nil {
+			return 0, nil, fmt.Errorf("failed to join masters: %w", err)
+		}
+		if err := moveVersions(ctx, tx, target, others); err != nil {
+			return 0, nil, err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM person_masters WHERE id = ANY($1)`, others); err != nil {
+			return 0, nil, fmt.Errorf("failed to delete joined masters: %w", err)
+		}
+	}
+	return target, others, nil
+}
+
+// moveVersions closes the current versions of joined masters. Their history stays under their own ids
+// linked to the target by merged_into, so the target's state at a moment is not mixed with theirs
+func moveVersions(ctx context.Context, tx *postgres.TxWrapper, target int, others []int) error {
+	query := `UPDATE person_versions SET valid_to = now() WHERE master_id = ANY($1) AND valid_to IS NULL`
+	if _, err := tx.Exec(ctx, query, others); err != nil {
+		return fmt.Errorf("failed to close versions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE person_versions SET merged_into = $1 WHERE master_id = ANY($2)`, target, others); err != nil {
+		return fmt.Errorf("failed to link versions: %w", err)
+	}
+	return nil
+}
+
+// ProposeReview queues an uncertain pair for manual review. A pair that is already
+// in the queue or was decided is left as is, so rejected pairs are never proposed again
+func (r *Repository) ProposeReview(ctx context.Context, leftId, rightId int, match matching.Match) error {
+	if leftId > rightId {
+		leftId, rightId = rightId, leftId
+	}
+	query := `
+        INSERT INTO match_reviews (left_id, right_id, score, reasons)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (left_id, right_id) DO NOTHING`
+	if _, err := r.db.Exec(ctx, query, leftId, rightId, match.Score, match.Reasons); err != nil {
+		return fmt.Errorf("failed to propose review: %w", err)
+	}
+	return nil
+}
+
+// RejectedMasterPairs returns the pairs of masters that must not be joined
+// because a reviewer rejected a pair of their members
+func (r *Repository) RejectedMasterPairs(ctx context.Context, masterIds []int) ([][2]int, error) {
+	query := `
+        SELECT DISTINCT l.master_id, rt.master_id
+        FROM match_reviews mr
+        JOIN persons l ON l.id = mr.left_id
+        JOIN persons rt ON rt.id = mr.right_id
+        WHERE mr.status = 'rejected' AND l.master_id = ANY($1) AND rt.master_id = ANY($1)`
+	rows, err := r.db.Query(ctx, query, masterIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rejected pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs [][2]int
+	for rows.Next() {
+		var pair [2]int
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, fmt.Errorf("failed to scan rejected pair: %w", err)
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
+
+// GetPerson returns a source record, cached until an import, merge or edit touches it or its master
+func (r *Repository) GetPerson(ctx context.Context, id int) (models.Person, error) {
+	var person models.Person
+	err := r.cache.Fetch(ctx, redis.Key("person", id), r.ttl.PersonTTL, &person, func() ([]string, error) {
+		var persons []models.Person
+		query := fmt.Sprintf(`SELECT %s FROM persons WHERE id = $1`, personColumns)
+		if err := r.db.Select(ctx, &persons, query, id); err != nil {
+			return nil, fmt.Errorf("failed to query person: %w", err)
+		}
+		if len(persons) == 0 {
+			return nil, ErrNotFound
+		}
+		person = persons[0]
+		return personTags(person), nil
+	})
+	return person, err
+}
+
+// GetPersons returns the source records with the given ids
+func (r *Repository) GetPersons(ctx context.Context, ids []int) ([]models.Person, error) {
 	var persons []models.Person
-	if err := r.db.Select(ctx, &persons, "SELECT id, fio, phone, snils, inn, passport, COALESCE(birth_date, '') as birth_date , address FROM persons"); err != nil {
+	query := fmt.Sprintf(`SELECT %s FROM persons WHERE id = ANY($1) ORDER BY id`, personColumns)
+	if err := r.db.Select(ctx, &persons, query, ids); err != nil {
 		return nil, fmt.Errorf("failed to query persons: %w", err)
 	}
 	return persons, nil
 }
 
+// MasterMembers returns the source records of a master in import order
+func (r *Repository) MasterMembers(ctx context.Context, masterId int) ([]models.Person, error) {
+	var persons []models.Person
+	query := fmt.Sprintf(`SELECT %s FROM persons WHERE master_id = $1 ORDER BY id`, personColumns)
+	if err := r.db.Select(ctx, &persons, query, masterId); err != nil {
+		return nil, fmt.Errorf("failed to query master members: %w", err)
+	}
+	return persons, nil
+}
+
+// RefreshGolden rebuilds the golden record of a master from its members and manual overrides
+func (r *Repository) RefreshGolden(ctx context.Context, masterId int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockMasters(ctx, tx, []int{masterId}); err != nil {
+		return err
+	}
+	if err := rebuildGolden(ctx, tx, masterId); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// rebuildGolden stores the golden record of a master; a master without members is removed
+func rebuildGolden(ctx context.Context, tx *postgres.TxWrapper, masterId int) error {
+	var members []models.Person
+	query := fmt.Sprintf(`SELECT %s FROM persons WHERE master_id = $1 ORDER BY id`, personColumns)
+	if err := tx.Select(ctx, &members, query, masterId); err != nil {
+		return fmt.Errorf("failed to query master members: %w", err)
+	}
+
+	if len(members) == 0 {
+		if err := saveVersions(ctx, tx, masterId, models.Person{}); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM person_masters WHERE id = $1`, masterId); err != nil {
+			return fmt.Errorf("failed to delete empty master: %w", err)
+		}
+		return nil
+	}
+
+	var overrides map[string]string
+	if err := tx.QueryRow(ctx, `SELECT overrides FROM person_masters WHERE id = $1`, masterId).Scan(&overrides); err != nil {
+		return fmt.Errorf("failed to query overrides: %w", err)
+	}
+	policy, err := survivorshipPolicy(ctx, tx, members)
+	if err != nil {
+		return err
+	}
+	golden := matching.ApplyOverrides(matching.Survive(members, policy), overrides)
+
+	query = `UPDATE person_masters SET golden = $2, member_count = $3, updated_at = now() WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, masterId, golden, len(members)); err != nil {
+		return fmt.Errorf("failed to save golden record: %w", err)
+	}
+	return saveVersions(ctx, tx, masterId, golden)
+}
+
+// survivorshipPolicy loads the configured strategies and the trust levels of the members' sources
+func survivorshipPolicy(ctx context.Context, tx *postgres.TxWrapper, members []models.Person) (matching.Policy, error) {
+	policy := matching.Policy{Strategies: make(map[string]matching.Strategy), Trust: make(map[string]int)}
+
+	rows, err := tx.Query(ctx, `SELECT field, strategy FROM survivorship_rules`)
+	if err != nil {
+		return policy, fmt.Errorf("failed to query survivorship rules: %w", err)
+	}
+	for rows.Next() {
+		var field, strategy string
+		if err := rows.Scan(&field, &strategy); err != nil {
+			rows.Close()
+			return policy, fmt.Errorf("failed to scan survivorship rule: %w", err)
+		}
+		policy.Strategies[field] = matching.Strategy(strategy)
+	}
+	rows.Close()
+
+	var sourceIds []string
+	for _, m := range members {
+		if m.Provenance.SourceId != "" {
+			sourceIds = append(sourceIds, m.Provenance.SourceId)
+		}
+	}
+	rows, err = tx.Query(ctx, `SELECT id, trust FROM sources WHERE id = ANY($1)`, sourceIds)
+	if err != nil {
+		return policy, fmt.Errorf("failed to query source trust: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var trust int
+		if err := rows.Scan(&id, &trust); err != nil {
+			return policy, fmt.Errorf("failed to scan source trust: %w", err)
+		}
+		policy.Trust[id] = trust
+	}
+	return policy, rows.Err()
+}
+
+// MastersOfSource returns the masters that have members from the source, or all masters for an empty source
+func (r *Repository) MastersOfSource(ctx context.Context, sourceId string) ([]int, error) {
+	var ids []int
+	query := `
+        SELECT DISTINCT master_id FROM persons
+        WHERE master_id IS NOT NULL AND ($1 = '' OR source_id = $1)
+        ORDER BY master_id`
+	rows, err := r.db.Query(ctx, query, sourceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query masters: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan master: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// saveVersions closes the current versions of the fields whose golden value changed
+// and opens versions with the new values
+func saveVersions(ctx context.Context, tx *postgres.TxWrapper, masterId int, golden models.Person) error {
+	rows, err := tx.Query(ctx, `SELECT field, value FROM person_versions WHERE master_id = $1 AND valid_to IS NULL`, masterId)
+	if err != nil {
+		return fmt.Errorf("failed to query versions: %w", err)
+	}
+	current := make(map[string]string)
+	for rows.Next() {
+		var field, value string
+		if err := rows.Scan(&field, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan version: %w", err)
+		}
+		current[field] = value
+	}
+	rows.Close()
+
+	for _, field := range matching.OverridableFields {
+		value := strings.TrimSpace(matching.Value(golden, field))
+		old, known := current[field]
+		if old == value && (known || value == "") {
+			continue
+		}
+		if known {
+			query := `UPDATE person_versions SET valid_to = now() WHERE master_id = $1 AND field = $2 AND valid_to IS NULL`
+			if _, err := tx.Exec(ctx, query, masterId, field); err != nil {
+				return fmt.Errorf("failed to close version: %w", err)
+			}
+		}
+		if value != "" {
+			query := `INSERT INTO person_versions (master_id, field, value) VALUES ($1, $2, $3)`
+			if _, err := tx.Exec(ctx, query, masterId, field, value); err != nil {
+				return fmt.Errorf("failed to save version: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// lockMasters locks the masters and returns their current state
+func lockMasters(ctx context.Context, tx *postgres.TxWrapper, masterIds []int) ([]models.MasterSnapshot, error) {
+	query := `
+        SELECT m.id, m.golden, m.overrides,
+               COALESCE((SELECT jsonb_agg(p.id ORDER BY p.id) FROM persons p WHERE p.master_id = m.id), '[]')
+        FROM person_masters m
+        WHERE m.id = ANY($1)
+        ORDER BY m.id
+        FOR UPDATE`
+	rows, err := tx.Query(ctx, query, masterIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock masters: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.MasterSnapshot
+	for rows.Next() {
+		var snapshot models.MasterSnapshot
+		if err := rows.Scan(&snapshot.Id, &snapshot.Golden, &snapshot.Overrides, &snapshot.MemberIds); err != nil {
+			return nil, fmt.Errorf("failed to scan master: %w", err)
+		}
+		snapshot.Golden.Id = snapshot.Id
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// GetMaster returns the master of the given person
+func (r *Repository) GetMaster(ctx context.Context, personId int) (models.Master, error) {
+	var master models.Master
+	query := `
+        SELECT m.id, m.golden, m.member_count, m.updated_at::text
+        FROM person_masters m
+        JOIN persons p ON p.master_id = m.id
+        WHERE p.id = $1`
+	err := r.db.QueryRow(ctx, query, personId).Scan(&master.Id, &master.Golden, &master.MemberCount, &master.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return master, ErrNotFound
+	}
+	if err != nil {
+		return master, fmt.Errorf("failed to query master: %w", err)
+	}
+	master.Golden.Id = master.Id
+	return master, nil
+}
+
+// Merge joins the masters of the given records into the master of the survivor, applies the
+// chosen field values and records the operation with before/after snapshots in one transaction
+func (r *Repository) Merge(ctx context.Context, request models.MergeRequest) (*models.MergeAudit, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	masterOf := make(map[int]int)
+	rows, err := tx.Query(ctx, `SELECT id, COALESCE(master_id, 0) FROM persons WHERE id = ANY($1)`, request.Ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query persons: %w", err)
+	}
+	for rows.Next() {
+		var id, masterId int
+		if err := rows.Scan(&id, &masterId); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan person: %w", err)
+		}
+		masterOf[id] = masterId
+	}
+	rows.Close()
+
+	var masterIds []int
+	seen := make(map[int]bool)
+	for _, id := range request.Ids {
+		masterId, ok := masterOf[id]
+		if !ok || masterId == 0 {
+			return nil, fmt.Errorf("person %d: %w", id, ErrNotFound)
+		}
+		if !seen[masterId] {
+			seen[masterId] = true
+			masterIds = append(masterIds, masterId)
+		}
+	}
+	survivor := masterOf[request.Survivor]
+
+	before, err := lockMasters(ctx, tx, masterIds)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]string)
+	var others []int
+	for _, snapshot := range before {
+		if snapshot.Id == survivor {
+			for field, value := range snapshot.Overrides {
+				overrides[field] = value
+			}
+		} else {
+			others = append(others, snapshot.Id)
+		}
+	}
+	for field, value := range request.Fields {
+		overrides[field] = value
+	}
+
+	if len(others) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE persons SET master_id = $1 WHERE master_id = ANY($2)`, survivor, others); err != nil {
+			return nil, fmt.Errorf("failed to move members: %w", err)
+		}
+		if err := moveVersions(ctx, tx, survivor, others); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM person_masters WHERE id = ANY($1)`, others); err != nil {
+			return nil, fmt.Errorf("failed to delete merged masters: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE person_masters SET overrides = $2 WHERE id = $1`, survivor, overrides); err != nil {
+		return nil, fmt.Errorf("failed to save overrides: %w", err)
+	}
+	if err := rebuildGolden(ctx, tx, survivor); err != nil {
+		return nil, err
+	}
+
+	after, err := lockMasters(ctx, tx, []int{survivor})
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &models.MergeAudit{Action: models.ActionMerge, Operator: request.Operator, Reason: request.Reason,
+		MasterId: survivor, PersonIds: request.Ids, Before: before, After: after}
+	if err := saveAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return audit, nil
+}
+
+// Unmerge undoes the latest manual merge of the person's master, restoring the masters as they
+// were before it; records that joined the master later stay with it. Without a manual merge
+// the person is detached into a master of its own and kept apart from the others by rejected reviews
+func (r *Repository) Unmerge(ctx context.Context, personId int, request models.UnmergeRequest) (*models.MergeAudit, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var masterId int
+	err = tx.QueryRow(ctx, `SELECT COALESCE(master_id, 0) FROM persons WHERE id = $1`, personId).Scan(&masterId)
+	if errors.Is(err, pgx.ErrNoRows) || masterId == 0 {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query person: %w", err)
+	}
+
+	current, err := lockMasters(ctx, tx, []int{masterId})
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var mergeId int
+	var restored []models.MasterSnapshot
+	query := `
+        SELECT id, before FROM person_merge_audit
+        WHERE action = $1 AND master_id = $2 AND undone_by IS NULL
+        ORDER BY id DESC
+        LIMIT 1`
+	err = tx.QueryRow(ctx, query, models.ActionMerge, masterId).Scan(&mergeId, &restored)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to query merge audit: %w", err)
+	}
+
+	audit := &models.MergeAudit{Operator: request.Operator, Reason: request.Reason, MasterId: masterId,
+		PersonIds: []int{personId}, Before: current}
+
+	var affected []int
+	if mergeId != 0 {
+		audit.Action = models.ActionUnmerge
+		for _, snapshot := range restored {
+			affected = append(affected, snapshot.Id)
+			if snapshot.Id == masterId {
+				if _, err := tx.Exec(ctx, `UPDATE person_masters SET overrides = $2 WHERE id = $1`, masterId, snapshot.Overrides); err != nil {
+					return nil, fmt.Errorf("failed to restore overrides: %w", err)
+				}
+				continue
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO person_masters (id, golden, overrides) VALUES ($1, $2, $3)`,
+				snapshot.Id, snapshot.Golden, snapshot.Overrides)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore master %d: %w", snapshot.Id, err)
+			}
+			_, err = tx.Exec(ctx, `UPDATE persons SET master_id = $1 WHERE id = ANY($2) AND master_id = $3`,
+				snapshot.Id, snapshot.MemberIds, masterId)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore members of master %d: %w", snapshot.Id, err)
+			}
+			_, err = tx.Exec(ctx, `UPDATE person_versions SET merged_into = NULL WHERE master_id = $1 AND merged_into = $2`,
+				snapshot.Id, masterId)
+			if err != nil {
+				return nil, fmt.Errorf("failed to split versions of master %d: %w", snapshot.Id, err)
+			}
+		}
+	} else {
+		if len(current[0].MemberIds) < 2 {
+			return nil, ErrNothingToUnmerge
+		}
+		audit.Action = models.ActionDetach
+
+		var detached int
+		if err := tx.QueryRow(ctx, `INSERT INTO person_masters DEFAULT VALUES RETURNING id`).Scan(&detached); err != nil {
+			return nil, fmt.Errorf("failed to create master: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE persons SET master_id = $1 WHERE id = $2`, detached, personId); err != nil {
+			return nil, fmt.Errorf("failed to detach person: %w", err)
+		}
+
+		// Решение оператора не дает сопоставлению снова объединить записи
+		query := `
+            INSERT INTO match_reviews (left_id, right_id, score, status, operator, comment, decided_at)
+            SELECT LEAST($1, p.id), GREATEST($1, p.id), 0, 'rejected', $3, NULLIF($4, ''), now()
+            FROM persons p WHERE p.master_id = $2
+            ON CONFLICT (left_id, right_id) DO UPDATE
+                SET status = 'rejected', operator = EXCLUDED.operator, comment = EXCLUDED.comment, decided_at = now()`
+		if _, err := tx.Exec(ctx, query, personId, masterId, request.Operator, request.Reason); err != nil {
+			return nil, fmt.Errorf("failed to reject pairs: %w", err)
+		}
+		affected = []int{masterId, detached}
+	}
+
+	for _, id := range affected {
+		if err := rebuildGolden(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if audit.After, err = lockMasters(ctx, tx, affected); err != nil {
+		return nil, err
+	}
+	if err := saveAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+	if mergeId != 0 {
+		if _, err := tx.Exec(ctx, `UPDATE person_merge_audit SET undone_by = $2 WHERE id = $1`, mergeId, audit.Id); err != nil {
+			return nil, fmt.Errorf("failed to mark merge undone: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return audit, nil
+}
+
+func saveAudit(ctx context.Context, tx *postgres.TxWrapper, audit *models.MergeAudit) error {
+	query := `
+        INSERT INTO person_merge_audit (action, operator, reason, master_id, person_ids, before, after)
+        VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+        RETURNING id, created_at::text`
+	err := tx.QueryRow(ctx, query, audit.Action, audit.Operator, audit.Reason, audit.MasterId, audit.PersonIds,
+		audit.Before, audit.After).Scan(&audit.Id, &audit.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save audit: %w", err)
+	}
+	return nil
+}
+
+// RollbackBatch deletes every record created by the batch and rebuilds the golden records of
+// the masters they belonged to. Merges and review decisions made by operators after the batch
+// that involve its records block the rollback. With dryRun only the impact is computed.
+// Masters joined through a deleted record stay joined; they can be split with unmerge
+func (r *Repository) RollbackBatch(ctx context.Context, batchId string, dryRun bool) (*models.RollbackImpact, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	impact := &models.RollbackImpact{BatchId: batchId, DryRun: dryRun}
+
+	var status, createdAt string
+	err = tx.QueryRow(ctx, `SELECT status, created_at::text FROM import_batches WHERE id = $1 FOR UPDATE`, batchId).
+		Scan(&status, &createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import batch: %w", err)
+	}
+	if status == models.BatchRunning || status == models.BatchRolledBack {
+		return nil, ErrBatchNotFinished
+	}
+
+	var personIds []int
+	rows, err := tx.Query(ctx, `SELECT id FROM persons WHERE import_batch_id = $1 ORDER BY id FOR UPDATE`, batchId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query batch persons: %w", err)
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan person: %w", err)
+		}
+		personIds = append(personIds, id)
+	}
+	rows.Close()
+	impact.Persons = len(personIds)
+
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM quarantined_persons WHERE import_batch_id = $1`, batchId).
+		Scan(&impact.Quarantined); err != nil {
+		return nil, fmt.Errorf("failed to count quarantined rows: %w", err)
+	}
+
+	// Мастер-записи, в которые входят записи пакета: удаляемые целиком и пересчитываемые
+	var masterIds []int
+	rows, err = tx.Query(ctx, `
+        SELECT master_id, bool_and(import_batch_id IS NOT DISTINCT FROM $1)
+        FROM persons
+        WHERE master_id IN (SELECT master_id FROM persons WHERE import_batch_id = $1)
+        GROUP BY master_id
+        ORDER BY master_id`, batchId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query affected masters: %w", err)
+	}
+	for rows.Next() {
+		var masterId int
+		var onlyBatch bool
+		if err := rows.Scan(&masterId, &onlyBatch); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan master: %w", err)
+		}
+		masterIds = append(masterIds, masterId)
+		if onlyBatch {
+			impact.MastersDeleted++
+		} else {
+			impact.MastersRecomputed++
+		}
+	}
+	rows.Close()
+
+	if impact.Conflicts, err = rollbackConflicts(ctx, tx, createdAt, personIds, masterIds); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return impact, nil
+	}
+	if len(impact.Conflicts) > 0 {
+		return impact, ErrRollbackConflict
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM persons WHERE import_batch_id = $1`, batchId); err != nil {
+		return nil, fmt.Errorf("failed to delete batch persons: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM quarantined_persons WHERE import_batch_id = $1`, batchId); err != nil {
+		return nil, fmt.Errorf("failed to delete quarantined rows: %w", err)
+	}
+	if _, err := lockMasters(ctx, tx, masterIds); err != nil {
+		return nil, err
+	}
+	for _, masterId := range masterIds {
+		if err := rebuildGolden(ctx, tx, masterId); err != nil {
+			return nil, err
+		}
+	}
+	query := `UPDATE import_batches SET status = $2, rolled_back_at = now() WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, batchId, models.BatchRolledBack); err != nil {
+		return nil, fmt.Errorf("failed to mark batch rolled back: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	impact.Applied = true
+	return impact, nil
+}
+
+// rollbackConflicts finds operator edits made after the batch that involve its records:
+// merges that are still in effect and accepted or rejected review decisions
+func rollbackConflicts(ctx context.Context, tx *postgres.TxWrapper, since string, personIds, masterIds []int) ([]models.RollbackConflict, error) {
+	var conflicts []models.RollbackConflict
+	if len(personIds) == 0 {
+		return conflicts, nil
+	}
+
+	// Отмененные слияния и отменившие их операции взаимно погашены
+	query := `
+        SELECT a.id, a.action, a.operator, a.created_at::text
+        FROM person_merge_audit a
+        WHERE a.created_at >= $1::timestamptz
+          AND a.undone_by IS NULL
+          AND NOT EXISTS (SELECT 1 FROM person_merge_audit m WHERE m.undone_by = a.id)
+          AND (a.master_id = ANY($3)
+               OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(a.person_ids) p WHERE p::int = ANY($2))
+               OR EXISTS (SELECT 1 FROM jsonb_array_elements(a.after) s, jsonb_array_elements_text(s -> 'member_ids') m
+                          WHERE m::int = ANY($2)))
+        ORDER BY a.id`
+	rows, err := tx.Query(ctx, query, since, personIds, masterIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query merge audit: %w", err)
+	}
+	for rows.Next() {
+		var c models.RollbackConflict
+		if err := rows.Scan(&c.Id, &c.Kind, &c.Operator, &c.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan merge audit: %w", err)
+		}
+		conflicts = append(conflicts, c)
+	}
+	rows.Close()
+
+	query = `
+        SELECT id, status, COALESCE(operator, ''), COALESCE(decided_at::text, '')
+        FROM match_reviews
+        WHERE status IN ('accepted', 'rejected') AND (left_id = ANY($1) OR right_id = ANY($1))
+        ORDER BY id`
+	rows, err = tx.Query(ctx, query, personIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review decisions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := models.RollbackConflict{Kind: "review"}
+		if err := rows.Scan(&c.Id, &c.Status, &c.Operator, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review decision: %w", err)
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// History returns the versions of a master's golden record fields together with the history of
+// the masters merged into it. With asOf only the master's own versions valid at that moment are
+// returned: the merged masters described other golden records until the merge
+func (r *Repository) History(ctx context.Context, masterId int, asOf *time.Time) ([]models.Version, error) {
+	var versions []models.Version
+	query := `
+        WITH RECURSIVE merged (id) AS (
+            SELECT $1::int
+            UNION
+            SELECT v.master_id FROM person_versions v JOIN merged m ON v.merged_into = m.id
+        )
+        SELECT master_id, field, value, valid_from::text AS valid_from, COALESCE(valid_to::text, '') AS valid_to
+        FROM person_versions
+        WHERE ($2::timestamptz IS NULL AND master_id IN (SELECT id FROM merged))
+           OR (master_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to > $2))
+        ORDER BY field, valid_from, id`
+	if err := r.db.Select(ctx, &versions, query, masterId, asOf); err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	return versions, nil
+}
+
 func (r *Repository) ExecuteSQL(ctx context.Context, sqlStatements string) error {
 	_, err := r.db.Pool.Exec(ctx, sqlStatements)
 	return err
@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"service/internal/domains/person/models"
+	"sync"
+)
+
+// Engine holds the active ruleset; it is safe to reload rules while rows are being evaluated
+type Engine struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// Compile validates the rules and returns compiled copies of them
+func Compile(rules []Rule) ([]Rule, error) {
+	names := make(map[string]bool, len(rules))
+	compiled := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+// Load replaces the active ruleset. The old rules stay active if the new ones are invalid
+func (e *Engine) Load(rules []Rule) error {
+	compiled, err := Compile(rules)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.rules = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]Rule(nil), e.rules...)
+}
+
+// Evaluate checks the person against every active rule
+func (e *Engine) Evaluate(person models.Person) []models.RuleViolation {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return Evaluate(e.rules, person)
+}
+
+// Evaluate checks the person against the given compiled rules
+func Evaluate(rules []Rule, person models.Person) []models.RuleViolation {
+	var violations []models.RuleViolation
+	for i := range rules {
+		if violation, ok := rules[i].Check(person); !ok {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// ReadFile reads a JSON array of rules from a config file
+func ReadFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rules file: %w", err)
+	}
+	return rules, nil
+}
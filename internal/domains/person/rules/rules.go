@@ -0,0 +1,298 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/parser"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Severity string
+
+const (
+	SeverityWarn       Severity = "warn"
+	SeverityQuarantine Severity = "quarantine"
+	SeverityReject     Severity = "reject"
+)
+
+const (
+	TypeRequired = "required"
+	TypeRegex    = "regex"
+	TypeRange    = "range"
+	TypeEnum     = "enum"
+	TypeCompare  = "compare"
+)
+
+// Condition restricts a rule to rows where another field matches
+type Condition struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"` // present, absent, equals, matches
+	Value    string `json:"value,omitempty"`
+}
+
+// Rule is a declarative acceptance check for an imported row
+type Rule struct {
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Field    string     `json:"field"`
+	Pattern  string     `json:"pattern,omitempty"`
+	Min      *float64   `json:"min,omitempty"`
+	Max      *float64   `json:"max,omitempty"`
+	Values   []string   `json:"values,omitempty"`
+	Other    string     `json:"other,omitempty"`
+	Operator string     `json:"operator,omitempty"` // eq, ne, lt, lte, gt, gte for compare rules
+	When     *Condition `json:"when,omitempty"`
+	Severity Severity   `json:"severity"`
+	Message  string     `json:"message,omitempty"`
+
+	regex     *regexp.Regexp
+	whenRegex *regexp.Regexp
+}
+
+var severityOrder = map[Severity]int{SeverityWarn: 1, SeverityQuarantine: 2, SeverityReject: 3}
+
+// Worst returns the most severe level among the violations, or "" if there are none
+func Worst(violations []models.RuleViolation) Severity {
+	var worst Severity
+	for _, v := range violations {
+		if severityOrder[Severity(v.Severity)] > severityOrder[worst] {
+			worst = Severity(v.Severity)
+		}
+	}
+	return worst
+}
+
+// Compile validates the rule definition and prepares its regular expressions
+func (r *Rule) Compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.Field == "" {
+		return fmt.Errorf("rule %s: field is required", r.Name)
+	}
+	if !fields[r.Field] {
+		return fmt.Errorf("rule %s: unknown field %q", r.Name, r.Field)
+	}
+	if _, ok := severityOrder[r.Severity]; !ok {
+		return fmt.Errorf("rule %s: unknown severity %q", r.Name, r.Severity)
+	}
+
+	switch r.Type {
+	case TypeRequired:
+	case TypeRegex:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid pattern: %w", r.Name, err)
+		}
+		r.regex = re
+	case TypeRange:
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("rule %s: min or max is required", r.Name)
+		}
+	case TypeEnum:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("rule %s: values are required", r.Name)
+		}
+	case TypeCompare:
+		if r.Other == "" {
+			return fmt.Errorf("rule %s: other field is required", r.Name)
+		}
+		if !fields[r.Other] {
+			return fmt.Errorf("rule %s: unknown other field %q", r.Name, r.Other)
+		}
+		if _, ok := compareOperators[r.Operator]; !ok {
+			return fmt.Errorf("rule %s: unknown operator %q", r.Name, r.Operator)
+		}
+	default:
+		return fmt.Errorf("rule %s: unknown type %q", r.Name, r.Type)
+	}
+
+	if r.When != nil && !fields[r.When.Field] {
+		return fmt.Errorf("rule %s: unknown condition field %q", r.Name, r.When.Field)
+	}
+	if r.When != nil && !conditionOperators[r.When.Operator] {
+		return fmt.Errorf("rule %s: unknown condition operator %q", r.Name, r.When.Operator)
+	}
+	if r.When != nil && r.When.Operator == "matches" {
+		re, err := regexp.Compile(r.When.Value)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid condition pattern: %w", r.Name, err)
+		}
+		r.whenRegex = re
+	}
+	return nil
+}
+
+// Check evaluates the rule against the person and returns a violation if it fails
+func (r *Rule) Check(person models.Person) (models.RuleViolation, bool) {
+	if r.When != nil && !r.applies(person) {
+		return models.RuleViolation{}, true
+	}
+
+	value := FieldValue(person, r.Field)
+	ok := true
+
+	switch r.Type {
+	case TypeRequired:
+		ok = value != ""
+	case TypeRegex:
+		ok = value == "" || r.regex.MatchString(value)
+	case TypeRange:
+		if value == "" {
+			break
+		}
+		number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			ok = false
+			break
+		}
+		ok = (r.Min == nil || number >= *r.Min) && (r.Max == nil || number <= *r.Max)
+	case TypeEnum:
+		ok = value == "" || containsFold(r.Values, value)
+	case TypeCompare:
+		other := FieldValue(person, r.Other)
+		if value == "" || other == "" {
+			break
+		}
+		ok = compareOperators[r.Operator](compareValues(value, other))
+	}
+
+	if ok {
+		return models.RuleViolation{}, true
+	}
+
+	message := r.Message
+	if message == "" {
+		message = fmt.Sprintf("%s check failed for %s", r.Type, r.Field)
+	}
+	return models.RuleViolation{Rule: r.Name, Field: r.Field, Severity: string(r.Severity), Message: message}, false
+}
+
+func (r *Rule) applies(person models.Person) bool {
+	value := FieldValue(person, r.When.Field)
+	switch r.When.Operator {
+	case "present":
+		return value != ""
+	case "absent":
+		return value == ""
+	case "equals":
+		return strings.EqualFold(value, r.When.Value)
+	case "matches":
+		return r.whenRegex != nil && r.whenRegex.MatchString(value)
+	}
+	return false
+}
+
+var conditionOperators = map[string]bool{"present": true, "absent": true, "equals": true, "matches": true}
+
+var compareOperators = map[string]func(int) bool{
+	"eq":  func(c int) bool { return c == 0 },
+	"ne":  func(c int) bool { return c != 0 },
+	"lt":  func(c int) bool { return c < 0 },
+	"lte": func(c int) bool { return c <= 0 },
+	"gt":  func(c int) bool { return c > 0 },
+	"gte": func(c int) bool { return c >= 0 },
+}
+
+// compareValues compares dates as dates, numbers as numbers and everything else as strings
+func compareValues(a, b string) int {
+	if da, ok := parser.ParseDate(a); ok {
+		if db, ok := parser.ParseDate(b); ok {
+			return da.Compare(db)
+		}
+	}
+	if na, err := strconv.ParseFloat(a, 64); err == nil {
+		if nb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Города федерального значения являются регионами сами по себе
+var federalCities = map[string]bool{"москва": true, "санкт-петербург": true, "севастополь": true}
+
+// fields are the names FieldValue knows
+var fields = map[string]bool{
+	"fio": true, "surname": true, "first_name": true, "patronymic": true, "gender": true,
+	"phone": true, "snils": true, "inn": true, "passport": true, "birth_date": true, "address": true,
+	"age": true, "region": true, "city": true, "postal_code": true,
+}
+
+// FieldValue returns the value of a person field, including the derived fields
+// age, region, city and postal_code
+func FieldValue(person models.Person, field string) string {
+	switch field {
+	case "fio":
+		return strings.TrimSpace(person.Fio)
+	case "surname":
+		return person.Surname
+	case "first_name":
+		return person.FirstName
+	case "patronymic":
+		return person.Patronymic
+	case "gender":
+		return person.Gender
+	case "phone":
+		return strings.TrimSpace(person.Phone)
+	case "snils":
+		return strings.TrimSpace(person.Snils)
+	case "inn":
+		return strings.TrimSpace(person.Inn)
+	case "passport":
+		return strings.TrimSpace(person.Passport)
+	case "birth_date":
+		return strings.TrimSpace(person.BirthDate)
+	case "address":
+		return strings.TrimSpace(person.Address)
+	case "age":
+		birth, ok := parser.ParseDate(person.BirthDate)
+		if !ok {
+			return ""
+		}
+		return strconv.Itoa(age(birth, time.Now()))
+	}
+
+	address := parser.ParseAddress(person.Address)
+	if person.AddressParts != nil {
+		address = *person.AddressParts
+	}
+	switch field {
+	case "region":
+		if address.Region == "" && federalCities[strings.ToLower(address.Settlement)] {
+			return address.Settlement
+		}
+		return address.Region
+	case "city":
+		return address.Settlement
+	case "postal_code":
+		return address.PostalCode
+	}
+	return ""
+}
+
+func age(birth, now time.Time) int {
+	years := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		years--
+	}
+	return years
+}
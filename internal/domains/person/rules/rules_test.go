@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"service/internal/domains/person/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestEngineEvaluate(t *testing.T) {
+	engine := NewEngine()
+	err := engine.Load([]Rule{
+		{Name: "phone_required", Type: TypeRequired, Field: "phone", Severity: SeverityReject},
+		{Name: "adult", Type: TypeRange, Field: "age", Min: float(14), Severity: SeverityQuarantine},
+		{Name: "moscow_only", Type: TypeEnum, Field: "region", Values: []string{"Москва", "Московская область"}, Severity: SeverityWarn},
+		{Name: "snils_digits", Type: TypeRegex, Field: "snils", Pattern: `^\d{3}-\d{3}-\d{3} \d{2}$`, Severity: SeverityWarn},
+		{Name: "inn_if_no_snils", Type: TypeRequired, Field: "inn", When: &Condition{Field: "snils", Operator: "absent"}, Severity: SeverityReject},
+	})
+	assert.NoError(t, err)
+
+	valid := models.Person{
+		Phone:     "+79991234567",
+		Snils:     "123-456-789 01",
+		BirthDate: "1990-01-01",
+		Address:   "г. Москва, ул. Ленина, д. 1",
+	}
+	assert.Empty(t, engine.Evaluate(valid))
+
+	invalid := models.Person{BirthDate: "01.01.2020", Address: "Тверская обл., г. Тверь, ул. Ленина, д. 1"}
+	violations := engine.Evaluate(invalid)
+
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	assert.Equal(t, []string{"phone_required", "adult", "moscow_only", "inn_if_no_snils"}, names)
+	assert.Equal(t, SeverityReject, Worst(violations))
+}
+
+func TestLoadKeepsRulesOnError(t *testing.T) {
+	engine := NewEngine()
+	assert.NoError(t, engine.Load([]Rule{{Name: "phone", Type: TypeRequired, Field: "phone", Severity: SeverityWarn}}))
+
+	err := engine.Load([]Rule{{Name: "bad", Type: TypeRegex, Field: "phone", Pattern: "(", Severity: SeverityWarn}})
+	assert.Error(t, err)
+	assert.Len(t, engine.Rules(), 1)
+}
+
+func TestCompileRejectsUnknownNames(t *testing.T) {
+	cases := []Rule{
+		{Name: "field", Type: TypeRequired, Field: "phon", Severity: SeverityReject},
+		{Name: "other", Type: TypeCompare, Field: "birth_date", Other: "birthday", Operator: "lt", Severity: SeverityWarn},
+		{Name: "when_field", Type: TypeRequired, Field: "inn", When: &Condition{Field: "snisl", Operator: "absent"}, Severity: SeverityWarn},
+		{Name: "when_operator", Type: TypeRequired, Field: "inn", When: &Condition{Field: "snils", Operator: "missing"}, Severity: SeverityWarn},
+	}
+	for _, rule := range cases {
+		t.Run(rule.Name, func(t *testing.T) {
+			assert.Error(t, rule.Compile())
+		})
+	}
+}
@@ -8,32 +8,412 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"unicode"
 
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"service/internal/domains/person/export"
+	"service/internal/domains/person/listing"
+	"service/internal/domains/person/mapping"
+	"service/internal/domains/person/matching"
 	"service/internal/domains/person/models"
+	"service/internal/domains/person/names"
+	"service/internal/domains/person/parser"
+	"service/internal/domains/person/quality"
+	"service/internal/domains/person/query"
+	"service/internal/domains/person/rules"
+	"service/internal/domains/person/stats"
+	"service/internal/infrastructure/config"
+	"service/internal/infrastructure/metrics"
 	"service/internal/infrastructure/utils"
 
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 	"github.com/xuri/excelize/v2"
 	"strings"
+	"time"
 )
 
 type Service struct {
-	repo *Repository
+	repo   *Repository
+	rules  *rules.Engine
+	paging *config.PagingConfig
+	hooks  []BatchHook
+	// qualityStale помечает, что метрики качества нужно пересчитать; буфер 1 схлопывает повторные сигналы
+	qualityStale chan struct{}
 }
 
-func NewService(repo *Repository) *Service {
+// BatchHook is called in the background after an import batch has saved records
+type BatchHook func(ctx context.Context, result models.ImportResult)
+
+// OnBatch registers a hook run after every import batch, e.g. to check saved searches on the new records
+func (s *Service) OnBatch(hook BatchHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func NewService(repo *Repository, engine *rules.Engine, paging *config.PagingConfig) *Service {
+	// Не заданные размеры страницы берутся из listing
+	limits := config.PagingConfig{DefaultLimit: listing.DefaultLimit, MaxLimit: listing.MaxLimit}
+	if paging != nil && paging.MaxLimit > 0 {
+		limits.MaxLimit = paging.MaxLimit
+	}
+	if paging != nil && paging.DefaultLimit > 0 {
+		limits.DefaultLimit = paging.DefaultLimit
+	}
+	limits.DefaultLimit = min(limits.DefaultLimit, limits.MaxLimit)
 	return &Service{
-		repo: repo,
+		repo:         repo,
+		rules:        engine,
+		paging:       &limits,
+		qualityStale: make(chan struct{}, 1),
+	}
+}
+
+const (
+	// qualityBatchLimit is the number of latest import batches in the quality report
+	qualityBatchLimit = 20
+	// maxReportedViolations limits the rows with rule violations returned in the import result
+	maxReportedViolations = 100
+	// maxMatchCandidates limits the records compared with a new one, e.g. sharing a common phone
+	maxMatchCandidates = 200
+	// nameCandidates is how many index candidates per requested hit a name search checks
+	nameCandidates = 10
+	// nameIndexBatch is the number of records given name parts or name search keys per query
+	nameIndexBatch = 1000
+)
+
+// PreparePerson fills the fields derived from the raw values: contacts of multi-value cells,
+// FIO parts, address components and quality
+func PreparePerson(person models.Person) models.Person {
+	person = splitContacts(person)
+	person.QualityScore, person.QualityIssues = quality.Evaluate(person)
+
+	name := parser.ParseFio(person.Fio)
+	person.Surname = name.Surname
+	person.FirstName = name.FirstName
+	person.Patronymic = name.Patronymic
+	person.Gender = string(name.Gender)
+
+	if birth, ok := parser.ParseDate(person.BirthDate); ok {
+		person.BirthDay = birth.Format("2006-01-02")
+	}
+
+	if len(person.Addresses) > 0 {
+		address := person.Addresses[0]
+		person.AddressParts = &address
+	} else {
+		address := parser.ParseAddress(person.Address)
+		person.AddressParts = &address
+	}
+
+	return person
+}
+
+// splitContacts splits cells like "89161234567, 84951234567" into phones, addresses and documents.
+// The flat fields keep the first value as the primary one
+func splitContacts(person models.Person) models.Person {
+	person.Phones = nil
+	for i, raw := range parser.SplitPhones(person.Phone) {
+		value, ok := parser.NormalizePhone(raw)
+		if !ok {
+			value = raw
+		}
+		person.Phones = append(person.Phones, models.Phone{Value: value, Raw: raw, Kind: parser.PhoneKind(value), Primary: i == 0})
+	}
+	if len(person.Phones) > 0 {
+		person.Phone = person.Phones[0].Raw
+	}
+
+	person.Addresses = nil
+	for i, raw := range parser.SplitAddresses(person.Address) {
+		address := parser.ParseAddress(raw)
+		address.Raw = raw
+		address.Primary = i == 0
+		person.Addresses = append(person.Addresses, address)
+	}
+	if len(person.Addresses) > 0 {
+		person.Address = person.Addresses[0].Raw
+	}
+
+	person.Documents = nil
+	for _, doc := range []struct {
+		kind      string
+		value     *string
+		normalize func(string) (string, bool)
+	}{
+		{models.DocumentPassport, &person.Passport, parser.NormalizePassport},
+		{models.DocumentSnils, &person.Snils, parser.NormalizeSnils},
+		{models.DocumentInn, &person.Inn, parser.NormalizeInn},
+	} {
+		for i, raw := range parser.SplitDocuments(*doc.value) {
+			number, ok := doc.normalize(raw)
+			if !ok {
+				number = raw
+			}
+			if i == 0 {
+				*doc.value = raw
+			}
+			person.Documents = append(person.Documents, models.Document{Kind: doc.kind, Number: number, Raw: raw, Primary: i == 0})
+		}
+	}
+	return person
+}
+
+// savePersons opens an import batch, runs every row through the validation rules and stores the accepted ones
+func (s *Service) savePersons(ctx context.Context, persons []models.Person, upload models.Upload) (*models.ImportResult, error) {
+	batch := models.ImportBatch{
+		Id:       uuid.NewString(),
+		SourceId: upload.SourceId,
+		FileName: upload.FileName,
+		Format:   strings.TrimPrefix(strings.ToLower(filepath.Ext(upload.FileName)), "."),
+	}
+	if err := s.repo.StartBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	result, err := s.saveBatch(ctx, persons, batch)
+	status := models.BatchCompleted
+	if err != nil {
+		status = models.BatchFailed
+	}
+	if finishErr := s.repo.FinishBatch(ctx, result, status); finishErr != nil {
+		if err != nil {
+			log.Errorf("failed to finish import batch %s: %v", batch.Id, finishErr)
+			return result, err
+		}
+		return result, finishErr
+	}
+
+	// Записи неудачного пакета тоже сохранены, поэтому хуки вызываются и для него
+	if result.Saved > 0 {
+		for _, hook := range s.hooks {
+			go hook(context.WithoutCancel(ctx), *result)
+		}
+	}
+	return result, err
+}
+
+func (s *Service) saveBatch(ctx context.Context, persons []models.Person, batch models.ImportBatch) (*models.ImportResult, error) {
+	result := &models.ImportResult{BatchId: batch.Id, Rows: len(persons)}
+	touched := make(map[int]bool)
+	var absorbed []int
+	// Кэш сбрасывается один раз за пакет, в том числе после ошибки: часть записей уже сохранена
+	defer func() {
+		stale := absorbed
+		for masterId := range touched {
+			stale = append(stale, masterId)
+		}
+		if len(stale) > 0 {
+			s.repo.invalidateMasters(context.WithoutCancel(ctx), stale...)
+		}
+	}()
+
+	for i, person := range persons {
+		person = PreparePerson(person)
+		person.ImportBatchId = result.BatchId
+		person.Provenance.SourceId = batch.SourceId
+		person.Provenance.SourceFile = batch.FileName
+
+		violations := s.rules.Evaluate(person)
+		if len(violations) > 0 && len(result.Violations) < maxReportedViolations {
+			result.Violations = append(result.Violations, models.RowViolations{Row: i + 1, Violations: violations})
+		}
+
+		switch rules.Worst(violations) {
+		case rules.SeverityReject:
+			result.Rejected++
+			continue
+		case rules.SeverityQuarantine:
+			if err := s.repo.SaveQuarantined(ctx, result.BatchId, i+1, person, violations); err != nil {
+				return result, err
+			}
+			result.Quarantined++
+			continue
+		case rules.SeverityWarn:
+			result.Warnings++
+			for _, v := range violations {
+				person.QualityIssues = append(person.QualityIssues, models.QualityIssue{Field: v.Field, Code: "rule:" + v.Rule})
+			}
+		}
+
+		keys := matching.Keys(person)
+		id, err := s.repo.SavePerson(ctx, person, keys)
+		if err != nil {
+			return result, fmt.Errorf("failed to save person: %w", err)
+		}
+		// Такая запись уже есть: ON CONFLICT DO NOTHING ничего не вставил
+		if id == 0 {
+			result.Duplicates++
+			continue
+		}
+		result.Saved++
+
+		person.Id = id
+		masterId, joined, merged, err := s.resolve(ctx, person, keys)
+		if err != nil {
+			return result, err
+		}
+		touched[masterId] = true
+		// Поглощенные мастер-записи удалены: пересчитывать нечего, но их члены закэшированы под ними
+		for _, id := range joined {
+			delete(touched, id)
+		}
+		absorbed = append(absorbed, joined...)
+		if merged {
+			result.Merged++
+		}
+	}
+
+	for masterId := range touched {
+		if err := s.repo.RefreshGolden(ctx, masterId); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// resolve finds the masters of the records matching the person and attaches the person to them.
+// Uncertain matches are queued for review; masters a reviewer kept apart are not joined.
+// Returns the master, the masters absorbed into it and whether the person joined an existing master
+func (s *Service) resolve(ctx context.Context, person models.Person, keys []matching.Key) (int, []int, bool, error) {
+	candidates, err := s.repo.MatchCandidates(ctx, person.Id, keys, maxMatchCandidates)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	type scored struct {
+		masterId int
+		score    float64
+	}
+	best := make(map[int]float64)
+	var uncertain []int
+	matches := make([]matching.Match, len(candidates))
+	for i, candidate := range candidates {
+		if candidate.MasterId == 0 {
+			continue
+		}
+		matches[i] = matching.Compare(person, candidate)
+		switch score := matches[i].Score; {
+		case score >= matching.MatchThreshold:
+			if score > best[candidate.MasterId] {
+				best[candidate.MasterId] = score
+			}
+		case score >= matching.ReviewThreshold:
+			uncertain = append(uncertain, i)
+		}
+	}
+	// Запись и так присоединяется к мастерам из best, сравнивать ее с другими их записями вручную незачем
+	for _, i := range uncertain {
+		if _, ok := best[candidates[i].MasterId]; ok {
+			continue
+		}
+		if err := s.repo.ProposeReview(ctx, person.Id, candidates[i].Id, matches[i]); err != nil {
+			return 0, nil, false, err
+		}
+	}
+
+	var matched []scored
+	for masterId, score := range best {
+		matched = append(matched, scored{masterId: masterId, score: score})
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score > matched[j].score
+		}
+		return matched[i].masterId < matched[j].masterId
+	})
+
+	masterIds := make([]int, 0, len(matched))
+	for _, m := range matched {
+		masterIds = append(masterIds, m.masterId)
+	}
+	if len(masterIds) > 1 {
+		rejected, err := s.repo.RejectedMasterPairs(ctx, masterIds)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		masterIds = compatibleMasters(masterIds, rejected)
+	}
+
+	masterId, absorbed, err := s.repo.AttachToMaster(ctx, person.Id, masterIds)
+	if err != nil {
+		return 0, nil, false, err
 	}
+	return masterId, absorbed, len(masterIds) > 0, nil
 }
 
-func getValueFromRecord(record []string, columnIndexes map[string]int, field string) string {
-	if index, ok := columnIndexes[field]; ok && index < len(record) {
-		return record[index]
+// compatibleMasters keeps the masters, best first, that have no rejected pair with the kept ones
+func compatibleMasters(masterIds []int, rejected [][2]int) []int {
+	conflicts := make(map[[2]int]bool)
+	for _, pair := range rejected {
+		conflicts[pair] = true
+		conflicts[[2]int{pair[1], pair[0]}] = true
+	}
+
+	var kept []int
+	for _, id := range masterIds {
+		ok := true
+		for _, k := range kept {
+			if conflicts[[2]int{id, k}] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+func personFromRecord(record []string, m mapping.Mapping) models.Person {
+	return models.Person{
+		Fio:       m.Value(record, mapping.FieldFio),
+		Phone:     m.Value(record, mapping.FieldPhone),
+		Snils:     m.Value(record, mapping.FieldSnils),
+		Inn:       m.Value(record, mapping.FieldInn),
+		Passport:  m.Value(record, mapping.FieldPassport),
+		BirthDate: m.Value(record, mapping.FieldBirth),
+		Address:   m.Value(record, mapping.FieldAddress),
+
+		Attributes: m.AttributeValues(record),
+	}
+}
+
+// personsFromRows maps table rows to persons. Columns are matched by header keywords
+// and by profiling their values, so files without a header row are supported as well.
+// Split layouts (surname/name/patronymic, passport series/number, address parts) are assembled
+func personsFromRows(rows [][]string) ([]models.Person, mapping.Mapping) {
+	m := mapping.Detect(rows)
+	if m.HasHeader {
+		rows = rows[1:]
+	}
+
+	// Номера строк считаются от начала файла, включая заголовок
+	first := 1
+	if m.HasHeader {
+		first = 2
+	}
+
+	persons := make([]models.Person, 0, len(rows))
+	for i, record := range rows {
+		person := personFromRecord(record, m)
+		setRawRow(&person, first+i, record)
+		persons = append(persons, person)
+	}
+	return persons, m
+}
+
+// setRawRow keeps the original row number and cells of a record
+func setRawRow(person *models.Person, row int, record []string) {
+	person.Provenance.SourceRow = row
+	if raw, err := json.Marshal(record); err == nil {
+		person.Provenance.RawRow = raw
 	}
-	return ""
 }
 
 // detectDelimiter определяет разделитель в CSV-файле
@@ -53,21 +433,22 @@ func detectDelimiter(firstLine string) rune {
 	return detectedDelimiter
 }
 
-func (s *Service) ParseAndSaveCSV(ctx context.Context, file io.Reader) error {
+// readCSVRows reads all rows of a CSV file, including the header, detecting the delimiter
+func readCSVRows(file io.Reader) ([][]string, error) {
 	// Use bufio.Reader to read the file
 	bufReader := bufio.NewReader(file)
 
 	// Peek the first 4096 bytes without advancing the reader
 	peekBytes, err := bufReader.Peek(4096)
 	if err != nil && err != io.EOF {
-		return fmt.Errorf("failed to peek into the CSV file: %w", err)
+		return nil, fmt.Errorf("failed to peek into the CSV file: %w", err)
 	}
 
 	// Convert peeked bytes to string and split by newline to get the first line
 	peekStr := string(peekBytes)
 	lines := strings.SplitN(peekStr, "\n", 2)
 	if len(lines) == 0 {
-		return fmt.Errorf("empty CSV file")
+		return nil, fmt.Errorf("empty CSV file")
 	}
 	firstLine := lines[0]
 
@@ -80,69 +461,27 @@ func (s *Service) ParseAndSaveCSV(ctx context.Context, file io.Reader) error {
 	reader.Comma = delimiter
 	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
 
-	// Читаем заголовки
-	headers, err := reader.Read()
+	records, err := reader.ReadAll()
 	if err != nil {
-		return fmt.Errorf("failed to read headers: %w", err)
-	}
-
-	// Создаем мапу для хранения индексов столбцов
-	columnIndexes := make(map[string]int)
-
-	// Ключевые слова для поиска столбцов
-	keywords := map[string][]string{
-		"ID":       {"номер заявки", "номер заявления", "идентификатор", "id", "application number", "identifier"},
-		"Fio":      {"фамилия имя отчество", "фамилия имя", "имя", "fio", "full name", "name"},
-		"Phone":    {"телефон", "номер телефона", "phone", "phone number"},
-		"Snils":    {"снилс", "страховой номер индивидуального лицевого счёта", "индивидуальный лицевой счет", "страховой номер", "snils", "insurance number"},
-		"Inn":      {"инн", "идентификационный номер налогоплательщика", "inn", "taxpayer identification number"},
-		"Passport": {"паспорт", "документ удостоверяющий личность", "passport", "identity document"},
-		"Birth":    {"дата рождения", "день рождения", "birth date", "birthday"},
-		"Address":  {"адрес", "address"},
+		return nil, fmt.Errorf("failed to read records: %w", err)
 	}
-
-	// Ищем индексы столбцов по ключевым словам
-	for i, header := range headers {
-		header = strings.ToLower(header) // Приводим к нижнему регистру для унификации
-		for field, keys := range keywords {
-			for _, key := range keys {
-				if strings.Contains(header, key) {
-					columnIndexes[field] = i
-					break
-				}
-			}
-		}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty CSV file")
 	}
+	return records, nil
+}
 
-	// Читаем остальные строки
-	records, err := reader.ReadAll()
+func readCSV(file io.Reader) ([]models.Person, error) {
+	records, err := readCSVRows(file)
 	if err != nil {
-		return fmt.Errorf("failed to read records: %w", err)
-	}
-
-	// Обрабатываем каждую запись
-	for _, record := range records {
-		fmt.Println(record)
-		person := models.Person{
-			Fio:       getValueFromRecord(record, columnIndexes, "Fio"),
-			Phone:     getValueFromRecord(record, columnIndexes, "Phone"),
-			Snils:     getValueFromRecord(record, columnIndexes, "Snils"),
-			Inn:       getValueFromRecord(record, columnIndexes, "Inn"),
-			Passport:  getValueFromRecord(record, columnIndexes, "Passport"),
-			BirthDate: getValueFromRecord(record, columnIndexes, "Birth"),
-			Address:   getValueFromRecord(record, columnIndexes, "Address"),
-		}
-
-		// Сохраняем запись в базу данных
-		if err := s.repo.SavePerson(ctx, person); err != nil {
-			return fmt.Errorf("failed to save person: %w", err)
-		}
+		return nil, err
 	}
 
-	return nil
+	persons, _ := personsFromRows(records)
+	return persons, nil
 }
 
-func (s *Service) ParseAndSaveCSVWithAi(ctx context.Context, file io.Reader) error {
+func readCSVWithAi(file io.Reader) ([]models.Person, error) {
 	reader := csv.NewReader(file)
 	reader.Comma = ','
 	reader.FieldsPerRecord = -1 // Разрешаем разное количество полей в строках
@@ -150,13 +489,13 @@ func (s *Service) ParseAndSaveCSVWithAi(ctx context.Context, file io.Reader) err
 	// Читаем заголовки
 	headers, err := reader.Read()
 	if err != nil {
-		return fmt.Errorf("failed to read headers: %w", err)
+		return nil, fmt.Errorf("failed to read headers: %w", err)
 	}
 
 	// Используем функцию CheckFields для определения соответствий
 	result, err := utils.CheckFields(headers)
 	if err != nil {
-		return fmt.Errorf("failed to check fields: %w", err)
+		return nil, fmt.Errorf("failed to check fields: %w", err)
 	}
 
 	// Создаем мапу для хранения индексов столбцов
@@ -167,7 +506,12 @@ func (s *Service) ParseAndSaveCSVWithAi(ctx context.Context, file io.Reader) err
 		header = strings.ToLower(header) // Приводим к нижнему регистру для унификации
 		for dbField, csvField := range result {
 			if strings.Contains(header, strings.ToLower(csvField)) {
-				columnIndexes[dbField] = i
+				// Модель отвечает в нижнем регистре ("fio"), поля сопоставления - "Fio"
+				for _, field := range mapping.Fields {
+					if strings.EqualFold(field, dbField) {
+						columnIndexes[field] = i
+					}
+				}
 				break
 			}
 		}
@@ -176,55 +520,54 @@ func (s *Service) ParseAndSaveCSVWithAi(ctx context.Context, file io.Reader) err
 	// Читаем остальные строки
 	records, err := reader.ReadAll()
 	if err != nil {
-		return fmt.Errorf("failed to read records: %w", err)
+		return nil, fmt.Errorf("failed to read records: %w", err)
 	}
 
-	// Обрабатываем каждую запись
-	for _, record := range records {
-		person := models.Person{
-			Fio:       getValueFromRecord(record, columnIndexes, "Fio"),
-			Phone:     getValueFromRecord(record, columnIndexes, "Phone"),
-			Snils:     getValueFromRecord(record, columnIndexes, "Snils"),
-			Inn:       getValueFromRecord(record, columnIndexes, "Inn"),
-			Passport:  getValueFromRecord(record, columnIndexes, "Passport"),
-			BirthDate: getValueFromRecord(record, columnIndexes, "Birth"),
-			Address:   getValueFromRecord(record, columnIndexes, "Address"),
-		}
+	m := mapping.Mapping{Fields: columnIndexes}
+	m.AddAttributes(headers, len(headers))
 
-		// Сохраняем запись в базу данных
-		if err := s.repo.SavePerson(ctx, person); err != nil {
-			return fmt.Errorf("failed to save person: %w", err)
-		}
+	var persons []models.Person
+	// Обрабатываем каждую запись
+	for i, record := range records {
+		person := personFromRecord(record, m)
+		setRawRow(&person, i+2, record)
+		persons = append(persons, person)
 	}
 
-	return nil
+	return persons, nil
 }
 
-func (s *Service) ParseAndSaveJSON(ctx context.Context, file io.Reader) error {
-	var persons []models.Person
-	if err := json.NewDecoder(file).Decode(&persons); err != nil {
-		return fmt.Errorf("failed to decode JSON: %w", err)
+func readJSON(file io.Reader) ([]models.Person, error) {
+	var objects []json.RawMessage
+	if err := json.NewDecoder(file).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
 	}
 
-	for _, person := range persons {
-		if err := s.repo.SavePerson(ctx, person); err != nil {
-			return fmt.Errorf("failed to save person: %w", err)
+	persons := make([]models.Person, 0, len(objects))
+	for i, object := range objects {
+		var person models.Person
+		if err := json.Unmarshal(object, &person); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON object %d: %w", i+1, err)
 		}
+		// Происхождение определяет сервис, а не загружаемый файл
+		person.Provenance = models.Provenance{SourceRow: i + 1, RawRow: object}
+		persons = append(persons, person)
 	}
-	return nil
+	return persons, nil
 }
 
-func (s *Service) ParseAndSaveXLSX(ctx context.Context, file io.Reader) error {
+// readXLSXRows reads all rows of the first sheet, including the header
+func readXLSXRows(file io.Reader) ([][]string, error) {
 	// Read file data into a byte slice
 	data, err := ioutil.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("failed to read XLSX file: %w", err)
+		return nil, fmt.Errorf("failed to read XLSX file: %w", err)
 	}
 
 	// Save data to a temporary file because excelize requires a file path
 	tempFile, err := ioutil.TempFile("", "*.xlsx")
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer tempFile.Close()
 	defer func() {
@@ -232,13 +575,13 @@ func (s *Service) ParseAndSaveXLSX(ctx context.Context, file io.Reader) error {
 	}()
 
 	if _, err := tempFile.Write(data); err != nil {
-		return fmt.Errorf("failed to write to temp file: %w", err)
+		return nil, fmt.Errorf("failed to write to temp file: %w", err)
 	}
 
 	// Open the Excel file
 	f, err := excelize.OpenFile(tempFile.Name())
 	if err != nil {
-		return fmt.Errorf("failed to open XLSX file: %w", err)
+		return nil, fmt.Errorf("failed to open XLSX file: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -249,67 +592,94 @@ func (s *Service) ParseAndSaveXLSX(ctx context.Context, file io.Reader) error {
 	// Get the first sheet
 	sheetName := f.GetSheetName(1)
 	if sheetName == "" {
-		return errors.New("no sheets found in Excel file")
+		return nil, errors.New("no sheets found in Excel file")
 	}
 
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return fmt.Errorf("failed to get rows from sheet: %w", err)
+		return nil, fmt.Errorf("failed to get rows from sheet: %w", err)
 	}
 
 	if len(rows) == 0 {
-		return errors.New("Excel file is empty")
+		return nil, errors.New("Excel file is empty")
 	}
 
-	// Read headers
-	headers := rows[0]
+	return rows, nil
+}
 
-	// Create a map for storing column indices
-	columnIndexes := make(map[string]int)
+func readXLSX(file io.Reader) ([]models.Person, error) {
+	rows, err := readXLSXRows(file)
+	if err != nil {
+		return nil, err
+	}
+
+	persons, _ := personsFromRows(rows)
+	return persons, nil
+}
 
-	// Keywords to search for columns
-	keywords := map[string][]string{
-		"Fio":       {"фамилия имя отчество", "фамилия имя", "имя", "fio", "full name", "name", "фио"},
-		"Phone":     {"телефон", "номер телефона", "phone", "phone number"},
-		"Snils":     {"снилс", "страховой номер индивидуального лицевого счёта", "страховой номер", "snils"},
-		"Inn":       {"инн", "идентификационный номер налогоплательщика", "inn"},
-		"Passport":  {"паспорт", "документ удостоверяющий личность", "passport", "identity document"},
-		"BirthDate": {"дата рождения", "день рождения", "birth date", "birthday"},
-		"Address":   {"адрес", "address"},
+func (s *Service) ParseAndSaveCSV(ctx context.Context, file io.Reader, upload models.Upload) (*models.ImportResult, error) {
+	persons, err := readCSV(file)
+	if err != nil {
+		return nil, err
 	}
+	return s.savePersons(ctx, persons, upload)
+}
 
-	// Search for column indices by keywords
-	for i, header := range headers {
-		header = strings.ToLower(strings.TrimSpace(header)) // Normalize the header
-		for field, keys := range keywords {
-			for _, key := range keys {
-				if strings.Contains(header, key) {
-					columnIndexes[field] = i
-					break
-				}
-			}
-		}
+func (s *Service) ParseAndSaveCSVWithAi(ctx context.Context, file io.Reader, upload models.Upload) (*models.ImportResult, error) {
+	persons, err := readCSVWithAi(file)
+	if err != nil {
+		return nil, err
 	}
+	return s.savePersons(ctx, persons, upload)
+}
 
-	// Process each row
-	for _, row := range rows[1:] {
-		person := models.Person{
-			Fio:       getValueFromRecord(row, columnIndexes, "Fio"),
-			Phone:     getValueFromRecord(row, columnIndexes, "Phone"),
-			Snils:     getValueFromRecord(row, columnIndexes, "Snils"),
-			Inn:       getValueFromRecord(row, columnIndexes, "Inn"),
-			Passport:  getValueFromRecord(row, columnIndexes, "Passport"),
-			BirthDate: getValueFromRecord(row, columnIndexes, "Birth"),
-			Address:   getValueFromRecord(row, columnIndexes, "Address"),
-		}
+func (s *Service) ParseAndSaveJSON(ctx context.Context, file io.Reader, upload models.Upload) (*models.ImportResult, error) {
+	persons, err := readJSON(file)
+	if err != nil {
+		return nil, err
+	}
+	return s.savePersons(ctx, persons, upload)
+}
 
-		// Save the person to the database
-		if err := s.repo.SavePerson(ctx, person); err != nil {
-			return fmt.Errorf("failed to save person: %w", err)
-		}
+func (s *Service) ParseAndSaveXLSX(ctx context.Context, file io.Reader, upload models.Upload) (*models.ImportResult, error) {
+	persons, err := readXLSX(file)
+	if err != nil {
+		return nil, err
 	}
+	return s.savePersons(ctx, persons, upload)
+}
 
-	return nil
+// ReadFile parses the rows of a CSV, JSON or XLSX file without saving them
+func ReadFile(file io.Reader, filename string) ([]models.Person, error) {
+	name := strings.ToLower(filename)
+	if strings.HasSuffix(name, ".csv") {
+		return readCSV(file)
+	} else if strings.HasSuffix(name, ".json") {
+		return readJSON(file)
+	} else if strings.HasSuffix(name, ".xlsx") {
+		return readXLSX(file)
+	}
+	return nil, fmt.Errorf("unsupported file type")
+}
+
+// ProposeMapping detects how the columns of a CSV or XLSX file map to person fields
+func (s *Service) ProposeMapping(file io.Reader, filename string) (mapping.Mapping, error) {
+	var rows [][]string
+	var err error
+
+	name := strings.ToLower(filename)
+	if strings.HasSuffix(name, ".csv") {
+		rows, err = readCSVRows(file)
+	} else if strings.HasSuffix(name, ".xlsx") {
+		rows, err = readXLSXRows(file)
+	} else {
+		err = fmt.Errorf("unsupported file type")
+	}
+	if err != nil {
+		return mapping.Mapping{}, err
+	}
+
+	return mapping.Detect(rows), nil
 }
 
 func (s *Service) ParseAndSaveSQL(ctx context.Context, file io.Reader) error {
@@ -324,29 +694,593 @@ func (s *Service) ParseAndSaveSQL(ctx context.Context, file io.Reader) error {
 	if err := s.repo.ExecuteSQL(ctx, sqlStatements); err != nil {
 		return fmt.Errorf("failed to execute SQL statements: %w", err)
 	}
+	s.repo.Invalidate(ctx, tagSearch, tagPersons)
 
 	return nil
 }
 
-func (s *Service) ProcessFile(ctx context.Context, file multipart.File, filename string) error {
-	// Determine file type based on the extension
-	if strings.HasSuffix(strings.ToLower(filename), ".csv") {
-		return s.ParseAndSaveCSV(ctx, file)
-	} else if strings.HasSuffix(strings.ToLower(filename), ".json") {
-		return s.ParseAndSaveJSON(ctx, file)
-	} else if strings.HasSuffix(strings.ToLower(filename), ".xlsx") {
-		return s.ParseAndSaveXLSX(ctx, file)
-	} else if strings.HasSuffix(strings.ToLower(filename), ".sql") {
-		return s.ParseAndSaveSQL(ctx, file)
-	} else {
-		return fmt.Errorf("unsupported file type")
+// ErrInvalidSource is returned for a source identifier that is empty after normalization
+var ErrInvalidSource = errors.New("invalid source identifier")
+
+// resolveUpload normalizes the source identifier of an upload or infers it from the file name
+func resolveUpload(upload models.Upload) (models.Upload, error) {
+	if upload.SourceId == "" {
+		upload.SourceId = InferSource(upload.FileName)
 	}
+	upload.SourceId = mapping.AttributeKey(upload.SourceId)
+	if upload.SourceId == "" {
+		return upload, ErrInvalidSource
+	}
+	return upload, nil
 }
 
-func (s *Service) FindPerson(ctx context.Context, field, value string) ([]models.Person, error) {
-	return s.repo.FindPerson(ctx, field, value)
+// InferSource derives a source identifier from the file name: the words before the first one
+// with digits, so "partner_a_2024-05-01.csv" and "partner_a_2024-06-01.csv" share "partner_a"
+func InferSource(filename string) string {
+	base := filepath.Base(filename)
+	key := mapping.AttributeKey(strings.TrimSuffix(base, filepath.Ext(base)))
+
+	var words []string
+	for _, word := range strings.Split(key, "_") {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			break
+		}
+		words = append(words, word)
+	}
+	if len(words) > 0 {
+		return strings.Join(words, "_")
+	}
+	if key != "" {
+		return key
+	}
+	return "unknown"
 }
 
-func (s *Service) ListPersons(ctx context.Context) ([]models.Person, error) {
-	return s.repo.GetAllPersons(ctx)
+func (s *Service) ProcessFile(ctx context.Context, file multipart.File, upload models.Upload) (*models.ImportResult, error) {
+	// SQL is executed as is, without row processing
+	if strings.HasSuffix(strings.ToLower(upload.FileName), ".sql") {
+		return &models.ImportResult{}, s.ParseAndSaveSQL(ctx, file)
+	}
+
+	upload, err := resolveUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	persons, err := ReadFile(file, upload.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.savePersons(ctx, persons, upload)
+	s.refreshQualityMetrics()
+	return result, err
+}
+
+func (s *Service) UploadCSVWithAi(ctx context.Context, file io.Reader, upload models.Upload) (*models.ImportResult, error) {
+	upload, err := resolveUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.ParseAndSaveCSVWithAi(ctx, file, upload)
+	s.refreshQualityMetrics()
+	return result, err
+}
+
+func (s *Service) FindPerson(ctx context.Context, field, value string, asOf *time.Time) ([]models.Person, error) {
+	if key, ok := strings.CutPrefix(field, AttributePrefix); ok {
+		field = AttributePrefix + mapping.AttributeKey(key)
+	}
+	return s.repo.FindPerson(ctx, field, value, asOf)
+}
+
+// Export writes the persons matching the filter and the optional structured query to w in id order.
+// The options must be validated with export.Options.Validate. Returns the number of rows written
+func (s *Service) Export(ctx context.Context, w io.Writer, filter listing.Filter, node *query.Node, opts export.Options) (int, error) {
+	var conditions []string
+	var args []interface{}
+	if node != nil {
+		compiled, err := query.Compile(*node, nil)
+		if err != nil {
+			return 0, err
+		}
+		conditions = append(conditions, compiled.Where)
+		args = compiled.Args
+	}
+	// Параметры фильтра нумеруются после параметров запроса
+	filterConditions, err := filter.Conditions(&args)
+	if err != nil {
+		return 0, err
+	}
+	conditions = append(conditions, filterConditions...)
+
+	writer, err := export.NewWriter(w, opts)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := s.repo.ExportPersons(ctx, strings.Join(conditions, " AND "), args, writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Errorf("Export to %s failed after %d rows: %v", opts.Format, rows, err)
+		return rows, err
+	}
+	log.Infof("Exported %d persons to %s", rows, opts.Format)
+	return rows, nil
+}
+
+// ErrInvalidIdentifier is returned for an unknown identifier type or a value that is not an identifier of the type
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// FindByIdentifier finds the records with a phone, SNILS, INN or passport written in any format
+func (s *Service) FindByIdentifier(ctx context.Context, kind, value string, asOf *time.Time) (*models.IdentifierLookup, error) {
+	if !slices.Contains(parser.IdentifierTypes(), kind) {
+		return nil, fmt.Errorf("%w: type must be one of %s", ErrInvalidIdentifier, strings.Join(parser.IdentifierTypes(), ", "))
+	}
+	canonical, ok := parser.NormalizeIdentifier(kind, value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a %s", ErrInvalidIdentifier, value, kind)
+	}
+
+	persons, err := s.repo.FindByIdentifier(ctx, kind, canonical, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []models.Person{}
+	}
+	return &models.IdentifierLookup{Type: kind, Value: canonical, Persons: persons}, nil
+}
+
+// TextSearch finds persons with an indexed search mode (query.ModeFullText, ModeTrigram, ModePrefix, ModeName).
+// With explain the search only runs under EXPLAIN ANALYZE and the diagnostics are returned.
+// The legacy substring search is explained for an empty mode
+func (s *Service) TextSearch(ctx context.Context, req models.TextSearch, asOf *time.Time) ([]models.SearchHit, *models.Explain, error) {
+	if req.Limit == 0 {
+		req.Limit = s.paging.DefaultLimit
+	}
+	if req.Limit < 0 || req.Limit > s.paging.MaxLimit {
+		return nil, nil, fmt.Errorf("%w: limit must be between 1 and %d", query.ErrInvalid, s.paging.MaxLimit)
+	}
+
+	if req.Mode == "" {
+		if !req.Explain {
+			return nil, nil, fmt.Errorf("%w: mode is required", query.ErrInvalid)
+		}
+		field := req.Field
+		if key, ok := strings.CutPrefix(field, AttributePrefix); ok {
+			field = AttributePrefix + mapping.AttributeKey(key)
+		}
+		explain, err := s.repo.ExplainFind(ctx, field, req.Value, asOf)
+		return nil, explain, err
+	}
+
+	text, err := query.CompileText(req.Mode, req.Field, req.Value, req.Threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Mode == query.ModeName {
+		return s.nameSearch(ctx, req, text, asOf)
+	}
+	hits, explain, err := s.repo.TextSearch(ctx, text, asOf, req.Limit, req.Explain)
+	if hits == nil && !req.Explain && err == nil {
+		hits = []models.SearchHit{}
+	}
+	return hits, explain, err
+}
+
+// nameSearch checks the candidates selected by the name keys with names.Match and keeps those where
+// every query word matches a word of the name, the best matches first
+func (s *Service) nameSearch(ctx context.Context, req models.TextSearch, text query.Text, asOf *time.Time) ([]models.SearchHit, *models.Explain, error) {
+	if req.MaxDistance > names.MaxDistanceLimit {
+		return nil, nil, fmt.Errorf("%w: max_distance must not exceed %d", query.ErrInvalid, names.MaxDistanceLimit)
+	}
+
+	candidates, explain, err := s.repo.TextSearch(ctx, text, asOf, req.Limit*nameCandidates, req.Explain)
+	if err != nil || req.Explain {
+		return nil, explain, err
+	}
+
+	hits := []models.SearchHit{}
+	for _, candidate := range candidates {
+		fields := []names.Field{
+			{Name: "surname", Value: candidate.Surname},
+			{Name: "first_name", Value: candidate.FirstName},
+			{Name: "patronymic", Value: candidate.Patronymic},
+		}
+		if candidate.Surname == "" && candidate.FirstName == "" && candidate.Patronymic == "" {
+			fields = []names.Field{{Name: "fio", Value: candidate.Fio}}
+		}
+		result, ok := names.Match(req.Value, fields, req.MaxDistance)
+		if !ok {
+			continue
+		}
+		candidate.Score = result.Score
+		candidate.Match = &result
+		hits = append(hits, candidate)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > req.Limit {
+		hits = hits[:req.Limit]
+	}
+	return hits, nil, nil
+}
+
+// ParseNames splits the FIO of the records imported before the name parts existed, in batches.
+// Run it before IndexNames: the search keys of these records are rebuilt from the parts
+func (s *Service) ParseNames(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.repo.ParseNames(ctx, nameIndexBatch)
+		total += n
+		if err != nil || n < nameIndexBatch {
+			if total > 0 {
+				s.repo.Invalidate(ctx, tagSearch, tagPersons)
+			}
+			return total, err
+		}
+	}
+}
+
+// IndexNames fills the name search keys of the records imported before they existed, in batches
+func (s *Service) IndexNames(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.repo.IndexNames(ctx, nameIndexBatch)
+		total += n
+		if err != nil || n < nameIndexBatch {
+			if total > 0 {
+				s.repo.Invalidate(ctx, tagSearch)
+			}
+			return total, err
+		}
+	}
+}
+
+// QualityReport returns fill-rate and validity per field and per import batch
+// and publishes the same numbers as Prometheus gauges
+func (s *Service) QualityReport(ctx context.Context) (models.QualityReport, error) {
+	report, err := s.repo.QualityReport(ctx, qualityBatchLimit)
+	if err != nil {
+		return report, err
+	}
+
+	metrics.ResetQuality()
+	for _, batch := range append([]models.BatchQuality{report.Total}, report.Batches...) {
+		metrics.SetQualityScore(batch.BatchId, batch.AvgScore)
+		for _, field := range batch.Fields {
+			metrics.SetFieldQuality(batch.BatchId, field.Field, field.Filled, field.Valid)
+		}
+	}
+	return report, nil
+}
+
+// Stats counts the persons matching the filter by the requested dimensions. With a minimum group
+// size the smaller groups are left out of the response
+func (s *Service) Stats(ctx context.Context, filter listing.Filter, req stats.Request) (*models.Stats, error) {
+	var args []interface{}
+	columns, err := stats.Compile(&req, &args)
+	if err != nil {
+		return nil, err
+	}
+	conditions, err := filter.Conditions(&args)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.repo.PersonStats(ctx, columns, strings.Join(conditions, " AND "), args)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.Stats{GroupBy: req.GroupBy, MinGroupSize: req.MinGroupSize}
+	result.Groups, result.SuppressedGroups = stats.Suppress(groups, req.MinGroupSize)
+	if result.Groups == nil {
+		result.Groups = []models.StatsGroup{}
+	}
+	return result, nil
+}
+
+// refreshQualityMetrics asks WatchQuality to recompute the quality gauges. It never blocks:
+// changes made while a refresh is pending are covered by that refresh
+func (s *Service) refreshQualityMetrics() {
+	select {
+	case s.qualityStale <- struct{}{}:
+	default:
+	}
+}
+
+// WatchQuality recomputes the quality gauges in the background after imports and rollbacks,
+// so the full-table aggregates never hold up a request. The gauges are computed once on start
+func (s *Service) WatchQuality(ctx context.Context) {
+	s.refreshQualityMetrics()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.qualityStale:
+			if _, err := s.QualityReport(ctx); err != nil && ctx.Err() == nil {
+				log.Errorf("failed to refresh quality metrics: %v", err)
+			}
+		}
+	}
+}
+
+// ListPersons returns one page of persons. A zero limit takes the configured page size,
+// a limit above the configured maximum is rejected. Attribute keys are normalized like column headers
+func (s *Service) ListPersons(ctx context.Context, req listing.Request, count string) (*models.PersonPage, error) {
+	if req.Limit == 0 {
+		req.Limit = s.paging.DefaultLimit
+	}
+	if req.Limit < 0 || req.Limit > s.paging.MaxLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", listing.ErrInvalid, s.paging.MaxLimit)
+	}
+	if count == "" {
+		count = listing.CountEstimated
+	}
+	if count != listing.CountExact && count != listing.CountEstimated && count != listing.CountNone {
+		return nil, fmt.Errorf("%w: unknown count mode %q", listing.ErrInvalid, count)
+	}
+	if len(req.Filter.Attributes) > 0 {
+		filter := make(map[string]string, len(req.Filter.Attributes))
+		for key, value := range req.Filter.Attributes {
+			filter[mapping.AttributeKey(key)] = strings.TrimSpace(value)
+		}
+		req.Filter.Attributes = filter
+	}
+
+	q, err := listing.Build(req)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListPersons(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.PersonPage{Limit: req.Limit, Sort: req.Sort.String()}
+	page.Persons, page.NextCursor, page.PrevCursor = listing.Paginate(rows, req)
+	if page.Persons == nil {
+		page.Persons = []models.Person{}
+	}
+
+	if count == listing.CountNone {
+		page.Total = -1
+		return page, nil
+	}
+	page.Total, page.TotalExact, err = s.repo.CountPersons(ctx, q, count == listing.CountExact)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Search runs a structured query. Pagination and counting follow ListPersons; a zero limit takes the page size
+func (s *Service) Search(ctx context.Context, req query.Request, asOf *time.Time) (*models.SearchPage, error) {
+	if req.Limit == 0 {
+		req.Limit = s.paging.DefaultLimit
+	}
+	if req.Limit < 0 || req.Limit > s.paging.MaxLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", query.ErrInvalid, s.paging.MaxLimit)
+	}
+	if req.Count == "" {
+		req.Count = listing.CountEstimated
+	}
+	if req.Count != listing.CountExact && req.Count != listing.CountEstimated && req.Count != listing.CountNone {
+		return nil, fmt.Errorf("%w: unknown count mode %q", query.ErrInvalid, req.Count)
+	}
+
+	var cursor *listing.Cursor
+	if req.Cursor != "" {
+		decoded, err := listing.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", query.ErrInvalid)
+		}
+		cursor = &decoded
+	}
+
+	compiled, err := query.Compile(req.Query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	page, err := query.BuildPage(compiled, cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := s.repo.SearchPersons(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SearchPage{Hits: hits, Limit: req.Limit}
+	if len(hits) > req.Limit {
+		result.Hits = hits[:req.Limit]
+		last := result.Hits[req.Limit-1]
+		result.NextCursor = query.NextCursor(last.Score, last.Id)
+	}
+	if result.Hits == nil {
+		result.Hits = []models.SearchHit{}
+	}
+
+	if req.Count == listing.CountNone {
+		result.Total = -1
+		return result, nil
+	}
+	result.Total, result.TotalExact, err = s.repo.CountPersons(ctx, listing.Query{Where: compiled.Where, CountArgs: compiled.Args},
+		req.Count == listing.CountExact)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PersonSources returns the master of a person with all source records merged into it
+func (s *Service) PersonSources(ctx context.Context, personId int) (*models.PersonSources, error) {
+	master, err := s.repo.GetMaster(ctx, personId)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := s.repo.MasterMembers(ctx, master.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PersonSources{Master: master, Sources: sources}, nil
+}
+
+// GetPerson returns a source record by id
+func (s *Service) GetPerson(ctx context.Context, id int) (models.Person, error) {
+	return s.repo.GetPerson(ctx, id)
+}
+
+// GetPersons returns the source records with the given ids
+func (s *Service) GetPersons(ctx context.Context, ids []int) ([]models.Person, error) {
+	return s.repo.GetPersons(ctx, ids)
+}
+
+// JoinPersons merges the masters of the given source records into one and rebuilds its golden record
+func (s *Service) JoinPersons(ctx context.Context, persons []models.Person) (int, error) {
+	seen := make(map[int]bool)
+	var masterIds []int
+	for _, p := range persons {
+		if p.MasterId != 0 && !seen[p.MasterId] {
+			seen[p.MasterId] = true
+			masterIds = append(masterIds, p.MasterId)
+		}
+	}
+	if len(masterIds) == 0 {
+		return 0, ErrNotFound
+	}
+
+	masterId, err := s.repo.JoinMasters(ctx, masterIds)
+	if err != nil {
+		return 0, err
+	}
+	err = s.repo.RefreshGolden(ctx, masterId)
+	// Члены поглощенных мастер-записей закэшированы под их прежними мастерами
+	s.repo.invalidateMasters(ctx, masterIds...)
+	return masterId, err
+}
+
+// ErrInvalidMerge is returned for a merge request that cannot be applied
+var ErrInvalidMerge = errors.New("invalid merge request")
+
+// Merge joins the masters of several records into the master of the survivor
+func (s *Service) Merge(ctx context.Context, request models.MergeRequest) (*models.MergeAudit, error) {
+	unique := make(map[int]bool)
+	for _, id := range request.Ids {
+		unique[id] = true
+	}
+	if len(unique) < 2 {
+		return nil, fmt.Errorf("%w: at least two ids are required", ErrInvalidMerge)
+	}
+	if request.Survivor == 0 {
+		request.Survivor = request.Ids[0]
+	}
+	if !unique[request.Survivor] {
+		return nil, fmt.Errorf("%w: survivor %d is not among ids", ErrInvalidMerge, request.Survivor)
+	}
+	for field := range request.Fields {
+		if !slices.Contains(matching.OverridableFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidMerge, field)
+		}
+	}
+
+	audit, err := s.repo.Merge(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateAudit(ctx, audit)
+	log.Infof("Operator %s merged persons %v into master %d", request.Operator, request.Ids, audit.MasterId)
+	return audit, nil
+}
+
+// invalidateAudit drops the cached results of the masters before and after a manual merge or unmerge
+func (s *Service) invalidateAudit(ctx context.Context, audit *models.MergeAudit) {
+	masterIds := []int{audit.MasterId}
+	for _, snapshot := range append(audit.Before, audit.After...) {
+		masterIds = append(masterIds, snapshot.Id)
+	}
+	s.repo.invalidateMasters(ctx, masterIds...)
+}
+
+// Unmerge undoes the latest manual merge of the person's master or detaches the person from it
+func (s *Service) Unmerge(ctx context.Context, personId int, request models.UnmergeRequest) (*models.MergeAudit, error) {
+	audit, err := s.repo.Unmerge(ctx, personId, request)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateAudit(ctx, audit)
+	log.Infof("Operator %s: %s of person %d from master %d", request.Operator, audit.Action, personId, audit.MasterId)
+	return audit, nil
+}
+
+// RollbackBatch removes the records of an import batch, or only reports the impact with dryRun
+func (s *Service) RollbackBatch(ctx context.Context, batchId string, dryRun bool) (*models.RollbackImpact, error) {
+	impact, err := s.repo.RollbackBatch(ctx, batchId, dryRun)
+	if err != nil {
+		return impact, err
+	}
+	if impact.Applied {
+		// Какие мастер-записи пересчитаны, здесь неизвестно - сбрасываем весь кэш
+		s.repo.Invalidate(ctx, tagSearch, tagPersons)
+		log.Infof("Import batch %s rolled back: %d persons deleted", batchId, impact.Persons)
+		s.refreshQualityMetrics()
+	}
+	return impact, nil
+}
+
+// History returns the versioned golden record fields of the person's master. With asOf
+// only the versions valid at that moment are returned, together with the resulting snapshot
+func (s *Service) History(ctx context.Context, personId int, asOf *time.Time) (*models.PersonHistory, error) {
+	master, err := s.repo.GetMaster(ctx, personId)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.History(ctx, master.Id, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.PersonHistory{MasterId: master.Id, Versions: versions}
+	if asOf != nil {
+		history.AsOf = asOf.Format(time.RFC3339)
+		history.Snapshot = make(map[string]string, len(versions))
+		for _, v := range versions {
+			history.Snapshot[v.Field] = v.Value
+		}
+	}
+	return history, nil
+}
+
+// RecomputeGoldens rebuilds the golden records after a survivorship change: the masters
+// with members from the source, or all masters when sourceId is empty. progress is called
+// after every rebuilt master. Returns how many were rebuilt
+func (s *Service) RecomputeGoldens(ctx context.Context, sourceId string, progress func(done, total int)) (int, error) {
+	masterIds, err := s.repo.MastersOfSource(ctx, sourceId)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	defer func() {
+		if done > 0 {
+			s.repo.invalidateMasters(context.WithoutCancel(ctx), masterIds[:done]...)
+		}
+	}()
+
+	progress(0, len(masterIds))
+	for i, id := range masterIds {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := s.repo.RefreshGolden(ctx, id); err != nil {
+			return i, fmt.Errorf("failed to recompute master %d: %w", id, err)
+		}
+		done = i + 1
+		progress(done, len(masterIds))
+	}
+	return len(masterIds), nil
 }
@@ -0,0 +1,172 @@
+package stats
+
+import (
+	"regexp"
+	"strings"
+)
+
+// region is a subject of the Russian Federation with its OKATO code: the first two digits
+// of a passport series are the code of the region where it was issued
+type region struct {
+	okato string
+	name  string
+}
+
+var regions = []region{
+	{"01", "Алтайский край"},
+	{"03", "Краснодарский край"},
+	{"04", "Красноярский край"},
+	{"05", "Приморский край"},
+	{"07", "Ставропольский край"},
+	{"08", "Хабаровский край"},
+	{"10", "Амурская область"},
+	{"11", "Архангельская область"},
+	{"12", "Астраханская область"},
+	{"14", "Белгородская область"},
+	{"15", "Брянская область"},
+	{"17", "Владимирская область"},
+	{"18", "Волгоградская область"},
+	{"19", "Вологодская область"},
+	{"20", "Воронежская область"},
+	{"22", "Нижегородская область"},
+	{"24", "Ивановская область"},
+	{"25", "Иркутская область"},
+	{"26", "Республика Ингушетия"},
+	{"27", "Калининградская область"},
+	{"28", "Тверская область"},
+	{"29", "Калужская область"},
+	{"30", "Камчатский край"},
+	{"32", "Кемеровская область"},
+	{"33", "Кировская область"},
+	{"34", "Костромская область"},
+	{"35", "Республика Крым"},
+	{"36", "Самарская область"},
+	{"37", "Курганская область"},
+	{"38", "Курская область"},
+	{"40", "Санкт-Петербург"},
+	{"41", "Ленинградская область"},
+	{"42", "Липецкая область"},
+	{"44", "Магаданская область"},
+	{"45", "Москва"},
+	{"46", "Московская область"},
+	{"47", "Мурманская область"},
+	{"49", "Новгородская область"},
+	{"50", "Новосибирская область"},
+	{"52", "Омская область"},
+	{"53", "Оренбургская область"},
+	{"54", "Орловская область"},
+	{"56", "Пензенская область"},
+	{"57", "Пермский край"},
+	{"58", "Псковская область"},
+	{"60", "Ростовская область"},
+	{"61", "Рязанская область"},
+	{"63", "Саратовская область"},
+	{"64", "Сахалинская область"},
+	{"65", "Свердловская область"},
+	{"66", "Смоленская область"},
+	{"67", "Севастополь"},
+	{"68", "Тамбовская область"},
+	{"69", "Томская область"},
+	{"70", "Тульская область"},
+	{"71", "Тюменская область"},
+	{"73", "Ульяновская область"},
+	{"75", "Челябинская область"},
+	{"76", "Забайкальский край"},
+	{"77", "Чукотский автономный округ"},
+	{"78", "Ярославская область"},
+	{"79", "Республика Адыгея"},
+	{"80", "Республика Башкортостан"},
+	{"81", "Республика Бурятия"},
+	{"82", "Республика Дагестан"},
+	{"83", "Кабардино-Балкарская Республика"},
+	{"84", "Республика Алтай"},
+	{"85", "Республика Калмыкия"},
+	{"86", "Республика Карелия"},
+	{"87", "Республика Коми"},
+	{"88", "Республика Марий Эл"},
+	{"89", "Республика Мордовия"},
+	{"90", "Республика Северная Осетия — Алания"},
+	{"91", "Карачаево-Черкесская Республика"},
+	{"92", "Республика Татарстан"},
+	{"93", "Республика Тыва"},
+	{"94", "Удмуртская Республика"},
+	{"95", "Республика Хакасия"},
+	{"96", "Чеченская Республика"},
+	{"97", "Чувашская Республика"},
+	{"98", "Республика Саха (Якутия)"},
+	{"99", "Еврейская автономная область"},
+	// Автономные округа входят в ОКАТО своих областей (71100, 71140, 11100)
+	{"", "Ханты-Мансийский автономный округ — Югра"},
+	{"", "Ямало-Ненецкий автономный округ"},
+	{"", "Ненецкий автономный округ"},
+}
+
+// phoneCodes maps landline area codes (ABC) to regions. Mobile numbers (9xx) are not tied
+// to a region since numbers are portable, so they stay unknown
+var phoneCodes = map[string]string{
+	"495": "Москва", "499": "Москва",
+	"496": "Московская область", "498": "Московская область",
+	"812": "Санкт-Петербург", "813": "Ленинградская область",
+	"301": "Республика Бурятия", "302": "Забайкальский край",
+	"341": "Удмуртская Республика", "342": "Пермский край", "343": "Свердловская область",
+	"345": "Тюменская область", "346": "Ханты-Мансийский автономный округ — Югра",
+	"347": "Республика Башкортостан", "349": "Ямало-Ненецкий автономный округ",
+	"351": "Челябинская область", "352": "Курганская область", "353": "Оренбургская область",
+	"365": "Республика Крым", "869": "Севастополь",
+	"381": "Омская область", "382": "Томская область", "383": "Новосибирская область",
+	"384": "Кемеровская область", "385": "Алтайский край", "388": "Республика Алтай",
+	"390": "Республика Хакасия", "391": "Красноярский край", "394": "Республика Тыва",
+	"395": "Иркутская область",
+	"401": "Калининградская область", "411": "Республика Саха (Якутия)", "413": "Магаданская область",
+	"415": "Камчатский край", "416": "Амурская область", "421": "Хабаровский край",
+	"423": "Приморский край", "424": "Сахалинская область", "426": "Еврейская автономная область",
+	"427": "Чукотский автономный округ",
+	"471": "Курская область", "472": "Белгородская область", "473": "Воронежская область",
+	"474": "Липецкая область", "475": "Тамбовская область",
+	"481": "Смоленская область", "482": "Тверская область", "483": "Брянская область",
+	"484": "Калужская область", "485": "Ярославская область", "486": "Орловская область",
+	"487": "Тульская область",
+	"491": "Рязанская область", "492": "Владимирская область", "493": "Ивановская область",
+	"494": "Костромская область",
+	"811": "Псковская область", "814": "Республика Карелия", "815": "Мурманская область",
+	"816": "Новгородская область", "817": "Вологодская область", "818": "Архангельская область",
+	"821": "Республика Коми",
+	"831": "Нижегородская область", "833": "Кировская область", "834": "Республика Мордовия",
+	"835": "Чувашская Республика", "836": "Республика Марий Эл",
+	"841": "Пензенская область", "842": "Ульяновская область", "843": "Республика Татарстан",
+	"844": "Волгоградская область", "845": "Саратовская область", "846": "Самарская область",
+	"847": "Республика Калмыкия", "848": "Самарская область",
+	"851": "Астраханская область", "855": "Республика Татарстан",
+	"861": "Краснодарский край", "862": "Краснодарский край", "863": "Ростовская область",
+	"865": "Ставропольский край", "866": "Кабардино-Балкарская Республика",
+	"867": "Республика Северная Осетия — Алания",
+	"871": "Чеченская Республика", "872": "Республика Дагестан", "873": "Республика Ингушетия",
+	"877": "Республика Адыгея", "878": "Карачаево-Черкесская Республика",
+}
+
+// Слова типа субъекта пишут в адресах по-разному: "Московская обл", "респ. Татарстан", "г Москва"
+const regionTypePattern = `область|обл|республика|респ|край|автономный округ|автономная область|ао|город|г`
+
+var regionTypeWords = regexp.MustCompile(`(^|\s)(` + regionTypePattern + `)(\.|\s|$)`)
+
+// regionKey reduces a region written in an address to the key it is looked up by: lower case,
+// ё replaced and the type words dropped, so "респ. Татарстан" and "Республика Татарстан" meet.
+// The same reduction is done in SQL by regionKeySQL
+func regionKey(name string) string {
+	key := strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+	key = strings.NewReplacer("—", " ", "-", " ", "(", " ", ")", " ").Replace(key)
+	// Повторяем, так как соседние слова типа делят пробел
+	for regionTypeWords.MatchString(key) {
+		key = regionTypeWords.ReplaceAllString(key, " ")
+	}
+	return strings.Join(strings.Fields(key), " ")
+}
+
+// regionKeys lists the region keys with their canonical names
+func regionKeys() (keys, names []string) {
+	for _, r := range regions {
+		keys = append(keys, regionKey(r.name))
+		names = append(names, r.name)
+	}
+	return keys, names
+}
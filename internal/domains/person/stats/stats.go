@@ -0,0 +1,239 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"service/internal/domains/person/models"
+	"service/internal/domains/person/quality"
+	"slices"
+	"strings"
+)
+
+// ErrInvalid is returned for an unknown or repeated dimension, a bad bucket or group size
+var ErrInvalid = errors.New("invalid stats parameters")
+
+// Unknown is the value of a dimension that cannot be derived for a person
+const Unknown = "unknown"
+
+// Dimensions of GET /persons/stats
+const (
+	DimensionRegion         = "region"
+	DimensionRegionAddress  = "region.address"
+	DimensionRegionPassport = "region.passport"
+	DimensionRegionPhone    = "region.phone"
+	DimensionBirthYear      = "birth_year"
+	DimensionBirthDecade    = "birth_decade"
+	DimensionGender         = "gender"
+	DimensionSource         = "source"
+	DimensionQuality        = "quality"
+	// QualityPrefix groups by the state of one field: quality.snils is valid, missing, checksum_failed...
+	QualityPrefix = "quality."
+)
+
+// Limits of a request
+const (
+	DefaultBirthBucket = 10
+	MaxBirthBucket     = 100
+	MaxDimensions      = 4
+)
+
+// Request is a grouping of GET /persons/stats
+type Request struct {
+	GroupBy []string
+	// BirthBucket is the number of years in a birth_year group
+	BirthBucket int
+	// MinGroupSize hides the groups of fewer people, so that the stats can be shared
+	MinGroupSize int
+}
+
+// Column is a dimension with its SQL expression over the persons aliased p
+type Column struct {
+	Name string
+	Expr string
+}
+
+// Dimensions lists the accepted dimensions; quality.<field> is given for every scored field
+func Dimensions() []string {
+	dimensions := []string{
+		DimensionRegion, DimensionRegionAddress, DimensionRegionPassport, DimensionRegionPhone,
+		DimensionBirthYear, DimensionBirthDecade, DimensionGender, DimensionSource, DimensionQuality,
+	}
+	for _, field := range quality.Fields {
+		dimensions = append(dimensions, QualityPrefix+field)
+	}
+	return dimensions
+}
+
+// lowerSQL lowers text regardless of the database locale: lower() knows Cyrillic letters only
+// in Russian locales, so they are lowered by translate
+const lowerSQL = `translate(lower(%s), 'АБВГДЕЁЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ', 'абвгдеёжзийклмнопрстуфхцчшщъыьэюя')`
+
+// regionKeySQL is regionKey written in SQL. Type words are anchored on whitespace like regionTypeWords,
+// not on the locale-dependent word boundaries \m \M; the second pass removes a word glued to the previous
+// one by a dot, as the loop in regionKey does
+const regionKeySQL = `btrim(regexp_replace(
+    regexp_replace(regexp_replace(translate(` + lowerSQL + `, 'ё—-()', 'е    '),
+        '(^|\s)(` + regionTypePattern + `)(\.|(?=\s|$))', ' ', 'g'),
+        '(^|\s)(` + regionTypePattern + `)(\.|(?=\s|$))', ' ', 'g'),
+    '\s+', ' ', 'g'))`
+
+// Города федерального значения пишут в адресе как город, без региона
+var federalCitySQL = fmt.Sprintf(`CASE WHEN %s IN ('москва', 'санкт-петербург', 'севастополь') THEN a.settlement END`,
+	fmt.Sprintf(lowerSQL, "a.settlement"))
+
+// compiler appends the lookup tables to the arguments once, however many dimensions use them
+type compiler struct {
+	args   *[]interface{}
+	tables map[string]string
+}
+
+func (c *compiler) table(name string, lookup func() ([]string, []string)) string {
+	if placeholder, ok := c.tables[name]; ok {
+		return placeholder
+	}
+	k, v := lookup()
+	*c.args = append(*c.args, k, v)
+	placeholder := fmt.Sprintf("unnest($%d::text[], $%d::text[])", len(*c.args)-1, len(*c.args))
+	c.tables[name] = placeholder
+	return placeholder
+}
+
+func (c *compiler) regionAddress() string {
+	table := c.table("keys", regionKeys)
+	return fmt.Sprintf(`(
+        SELECT COALESCE(r.name, a.region)
+        FROM (
+            SELECT COALESCE(NULLIF(a.region, ''), %s) AS region
+            FROM person_addresses a
+            WHERE a.person_id = p.id
+            ORDER BY a.is_primary DESC, a.id
+            LIMIT 1
+        ) a
+        LEFT JOIN %s AS r(key, name) ON r.key = %s
+    )`, federalCitySQL, table, fmt.Sprintf(regionKeySQL, "a.region"))
+}
+
+func (c *compiler) regionPassport() string {
+	table := c.table("okato", okatoCodes)
+	return fmt.Sprintf(`(
+        SELECT r.name FROM %s AS r(code, name)
+        WHERE length(regexp_replace(p.passport, '\D', '', 'g')) = 10
+            AND r.code = left(regexp_replace(p.passport, '\D', '', 'g'), 2)
+    )`, table)
+}
+
+func (c *compiler) regionPhone() string {
+	table := c.table("phone", areaCodes)
+	return fmt.Sprintf(`(
+        SELECT r.name FROM %s AS r(code, name)
+        WHERE length(regexp_replace(p.phone, '\D', '', 'g')) IN (10, 11)
+            AND r.code = left(right(regexp_replace(p.phone, '\D', '', 'g'), 10), 3)
+    )`, table)
+}
+
+func birthYears(bucket int) string {
+	if bucket == 1 {
+		return "extract(year FROM p.birth_day)::int::text"
+	}
+	year := fmt.Sprintf("(extract(year FROM p.birth_day)::int / %d * %d)", bucket, bucket)
+	return fmt.Sprintf("%s::text || '-' || (%s + %d)::text", year, year, bucket-1)
+}
+
+// Compile checks the request, fills the defaults and returns the dimension columns.
+// Values of the region lookup tables are appended to args as parameters
+func Compile(req *Request, args *[]interface{}) ([]Column, error) {
+	if len(req.GroupBy) == 0 {
+		return nil, fmt.Errorf("%w: group_by is required, use %s", ErrInvalid, strings.Join(Dimensions(), ", "))
+	}
+	if len(req.GroupBy) > MaxDimensions {
+		return nil, fmt.Errorf("%w: at most %d dimensions", ErrInvalid, MaxDimensions)
+	}
+	if req.BirthBucket == 0 {
+		req.BirthBucket = DefaultBirthBucket
+	}
+	if req.BirthBucket < 1 || req.BirthBucket > MaxBirthBucket {
+		return nil, fmt.Errorf("%w: birth_bucket must be within 1-%d", ErrInvalid, MaxBirthBucket)
+	}
+	if req.MinGroupSize < 0 {
+		return nil, fmt.Errorf("%w: min_group_size must not be negative", ErrInvalid)
+	}
+
+	c := &compiler{args: args, tables: make(map[string]string)}
+	columns := make([]Column, 0, len(req.GroupBy))
+	for i, name := range req.GroupBy {
+		if slices.Contains(req.GroupBy[:i], name) {
+			return nil, fmt.Errorf("%w: dimension %q is repeated", ErrInvalid, name)
+		}
+
+		var expr string
+		switch name {
+		case DimensionRegion:
+			// Адрес точнее паспорта, а паспорт - городского телефона
+			expr = fmt.Sprintf("COALESCE(%s, %s, %s)", c.regionAddress(), c.regionPassport(), c.regionPhone())
+		case DimensionRegionAddress:
+			expr = c.regionAddress()
+		case DimensionRegionPassport:
+			expr = c.regionPassport()
+		case DimensionRegionPhone:
+			expr = c.regionPhone()
+		case DimensionBirthYear:
+			expr = birthYears(req.BirthBucket)
+		case DimensionBirthDecade:
+			expr = birthYears(10)
+		case DimensionGender:
+			expr = "NULLIF(p.gender, '')"
+		case DimensionSource:
+			expr = "p.source_id"
+		case DimensionQuality:
+			expr = "CASE WHEN p.quality_issues = '[]'::jsonb THEN 'clean' ELSE 'issues' END"
+		default:
+			field, ok := strings.CutPrefix(name, QualityPrefix)
+			if !ok || !slices.Contains(quality.Fields, field) {
+				return nil, fmt.Errorf("%w: unknown dimension %q, use %s", ErrInvalid, name, strings.Join(Dimensions(), ", "))
+			}
+			// Поле из белого списка, поэтому его можно подставить в запрос
+			expr = fmt.Sprintf(`COALESCE((
+                SELECT i->>'code' FROM jsonb_array_elements(p.quality_issues) i WHERE i->>'field' = '%s' LIMIT 1
+            ), 'valid')`, field)
+		}
+		columns = append(columns, Column{Name: name, Expr: fmt.Sprintf("COALESCE(%s, '%s')", expr, Unknown)})
+	}
+	return columns, nil
+}
+
+// Suppress hides the groups of fewer than min people and returns how many were hidden.
+// Neither their counts nor their sum are reported, so a hidden group cannot be recovered from totals
+func Suppress(groups []models.StatsGroup, min int) ([]models.StatsGroup, int) {
+	if min <= 1 {
+		return groups, 0
+	}
+	shown := make([]models.StatsGroup, 0, len(groups))
+	for _, group := range groups {
+		if group.People >= int64(min) {
+			shown = append(shown, group)
+		}
+	}
+	return shown, len(groups) - len(shown)
+}
+
+func okatoCodes() (codes, names []string) {
+	for _, r := range regions {
+		if r.okato != "" {
+			codes = append(codes, r.okato)
+			names = append(names, r.name)
+		}
+	}
+	return codes, names
+}
+
+func areaCodes() (codes, names []string) {
+	for code := range phoneCodes {
+		codes = append(codes, code)
+	}
+	// Порядок фиксирован, чтобы одинаковые запросы давали одинаковый ключ кэша
+	slices.Sort(codes)
+	for _, code := range codes {
+		names = append(names, phoneCodes[code])
+	}
+	return codes, names
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"service/internal/domains/person/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionKey(t *testing.T) {
+	assert.Equal(t, "татарстан", regionKey("респ. Татарстан"))
+	assert.Equal(t, "татарстан", regionKey("Республика Татарстан"))
+	assert.Equal(t, "московская", regionKey("Московская обл"))
+	assert.Equal(t, "москва", regionKey("г Москва"))
+	assert.Equal(t, "ханты мансийский югра", regionKey("Ханты-Мансийский автономный округ — Югра"))
+	assert.Equal(t, "кабардино балкарская", regionKey("Кабардино-Балкарская Республика"))
+
+	keys, names := regionKeys()
+	seen := make(map[string]string)
+	for i, key := range keys {
+		assert.NotEmpty(t, key, names[i])
+		assert.NotContains(t, seen, key, names[i])
+		seen[key] = names[i]
+	}
+	for code, name := range phoneCodes {
+		assert.Contains(t, names, name, code)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	var args []interface{}
+	req := Request{GroupBy: []string{"region", "region.passport", "birth_year", "quality.snils"}}
+	columns, err := Compile(&req, &args)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultBirthBucket, req.BirthBucket)
+	assert.Len(t, columns, 4)
+	assert.Equal(t, "quality.snils", columns[3].Name)
+	assert.Contains(t, columns[3].Expr, "i->>'field' = 'snils'")
+
+	// Таблицы регионов передаются параметрами по одному разу
+	assert.Len(t, args, 6)
+	assert.Equal(t, 1, strings.Count(columns[1].Expr, "unnest($3::text[], $4::text[])"))
+	assert.Contains(t, columns[0].Expr, "unnest($3::text[], $4::text[])")
+
+	req = Request{GroupBy: []string{"birth_year"}, BirthBucket: 1}
+	columns, err = Compile(&req, &args)
+	assert.NoError(t, err)
+	assert.Equal(t, "COALESCE(extract(year FROM p.birth_day)::int::text, 'unknown')", columns[0].Expr)
+
+	for name, req := range map[string]Request{
+		"no dimensions":      {},
+		"unknown dimension":  {GroupBy: []string{"city"}},
+		"unknown field":      {GroupBy: []string{"quality.gender"}},
+		"repeated dimension": {GroupBy: []string{"gender", "gender"}},
+		"too many":           {GroupBy: []string{"gender", "source", "quality", "region", "birth_year"}},
+		"bad bucket":         {GroupBy: []string{"birth_year"}, BirthBucket: 101},
+		"negative size":      {GroupBy: []string{"gender"}, MinGroupSize: -1},
+	} {
+		_, err := Compile(&req, &args)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	groups := []models.StatsGroup{
+		{Keys: map[string]string{"gender": "female"}, Records: 40, People: 30},
+		{Keys: map[string]string{"gender": "male"}, Records: 25, People: 12},
+		{Keys: map[string]string{"gender": "unknown"}, Records: 9, People: 3},
+	}
+
+	shown, suppressed := Suppress(groups, 10)
+	assert.Len(t, shown, 2)
+	assert.Equal(t, 1, suppressed)
+
+	shown, suppressed = Suppress(groups, 0)
+	assert.Len(t, shown, 3)
+	assert.Equal(t, 0, suppressed)
+}
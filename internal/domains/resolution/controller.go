@@ -0,0 +1,102 @@
+package resolution
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"service/internal/domains/resolution/models"
+	"strconv"
+)
+
+const (
+	defaultReviewLimit = 50
+	maxReviewLimit     = 500
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/reviews", c.ListReviews)
+	r.POST("/reviews/:id/accept", c.Accept)
+	r.POST("/reviews/:id/reject", c.Reject)
+	r.POST("/reviews/:id/defer", c.Defer)
+}
+
+// ListReviews returns queued pairs: ?status=pending|deferred|accepted|rejected&limit=&offset=
+func (c *Controller) ListReviews(ctx *gin.Context) {
+	status := ctx.DefaultQuery("status", models.StatusPending)
+	switch status {
+	case models.StatusPending, models.StatusDeferred, models.StatusAccepted, models.StatusRejected:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Неизвестный статус: " + status})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultReviewLimit)))
+	if err != nil || limit <= 0 || limit > maxReviewLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный limit"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный offset"})
+		return
+	}
+
+	pairs, err := c.svc.ListReviews(ctx.Request.Context(), status, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reviews": pairs})
+}
+
+func (c *Controller) Accept(ctx *gin.Context) {
+	c.decide(ctx, c.svc.Accept)
+}
+
+func (c *Controller) Reject(ctx *gin.Context) {
+	c.decide(ctx, c.svc.Reject)
+}
+
+func (c *Controller) Defer(ctx *gin.Context) {
+	c.decide(ctx, c.svc.Defer)
+}
+
+func (c *Controller) decide(ctx *gin.Context, action func(context.Context, int, models.Decision) error) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный идентификатор"})
+		return
+	}
+
+	// Тело запроса необязательно
+	var decision models.Decision
+	if err := ctx.ShouldBindJSON(&decision); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = action(ctx.Request.Context(), id, decision)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Пара не найдена"})
+	case errors.Is(err, ErrDecided):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Решение по паре уже принято"})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"message": "Decision saved"})
+	}
+}
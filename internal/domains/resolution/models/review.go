@@ -0,0 +1,42 @@
+package models
+
+import (
+	"service/internal/domains/person/matching"
+	personModels "service/internal/domains/person/models"
+)
+
+// Review statuses
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRejected = "rejected"
+	StatusDeferred = "deferred"
+)
+
+// Review is a candidate pair of source records whose match score lies between the thresholds
+type Review struct {
+	Id        int      `db:"id" json:"id"`
+	LeftId    int      `db:"left_id" json:"left_id"`
+	RightId   int      `db:"right_id" json:"right_id"`
+	Score     float64  `db:"score" json:"score"`
+	Reasons   []string `db:"reasons" json:"reasons"`
+	Status    string   `db:"status" json:"status"`
+	Operator  string   `db:"operator" json:"operator,omitempty"`
+	Comment   string   `db:"comment" json:"comment,omitempty"`
+	CreatedAt string   `db:"created_at" json:"created_at"`
+	DecidedAt string   `db:"decided_at" json:"decided_at,omitempty"`
+}
+
+// ReviewPair is a review with both records and their field-by-field comparison
+type ReviewPair struct {
+	Review
+	Left   personModels.Person        `json:"left"`
+	Right  personModels.Person        `json:"right"`
+	Fields []matching.FieldComparison `json:"fields"`
+}
+
+// Decision is the body of accept, reject and defer requests
+type Decision struct {
+	Operator string `json:"operator"`
+	Comment  string `json:"comment"`
+}
@@ -0,0 +1,85 @@
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"service/internal/domains/resolution/models"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+}
+
+func NewRepository(db *postgres.Wrapper) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+const reviewColumns = `
+    id,
+    left_id,
+    right_id,
+    score,
+    reasons,
+    status,
+    COALESCE(operator, '') AS operator,
+    COALESCE(comment, '') AS comment,
+    created_at::text AS created_at,
+    COALESCE(decided_at::text, '') AS decided_at`
+
+// ListReviews returns the reviews in the given status, the most likely matches first
+func (r *Repository) ListReviews(ctx context.Context, status string, limit, offset int) ([]models.Review, error) {
+	var reviews []models.Review
+	query := fmt.Sprintf(`
+        SELECT %s FROM match_reviews
+        WHERE status = $1
+        ORDER BY score DESC, id
+        LIMIT $2 OFFSET $3`, reviewColumns)
+	if err := r.db.Select(ctx, &reviews, query, status, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// GetReview returns a review, or nil if it does not exist
+func (r *Repository) GetReview(ctx context.Context, id int) (*models.Review, error) {
+	var reviews []models.Review
+	query := fmt.Sprintf(`SELECT %s FROM match_reviews WHERE id = $1`, reviewColumns)
+	if err := r.db.Select(ctx, &reviews, query, id); err != nil {
+		return nil, fmt.Errorf("failed to query review: %w", err)
+	}
+	if len(reviews) == 0 {
+		return nil, nil
+	}
+	return &reviews[0], nil
+}
+
+// Decide records the decision on an open (pending or deferred) review.
+// It reports false if the review is missing or already accepted or rejected
+func (r *Repository) Decide(ctx context.Context, id int, status string, decision models.Decision) (bool, error) {
+	query := `
+        UPDATE match_reviews
+        SET status = $2, operator = NULLIF($3, ''), comment = NULLIF($4, ''), decided_at = now()
+        WHERE id = $1 AND status IN ('pending', 'deferred')`
+	tag, err := r.db.Exec(ctx, query, id, status, decision.Operator, decision.Comment)
+	if err != nil {
+		return false, fmt.Errorf("failed to save decision: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Restore puts an accepted review back into the state it had before the decision,
+// e.g. when the merge that should follow the decision fails
+func (r *Repository) Restore(ctx context.Context, review models.Review) error {
+	query := `
+        UPDATE match_reviews
+        SET status = $2, operator = NULLIF($3, ''), comment = NULLIF($4, ''), decided_at = NULLIF($5, '')::timestamptz
+        WHERE id = $1 AND status = 'accepted'`
+	_, err := r.db.Exec(ctx, query, review.Id, review.Status, review.Operator, review.Comment, review.DecidedAt)
+	if err != nil {
+		return fmt.Errorf("failed to restore review: %w", err)
+	}
+	return nil
+}
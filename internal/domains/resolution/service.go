@@ -0,0 +1,131 @@
+package resolution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service/internal/domains/person"
+	"service/internal/domains/person/matching"
+	"service/internal/domains/resolution/models"
+
+	personModels "service/internal/domains/person/models"
+)
+
+var (
+	// ErrNotFound is returned for a missing review
+	ErrNotFound = errors.New("review not found")
+	// ErrDecided is returned when a review was already accepted or rejected
+	ErrDecided = errors.New("review already decided")
+)
+
+type Service struct {
+	repo    *Repository
+	persons *person.Service
+}
+
+func NewService(repo *Repository, persons *person.Service) *Service {
+	return &Service{
+		repo:    repo,
+		persons: persons,
+	}
+}
+
+// ListReviews returns the queued pairs with both records compared field by field
+func (s *Service) ListReviews(ctx context.Context, status string, limit, offset int) ([]models.ReviewPair, error) {
+	reviews, err := s.repo.ListReviews(ctx, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(reviews)*2)
+	for _, review := range reviews {
+		ids = append(ids, review.LeftId, review.RightId)
+	}
+	persons, err := s.persons.GetPersons(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int]personModels.Person, len(persons))
+	for _, p := range persons {
+		byId[p.Id] = p
+	}
+
+	pairs := make([]models.ReviewPair, 0, len(reviews))
+	for _, review := range reviews {
+		left, right := byId[review.LeftId], byId[review.RightId]
+		pairs = append(pairs, models.ReviewPair{
+			Review: review,
+			Left:   left,
+			Right:  right,
+			Fields: matching.CompareFields(left, right),
+		})
+	}
+	return pairs, nil
+}
+
+// Accept confirms that both records describe one person and joins their masters
+func (s *Service) Accept(ctx context.Context, id int, decision models.Decision) error {
+	review, err := s.openReview(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	persons, err := s.persons.GetPersons(ctx, []int{review.LeftId, review.RightId})
+	if err != nil {
+		return err
+	}
+	// Решение фиксируется до слияния: из одновременных запросов сливает записи только тот,
+	// кто закрыл открытую заявку. Если слияние не удалось, заявка возвращается в очередь
+	if err := s.decide(ctx, id, models.StatusAccepted, decision); err != nil {
+		return err
+	}
+	if _, err := s.persons.JoinPersons(ctx, persons); err != nil {
+		if restoreErr := s.repo.Restore(ctx, *review); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+		return fmt.Errorf("failed to merge review %d: %w", id, err)
+	}
+	return nil
+}
+
+// Reject keeps the records apart: the pair is never proposed again and the matcher
+// does not join their masters through other records
+func (s *Service) Reject(ctx context.Context, id int, decision models.Decision) error {
+	if _, err := s.openReview(ctx, id); err != nil {
+		return err
+	}
+	return s.decide(ctx, id, models.StatusRejected, decision)
+}
+
+// Defer postpones the decision; the pair stays in the queue with status deferred
+func (s *Service) Defer(ctx context.Context, id int, decision models.Decision) error {
+	if _, err := s.openReview(ctx, id); err != nil {
+		return err
+	}
+	return s.decide(ctx, id, models.StatusDeferred, decision)
+}
+
+func (s *Service) openReview(ctx context.Context, id int) (*models.Review, error) {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrNotFound
+	}
+	if review.Status != models.StatusPending && review.Status != models.StatusDeferred {
+		return nil, ErrDecided
+	}
+	return review, nil
+}
+
+func (s *Service) decide(ctx context.Context, id int, status string, decision models.Decision) error {
+	ok, err := s.repo.Decide(ctx, id, status, decision)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDecided
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package survivorship
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/internal/domains/survivorship/models"
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/survivorship", c.ListRules)
+	r.PUT("/survivorship/:field", c.SetRule)
+	r.DELETE("/survivorship/:field", c.DeleteRule)
+	r.GET("/survivorship/recompute", c.Recompute)
+
+	r.GET("/sources", c.ListSources)
+	r.PUT("/sources/:id", c.UpdateSource)
+}
+
+// ListRules returns the survivorship strategy of every golden record field
+func (c *Controller) ListRules(ctx *gin.Context) {
+	rules, err := c.svc.ListRules(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// SetRule sets the strategy of a field: {"strategy": "most_trusted"}
+func (c *Controller) SetRule(ctx *gin.Context) {
+	var request models.RuleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recompute, err := c.svc.SetRule(ctx.Request.Context(), ctx.Param("field"), request.Strategy)
+	c.respond(ctx, gin.H{"recompute": recompute}, err)
+}
+
+// DeleteRule returns a field to the default strategy
+func (c *Controller) DeleteRule(ctx *gin.Context) {
+	recompute, err := c.svc.DeleteRule(ctx.Request.Context(), ctx.Param("field"))
+	c.respond(ctx, gin.H{"recompute": recompute}, err)
+}
+
+// Recompute shows the progress of the golden record rebuild started by the latest change
+func (c *Controller) Recompute(ctx *gin.Context) {
+	current, queued := c.svc.Recompute()
+	ctx.JSON(http.StatusOK, gin.H{"recompute": current, "queued": queued})
+}
+
+// ListSources returns the sources with their trust levels
+func (c *Controller) ListSources(ctx *gin.Context) {
+	sources, err := c.svc.ListSources(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"sources": sources})
+}
+
+// UpdateSource changes the name or trust level of a source: {"trust": 90}
+func (c *Controller) UpdateSource(ctx *gin.Context) {
+	var request models.SourceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, recompute, err := c.svc.UpdateSource(ctx.Request.Context(), ctx.Param("id"), request)
+	c.respond(ctx, gin.H{"source": source, "recompute": recompute}, err)
+}
+
+func (c *Controller) respond(ctx *gin.Context, body gin.H, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusOK, body)
+	}
+}
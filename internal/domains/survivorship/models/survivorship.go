@@ -0,0 +1,48 @@
+package models
+
+// Rule is the survivorship strategy configured for a golden record field
+type Rule struct {
+	Field     string `db:"field" json:"field"`
+	Strategy  string `db:"strategy" json:"strategy"`
+	UpdatedAt string `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// RuleRequest sets the strategy of a field
+type RuleRequest struct {
+	Strategy string `json:"strategy" binding:"required"`
+}
+
+// Source is a data source with its trust level (0..100)
+type Source struct {
+	Id        string `db:"id" json:"id"`
+	Name      string `db:"name" json:"name"`
+	Trust     int    `db:"trust" json:"trust"`
+	Persons   int    `db:"persons" json:"persons"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+}
+
+// SourceRequest updates a source; omitted fields keep their values
+type SourceRequest struct {
+	Name  *string `json:"name"`
+	Trust *int    `json:"trust"`
+}
+
+// Recompute job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// RecomputeJob is the background rebuild of golden records after a survivorship change
+type RecomputeJob struct {
+	Id         int    `json:"id"`
+	SourceId   string `json:"source_id,omitempty"` // empty when all masters are rebuilt
+	Status     string `json:"status"`
+	Total      int    `json:"total"`
+	Done       int    `json:"done"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
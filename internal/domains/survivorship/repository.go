@@ -0,0 +1,87 @@
+package survivorship
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"service/internal/domains/survivorship/models"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+}
+
+func NewRepository(db *postgres.Wrapper) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// ListRules returns the configured field strategies
+func (r *Repository) ListRules(ctx context.Context) ([]models.Rule, error) {
+	var rules []models.Rule
+	query := `SELECT field, strategy, updated_at::text AS updated_at FROM survivorship_rules ORDER BY field`
+	if err := r.db.Select(ctx, &rules, query); err != nil {
+		return nil, fmt.Errorf("failed to query survivorship rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveRule sets the strategy of a field
+func (r *Repository) SaveRule(ctx context.Context, field, strategy string) error {
+	query := `
+        INSERT INTO survivorship_rules (field, strategy) VALUES ($1, $2)
+        ON CONFLICT (field) DO UPDATE SET strategy = EXCLUDED.strategy, updated_at = now()`
+	if _, err := r.db.Exec(ctx, query, field, strategy); err != nil {
+		return fmt.Errorf("failed to save survivorship rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule returns the field to the default strategy. It reports false if no rule was configured
+func (r *Repository) DeleteRule(ctx context.Context, field string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM survivorship_rules WHERE field = $1`, field)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete survivorship rule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+const sourceColumns = `
+    s.id,
+    s.name,
+    s.trust,
+    (SELECT count(*) FROM persons p WHERE p.source_id = s.id)::int AS persons,
+    s.created_at::text AS created_at`
+
+// ListSources returns the sources, the most trusted first
+func (r *Repository) ListSources(ctx context.Context) ([]models.Source, error) {
+	var sources []models.Source
+	query := fmt.Sprintf(`SELECT %s FROM sources s ORDER BY s.trust DESC, s.id`, sourceColumns)
+	if err := r.db.Select(ctx, &sources, query); err != nil {
+		return nil, fmt.Errorf("failed to query sources: %w", err)
+	}
+	return sources, nil
+}
+
+// UpdateSource changes the name and trust level of a source and returns it, or nil if it does not exist
+func (r *Repository) UpdateSource(ctx context.Context, id string, request models.SourceRequest) (*models.Source, error) {
+	query := `UPDATE sources SET name = COALESCE($2, name), trust = COALESCE($3, trust) WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id, request.Name, request.Trust)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update source: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	var sources []models.Source
+	query = fmt.Sprintf(`SELECT %s FROM sources s WHERE s.id = $1`, sourceColumns)
+	if err := r.db.Select(ctx, &sources, query, id); err != nil {
+		return nil, fmt.Errorf("failed to query source: %w", err)
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	return &sources[0], nil
+}
@@ -0,0 +1,194 @@
+package survivorship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service/internal/domains/person"
+	"service/internal/domains/person/matching"
+	"service/internal/domains/survivorship/models"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNotFound is returned for a missing source or rule
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for an unknown field, strategy or an out of range trust level
+	ErrInvalid = errors.New("invalid survivorship settings")
+)
+
+type Service struct {
+	repo    *Repository
+	persons *person.Service
+
+	// Пересчет идет в фоне; изменения, сделанные во время пересчета, копятся в одном ожидающем задании
+	mu      sync.Mutex
+	lastId  int
+	job     *models.RecomputeJob
+	pending *models.RecomputeJob
+}
+
+func NewService(repo *Repository, persons *person.Service) *Service {
+	return &Service{
+		repo:    repo,
+		persons: persons,
+	}
+}
+
+// ListRules returns the strategy of every golden record field, defaults included
+func (s *Service) ListRules(ctx context.Context) ([]models.Rule, error) {
+	configured, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byField := make(map[string]models.Rule, len(configured))
+	for _, rule := range configured {
+		byField[rule.Field] = rule
+	}
+
+	rules := make([]models.Rule, 0, len(matching.OverridableFields))
+	for _, field := range matching.OverridableFields {
+		rule, ok := byField[field]
+		if !ok {
+			rule = models.Rule{Field: field, Strategy: string(matching.DefaultStrategy)}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SetRule configures the strategy of a field and starts rebuilding all golden records
+func (s *Service) SetRule(ctx context.Context, field, strategy string) (*models.RecomputeJob, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if !knownField(field) {
+		return nil, fmt.Errorf("%w: unknown field %q", ErrInvalid, field)
+	}
+	if !matching.ValidStrategy(matching.Strategy(strategy)) {
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalid, strategy)
+	}
+	if err := s.repo.SaveRule(ctx, field, strategy); err != nil {
+		return nil, err
+	}
+	return s.recompute(ctx, ""), nil
+}
+
+// DeleteRule returns a field to the default strategy and starts rebuilding all golden records
+func (s *Service) DeleteRule(ctx context.Context, field string) (*models.RecomputeJob, error) {
+	deleted, err := s.repo.DeleteRule(ctx, field)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrNotFound
+	}
+	return s.recompute(ctx, ""), nil
+}
+
+// ListSources returns the sources with their trust levels
+func (s *Service) ListSources(ctx context.Context) ([]models.Source, error) {
+	return s.repo.ListSources(ctx)
+}
+
+// UpdateSource changes a source; a new trust level starts rebuilding the golden records the source contributes to
+func (s *Service) UpdateSource(ctx context.Context, id string, request models.SourceRequest) (*models.Source, *models.RecomputeJob, error) {
+	if request.Trust != nil && (*request.Trust < 0 || *request.Trust > 100) {
+		return nil, nil, fmt.Errorf("%w: trust must be between 0 and 100", ErrInvalid)
+	}
+	if request.Name != nil && strings.TrimSpace(*request.Name) == "" {
+		return nil, nil, fmt.Errorf("%w: empty name", ErrInvalid)
+	}
+
+	source, err := s.repo.UpdateSource(ctx, id, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	if source == nil {
+		return nil, nil, ErrNotFound
+	}
+	if request.Trust == nil {
+		return source, nil, nil
+	}
+	return source, s.recompute(ctx, id), nil
+}
+
+// Recompute returns the running or the latest recompute job and the job queued after it, if any
+func (s *Service) Recompute() (*models.RecomputeJob, *models.RecomputeJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return snapshot(s.job), snapshot(s.pending)
+}
+
+// recompute schedules a rebuild of the masters of the source, or of all masters when sourceId is empty.
+// While a job runs, the changes are collected into one queued job started after it
+func (s *Service) recompute(ctx context.Context, sourceId string) *models.RecomputeJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending != nil {
+		if s.pending.SourceId != sourceId {
+			s.pending.SourceId = ""
+		}
+		return snapshot(s.pending)
+	}
+
+	s.lastId++
+	job := &models.RecomputeJob{Id: s.lastId, SourceId: sourceId, Status: models.JobQueued}
+	if s.job != nil && s.job.Status == models.JobRunning {
+		s.pending = job
+	} else {
+		s.start(context.WithoutCancel(ctx), job)
+	}
+	return snapshot(job)
+}
+
+// start runs the job in the background; s.mu must be held
+func (s *Service) start(ctx context.Context, job *models.RecomputeJob) {
+	job.Status = models.JobRunning
+	job.StartedAt = time.Now().Format(time.RFC3339)
+	s.job = job
+	go s.run(ctx, job)
+}
+
+func (s *Service) run(ctx context.Context, job *models.RecomputeJob) {
+	_, err := s.persons.RecomputeGoldens(ctx, job.SourceId, func(done, total int) {
+		s.mu.Lock()
+		job.Done, job.Total = done, total
+		s.mu.Unlock()
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.Status = models.JobCompleted
+	if err != nil {
+		log.Errorf("Failed to recompute golden records: %v", err)
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+	}
+	job.FinishedAt = time.Now().Format(time.RFC3339)
+
+	if next := s.pending; next != nil {
+		s.pending = nil
+		s.start(ctx, next)
+	}
+}
+
+func snapshot(job *models.RecomputeJob) *models.RecomputeJob {
+	if job == nil {
+		return nil
+	}
+	copied := *job
+	return &copied
+}
+
+func knownField(field string) bool {
+	for _, f := range matching.OverridableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/internal/domains/person/rules"
+)
+
+type Controller struct {
+	svc *Service
+}
+
+func NewController(svc *Service) *Controller {
+	return &Controller{
+		svc: svc,
+	}
+}
+
+func (c *Controller) Endpoints(r *gin.Engine) {
+	r.GET("/rules", c.ListRules)
+	r.POST("/rules", c.SaveRule)
+	r.DELETE("/rules/:name", c.DeleteRule)
+	r.POST("/rules/reload", c.Reload)
+	r.POST("/rules/test", c.TestFile)
+}
+
+func (c *Controller) ListRules(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"rules": c.svc.Rules()})
+}
+
+func (c *Controller) SaveRule(ctx *gin.Context) {
+	var rule rules.Rule
+	if err := ctx.ShouldBindJSON(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := rule.Compile(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.svc.SaveRule(ctx.Request.Context(), rule); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+func (c *Controller) DeleteRule(ctx *gin.Context) {
+	deleted, err := c.svc.DeleteRule(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Правило не найдено"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Rule deleted"})
+}
+
+func (c *Controller) Reload(ctx *gin.Context) {
+	if err := c.svc.Reload(ctx.Request.Context()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rules": c.svc.Rules()})
+}
+
+// TestFile accepts a file and an optional "rules" form field with a JSON ruleset
+func (c *Controller) TestFile(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Файл не найден"})
+		return
+	}
+	defer file.Close()
+
+	var ruleset []rules.Rule
+	if raw := ctx.PostForm("rules"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &ruleset); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := c.svc.TestFile(file, header.Filename, ruleset)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
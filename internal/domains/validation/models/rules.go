@@ -0,0 +1,14 @@
+package models
+
+import personModels "service/internal/domains/person/models"
+
+// RuleTestReport shows how a ruleset would treat every row of a file
+type RuleTestReport struct {
+	Rows        int                          `json:"rows"`
+	Passed      int                          `json:"passed"`
+	Warned      int                          `json:"warned"`
+	Quarantined int                          `json:"quarantined"`
+	Rejected    int                          `json:"rejected"`
+	ByRule      map[string]int               `json:"by_rule"`
+	Violations  []personModels.RowViolations `json:"violations"`
+}
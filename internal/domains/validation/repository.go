@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arlandaren/pgxWrappy/pkg/postgres"
+	"service/internal/domains/person/rules"
+)
+
+type Repository struct {
+	db *postgres.Wrapper
+}
+
+func NewRepository(db *postgres.Wrapper) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) GetRules(ctx context.Context) ([]rules.Rule, error) {
+	rows, err := r.db.Query(ctx, `SELECT definition FROM validation_rules WHERE enabled ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	var result []rules.Rule
+	for rows.Next() {
+		var rule rules.Rule
+		if err := rows.Scan(&rule); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		result = append(result, rule)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) SaveRule(ctx context.Context, rule rules.Rule) error {
+	query := `
+        INSERT INTO validation_rules (name, definition)
+        VALUES ($1, $2)
+        ON CONFLICT (name) DO UPDATE SET definition = EXCLUDED.definition, enabled = TRUE, updated_at = now()`
+
+	if _, err := r.db.Exec(ctx, query, rule.Name, rule); err != nil {
+		return fmt.Errorf("failed to save rule: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteRule(ctx context.Context, name string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM validation_rules WHERE name = $1`, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete rule: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,149 @@
+package validation
+
+import (
+	"context"
+	"io"
+	"service/internal/domains/person"
+	"service/internal/domains/person/rules"
+	"service/internal/domains/validation/models"
+	"service/internal/infrastructure/config"
+	"time"
+
+	personModels "service/internal/domains/person/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Service struct {
+	repo   *Repository
+	engine *rules.Engine
+	cfg    *config.RulesConfig
+}
+
+func NewService(repo *Repository, engine *rules.Engine, cfg *config.RulesConfig) *Service {
+	return &Service{
+		repo:   repo,
+		engine: engine,
+		cfg:    cfg,
+	}
+}
+
+// Reload reads the rules from the config file and the validation_rules table
+// and makes them active. Table rules override file rules with the same name
+func (s *Service) Reload(ctx context.Context) error {
+	var fileRules []rules.Rule
+	if s.cfg != nil && s.cfg.File != "" {
+		var err error
+		if fileRules, err = rules.ReadFile(s.cfg.File); err != nil {
+			return err
+		}
+	}
+
+	dbRules, err := s.repo.GetRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	overridden := make(map[string]bool, len(dbRules))
+	for _, rule := range dbRules {
+		overridden[rule.Name] = true
+	}
+
+	ruleset := make([]rules.Rule, 0, len(fileRules)+len(dbRules))
+	for _, rule := range fileRules {
+		if !overridden[rule.Name] {
+			ruleset = append(ruleset, rule)
+		}
+	}
+	ruleset = append(ruleset, dbRules...)
+
+	return s.engine.Load(ruleset)
+}
+
+// Watch reloads the rules periodically until the context is cancelled
+func (s *Service) Watch(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		log.Errorf("failed to load validation rules: %v", err)
+	}
+	if s.cfg == nil || s.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Errorf("failed to reload validation rules: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Service) Rules() []rules.Rule {
+	return s.engine.Rules()
+}
+
+func (s *Service) SaveRule(ctx context.Context, rule rules.Rule) error {
+	if err := rule.Compile(); err != nil {
+		return err
+	}
+	if err := s.repo.SaveRule(ctx, rule); err != nil {
+		return err
+	}
+	return s.Reload(ctx)
+}
+
+func (s *Service) DeleteRule(ctx context.Context, name string) (bool, error) {
+	deleted, err := s.repo.DeleteRule(ctx, name)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+	return true, s.Reload(ctx)
+}
+
+// TestFile evaluates a ruleset against the rows of a file without saving anything.
+// If ruleset is empty, the active rules are used
+func (s *Service) TestFile(file io.Reader, filename string, ruleset []rules.Rule) (*models.RuleTestReport, error) {
+	compiled := s.engine.Rules()
+	if len(ruleset) > 0 {
+		var err error
+		if compiled, err = rules.Compile(ruleset); err != nil {
+			return nil, err
+		}
+	}
+
+	persons, err := person.ReadFile(file, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RuleTestReport{
+		Rows:       len(persons),
+		ByRule:     make(map[string]int),
+		Violations: []personModels.RowViolations{},
+	}
+	for i, p := range persons {
+		violations := rules.Evaluate(compiled, person.PreparePerson(p))
+		for _, v := range violations {
+			report.ByRule[v.Rule]++
+		}
+
+		switch rules.Worst(violations) {
+		case rules.SeverityReject:
+			report.Rejected++
+		case rules.SeverityQuarantine:
+			report.Quarantined++
+		case rules.SeverityWarn:
+			report.Warned++
+		default:
+			report.Passed++
+			continue
+		}
+		report.Violations = append(report.Violations, personModels.RowViolations{Row: i + 1, Violations: violations})
+	}
+	return report, nil
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type PostgresConfig struct {
 	ConnStr string
 }
@@ -24,3 +26,32 @@ type MinioConfig struct {
 	SecretAccessKey string
 	UseSSL          bool
 }
+
+// PagingConfig limits the page size of person listings; zero or negative sizes fall back to the defaults
+type PagingConfig struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+type RulesConfig struct {
+	File           string
+	ReloadInterval time.Duration
+}
+
+// CacheConfig sets how long cached results live; zero disables caching of the kind
+type CacheConfig struct {
+	SearchTTL time.Duration
+	PersonTTL time.Duration
+}
+
+// ExportsConfig sets how often the scheduler looks for due exports; zero disables it
+type ExportsConfig struct {
+	Interval time.Duration
+}
+
+// AlertsConfig sets how often saved searches are checked and webhooks retried (zero disables
+// the background loop, imports still trigger the checks) and how long a webhook call may take
+type AlertsConfig struct {
+	Interval       time.Duration
+	WebhookTimeout time.Duration
+}
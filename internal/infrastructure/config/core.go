@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +12,11 @@ type Config struct {
 	Address  *Address
 	Redis    *RedisConfig
 	Minio    *MinioConfig
+	Rules    *RulesConfig
+	Paging   *PagingConfig
+	Cache    *CacheConfig
+	Exports  *ExportsConfig
+	Alerts   *AlertsConfig
 	Env      string
 }
 
@@ -29,6 +35,11 @@ func NewConfig() *Config {
 		Redis:    GetRedis(),
 		Env:      GetEnvironment(),
 		Minio:    mn,
+		Rules:    GetRules(),
+		Paging:   GetPaging(),
+		Cache:    GetCache(),
+		Exports:  GetExports(),
+		Alerts:   GetAlerts(),
 	}
 }
 
@@ -93,3 +104,64 @@ func GetMinio() (*MinioConfig, error) {
 		UseSSL:          minioSsl,
 	}, nil
 }
+
+func GetPaging() *PagingConfig {
+	// Ноль означает размер по умолчанию, его подставляет person.NewService
+	defaultLimit, _ := strconv.Atoi(os.Getenv("PERSONS_PAGE_SIZE"))
+	maxLimit, _ := strconv.Atoi(os.Getenv("PERSONS_MAX_PAGE_SIZE"))
+	return &PagingConfig{
+		DefaultLimit: defaultLimit,
+		MaxLimit:     maxLimit,
+	}
+}
+
+func GetRules() *RulesConfig {
+	interval, err := time.ParseDuration(os.Getenv("RULES_RELOAD_INTERVAL"))
+	if err != nil {
+		interval = time.Minute
+	}
+	return &RulesConfig{
+		File:           os.Getenv("RULES_FILE"),
+		ReloadInterval: interval,
+	}
+}
+
+func GetCache() *CacheConfig {
+	searchTTL, err := time.ParseDuration(os.Getenv("CACHE_SEARCH_TTL"))
+	if err != nil || searchTTL < 0 {
+		searchTTL = time.Minute
+	}
+	personTTL, err := time.ParseDuration(os.Getenv("CACHE_PERSON_TTL"))
+	if err != nil || personTTL < 0 {
+		personTTL = 10 * time.Minute
+	}
+	return &CacheConfig{
+		SearchTTL: searchTTL,
+		PersonTTL: personTTL,
+	}
+}
+
+func GetExports() *ExportsConfig {
+	interval, err := time.ParseDuration(os.Getenv("EXPORTS_INTERVAL"))
+	if err != nil || interval < 0 {
+		interval = time.Minute
+	}
+	return &ExportsConfig{
+		Interval: interval,
+	}
+}
+
+func GetAlerts() *AlertsConfig {
+	interval, err := time.ParseDuration(os.Getenv("ALERTS_INTERVAL"))
+	if err != nil || interval < 0 {
+		interval = time.Minute
+	}
+	timeout, err := time.ParseDuration(os.Getenv("ALERTS_WEBHOOK_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &AlertsConfig{
+		Interval:       interval,
+		WebhookTimeout: timeout,
+	}
+}
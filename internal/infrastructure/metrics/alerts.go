@@ -0,0 +1,28 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	alertsCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "app_alerts_created_total",
+			Help: "Records that matched a saved search",
+		},
+	)
+
+	alertWebhooks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app_alert_webhooks_total",
+			Help: "Alert webhook calls by result: delivered, retry or failed",
+		},
+		[]string{"result"},
+	)
+)
+
+func AlertsCreated(n int) {
+	alertsCreated.Add(float64(n))
+}
+
+func AlertWebhook(result string) {
+	alertWebhooks.WithLabelValues(result).Inc()
+}
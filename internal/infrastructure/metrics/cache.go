@@ -0,0 +1,37 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	cacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app_cache_requests_total",
+			Help: "Cache lookups by cache and result: hit, miss or error",
+		},
+		[]string{"cache", "result"},
+	)
+
+	cacheInvalidated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app_cache_invalidated_keys_total",
+			Help: "Cache entries dropped by tag invalidation",
+		},
+		[]string{"cache"},
+	)
+)
+
+func CacheHit(cache string) {
+	cacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+func CacheMiss(cache string) {
+	cacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+func CacheError(cache string) {
+	cacheRequests.WithLabelValues(cache, "error").Inc()
+}
+
+func CacheInvalidated(cache string, keys int) {
+	cacheInvalidated.WithLabelValues(cache).Add(float64(keys))
+}
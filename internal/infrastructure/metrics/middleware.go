@@ -33,6 +33,13 @@ func NewPrometheus() *Prometheus {
 		metrics: []prometheus.Collector{
 			requestCounter,
 			requestDuration,
+			qualityFillRate,
+			qualityValidRate,
+			qualityScore,
+			cacheRequests,
+			cacheInvalidated,
+			alertsCreated,
+			alertWebhooks,
 		},
 	}
 }
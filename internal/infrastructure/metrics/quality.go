@@ -0,0 +1,45 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	qualityFillRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "person_quality_fill_rate_percent",
+			Help: "Share of person records with a non-empty field",
+		},
+		[]string{"batch", "field"},
+	)
+
+	qualityValidRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "person_quality_valid_rate_percent",
+			Help: "Share of non-empty field values that are valid",
+		},
+		[]string{"batch", "field"},
+	)
+
+	qualityScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "person_quality_score",
+			Help: "Average quality score of person records",
+		},
+		[]string{"batch"},
+	)
+)
+
+// ResetQuality drops the gauges of batches that are no longer reported
+func ResetQuality() {
+	qualityFillRate.Reset()
+	qualityValidRate.Reset()
+	qualityScore.Reset()
+}
+
+func SetQualityScore(batch string, score float64) {
+	qualityScore.WithLabelValues(batch).Set(score)
+}
+
+func SetFieldQuality(batch, field string, filled, valid float64) {
+	qualityFillRate.WithLabelValues(batch, field).Set(filled)
+	qualityValidRate.WithLabelValues(batch, field).Set(valid)
+}
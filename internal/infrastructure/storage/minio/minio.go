@@ -6,6 +6,7 @@ import (
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"service/internal/infrastructure/config"
 	"time"
 
@@ -108,3 +109,26 @@ func (m *Minio) GenerateResignedURL(objectName string) (string, error) {
 	}
 	return url.String(), nil
 }
+
+// UploadStream загружает объект неизвестного заранее размера частями по 16 МБ.
+// Бакет создается без публичной политики: выгрузки содержат персональные данные
+func (m *Minio) UploadStream(ctx context.Context, bucketName, objectName, contentType string, reader io.Reader) (int64, error) {
+	exists, err := m.Client.BucketExists(ctx, bucketName)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка проверки существования бакета: %v", err)
+	}
+	if !exists {
+		if err := m.Client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{}); err != nil {
+			return 0, fmt.Errorf("не удалось создать бакет: %v", err)
+		}
+	}
+
+	info, err := m.Client.PutObject(ctx, bucketName, objectName, reader, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    16 << 20,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка загрузки файла: %v", err)
+	}
+	return info.Size, nil
+}
@@ -0,0 +1,212 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+	"service/internal/infrastructure/metrics"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockTimeout bounds how long the other instances wait for the one filling an entry
+	lockTimeout = 5 * time.Second
+	// lockPoll is how often a waiting instance checks whether the entry is filled
+	lockPoll = 50 * time.Millisecond
+	// invalidateChunk bounds the keys deleted by one DEL command
+	invalidateChunk = 1000
+)
+
+// Cache keeps JSON values in Redis. Entries carry tags, and Invalidate drops every entry of a tag:
+// a search result is tagged with what it depends on, and the writers invalidate what they touched.
+// A missing entry is loaded once: concurrent requests of the instance share the load (singleflight)
+// and the other instances wait on a Redis lock instead of querying the database too.
+// A nil Cache or a Cache without a client loads every time
+type Cache struct {
+	client *redis.Client
+	name   string
+	tagTTL time.Duration
+	group  singleflight.Group
+}
+
+// NewCache creates a cache with keys under the name. tagTTL must cover the longest entry TTL
+func NewCache(rdb *RDB, name string, tagTTL time.Duration) *Cache {
+	var client *redis.Client
+	if rdb != nil {
+		client = rdb.Client
+	}
+	return &Cache{client: client, name: name, tagTTL: tagTTL}
+}
+
+// Key builds an entry key from a kind and the parameters of the request
+func Key(kind string, params ...interface{}) string {
+	raw, _ := json.Marshal(params)
+	sum := sha256.Sum256(raw)
+	return kind + ":" + hex.EncodeToString(sum[:16])
+}
+
+func (c *Cache) key(parts ...string) string {
+	key := c.name
+	for _, part := range parts {
+		key += ":" + part
+	}
+	return key
+}
+
+// Fetch reads the entry into dest or, on a miss, fills dest with load and stores it for ttl under the
+// tags load returns. A zero ttl disables caching of the entry. Redis errors never fail the request:
+// the value is loaded from the source instead
+func (c *Cache) Fetch(ctx context.Context, key string, ttl time.Duration, dest interface{}, load func() ([]string, error)) error {
+	if c == nil || c.client == nil || ttl <= 0 {
+		_, err := load()
+		return err
+	}
+
+	if ok := c.read(ctx, key, dest); ok {
+		return nil
+	}
+
+	raw, err, _ := c.group.Do(key, func() (interface{}, error) {
+		return c.fill(ctx, key, ttl, dest, load)
+	})
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		// Значение загрузил другой запрос этого экземпляра
+		return json.Unmarshal(raw.([]byte), dest)
+	}
+	return nil
+}
+
+// read reports a hit and decodes the entry
+func (c *Cache) read(ctx context.Context, key string, dest interface{}) bool {
+	raw, err := c.client.Get(ctx, c.key("entry", key)).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		return false
+	case err != nil:
+		metrics.CacheError(c.name)
+		log.Warnf("Cache %s: failed to read %s: %v", c.name, key, err)
+		return false
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		metrics.CacheError(c.name)
+		return false
+	}
+	metrics.CacheHit(c.name)
+	return true
+}
+
+// fill loads a missing entry under the lock and returns its JSON for the requests that shared the load
+func (c *Cache) fill(ctx context.Context, key string, ttl time.Duration, dest interface{}, load func() ([]string, error)) ([]byte, error) {
+	lock := c.key("lock", key)
+	locked, err := c.client.SetNX(ctx, lock, 1, lockTimeout).Result()
+	if err != nil {
+		metrics.CacheError(c.name)
+		log.Warnf("Cache %s: failed to lock %s: %v", c.name, key, err)
+	}
+
+	if err == nil && !locked {
+		// Запись заполняет другой экземпляр: ждем ее, а не идем в базу
+		deadline := time.Now().Add(lockTimeout)
+		for time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(lockPoll):
+			}
+			if c.read(ctx, key, dest) {
+				return json.Marshal(dest)
+			}
+		}
+	}
+	if locked {
+		defer c.client.Del(context.WithoutCancel(ctx), lock)
+	}
+
+	metrics.CacheMiss(c.name)
+	// Запись, загруженная до инвалидации, не должна пережить ее
+	epoch, _ := c.client.Get(ctx, c.key("epoch")).Int64()
+	tags, err := load()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if current, _ := c.client.Get(ctx, c.key("epoch")).Int64(); current == epoch {
+		c.store(ctx, key, raw, ttl, tags)
+	}
+	return raw, nil
+}
+
+// store writes the entry and adds it to the tag sets. A tag set is a sorted set scored by the expiry
+// of its entries: the expired ones are pruned on every write, so a tag that is rarely invalidated,
+// like the one of all searches, does not grow without bound
+func (c *Cache) store(ctx context.Context, key string, raw []byte, ttl time.Duration, tags []string) {
+	entry := c.key("entry", key)
+	now := time.Now()
+	expired := strconv.FormatInt(now.Unix(), 10)
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, entry, raw, ttl)
+		for _, tag := range tags {
+			set := c.key("tag", tag)
+			pipe.ZRemRangeByScore(ctx, set, "-inf", expired)
+			pipe.ZAdd(ctx, set, redis.Z{Score: float64(now.Add(ttl).Unix()), Member: entry})
+			pipe.Expire(ctx, set, c.tagTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		metrics.CacheError(c.name)
+		log.Warnf("Cache %s: failed to store %s: %v", c.name, key, err)
+	}
+}
+
+// Invalidate drops the entries of the tags
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	if c == nil || c.client == nil || len(tags) == 0 {
+		return nil
+	}
+	if err := c.client.Incr(ctx, c.key("epoch")).Err(); err != nil {
+		return fmt.Errorf("failed to advance cache epoch: %w", err)
+	}
+
+	// Теги читаются одним конвейером: после пересчета мастер-записей их могут быть тысячи
+	members := make([]*redis.StringSliceCmd, len(tags))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, tag := range tags {
+			members[i] = pipe.ZRange(ctx, c.key("tag", tag), 0, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read cache tags: %w", err)
+	}
+
+	keys := make([]string, 0, len(tags))
+	dropped := 0
+	for i, tag := range tags {
+		entries := members[i].Val()
+		keys = append(keys, c.key("tag", tag))
+		keys = append(keys, entries...)
+		dropped += len(entries)
+	}
+	for start := 0; start < len(keys); start += invalidateChunk {
+		end := min(start+invalidateChunk, len(keys))
+		if err := c.client.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache tags: %w", err)
+		}
+	}
+	metrics.CacheInvalidated(c.name, dropped)
+	return nil
+}
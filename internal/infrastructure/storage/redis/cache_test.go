@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("find", "fio", "Иванов"), Key("find", "fio", "Иванов"))
+	assert.NotEqual(t, Key("find", "fio", "Иванов"), Key("find", "fio", "Петров"))
+	assert.NotEqual(t, Key("find", "fio", "Иванов"), Key("list", "fio", "Иванов"))
+}
+
+func TestCacheWithoutRedis(t *testing.T) {
+	// Без клиента кэш каждый раз загружает значение из источника
+	cache := NewCache(&RDB{}, "test", time.Minute)
+	loads := 0
+	for i := 0; i < 2; i++ {
+		var value int
+		err := cache.Fetch(context.Background(), "key", time.Minute, &value, func() ([]string, error) {
+			loads++
+			value = 42
+			return []string{"tag"}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, value)
+	}
+	assert.Equal(t, 2, loads)
+	assert.NoError(t, cache.Invalidate(context.Background(), "tag"))
+}
@@ -0,0 +1,2 @@
+ALTER TABLE import_batches
+    ADD COLUMN rolled_back_at TIMESTAMPTZ;
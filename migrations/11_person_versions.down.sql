@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS person_versions;
+
+DROP INDEX IF EXISTS idx_persons_created_at;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS created_at;
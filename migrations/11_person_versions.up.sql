@@ -0,0 +1,30 @@
+ALTER TABLE persons
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Время загрузки существующих записей берем из их пакетов
+UPDATE persons p
+SET created_at = b.created_at
+FROM import_batches b
+WHERE b.id = p.import_batch_id;
+
+CREATE INDEX idx_persons_created_at ON persons (created_at);
+
+CREATE TABLE person_versions (
+    id         BIGSERIAL PRIMARY KEY,
+    master_id  INT         NOT NULL,
+    field      TEXT        NOT NULL,
+    value      TEXT        NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL DEFAULT now(),
+    valid_to   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_person_versions_master ON person_versions (master_id, field, valid_from);
+CREATE UNIQUE INDEX idx_person_versions_current ON person_versions (master_id, field) WHERE valid_to IS NULL;
+
+INSERT INTO person_versions (master_id, field, value, valid_from)
+SELECT m.id, f.key, f.value, m.updated_at
+FROM person_masters m,
+     jsonb_each_text(m.golden) f
+WHERE f.key IN ('fio', 'surname', 'first_name', 'patronymic', 'gender', 'phone', 'snils', 'inn', 'passport',
+                'birth_date', 'address')
+  AND f.value <> '';
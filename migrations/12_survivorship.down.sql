@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS survivorship_rules;
+
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS trust;
@@ -0,0 +1,8 @@
+ALTER TABLE sources
+    ADD COLUMN trust INT NOT NULL DEFAULT 50 CHECK (trust BETWEEN 0 AND 100);
+
+CREATE TABLE survivorship_rules (
+    field      TEXT PRIMARY KEY,
+    strategy   TEXT        NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS person_documents;
+DROP TABLE IF EXISTS person_phones;
+
+ALTER TABLE person_addresses
+    DROP COLUMN IF EXISTS source_id,
+    DROP COLUMN IF EXISTS valid_to,
+    DROP COLUMN IF EXISTS valid_from,
+    DROP COLUMN IF EXISTS is_primary,
+    DROP COLUMN IF EXISTS kind,
+    DROP COLUMN IF EXISTS raw;
@@ -0,0 +1,74 @@
+CREATE TABLE person_phones (
+    id         SERIAL PRIMARY KEY,
+    person_id  INT     NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    value      TEXT    NOT NULL,
+    raw        TEXT,
+    kind       TEXT,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    valid_from DATE,
+    valid_to   DATE,
+    source_id  TEXT REFERENCES sources (id)
+);
+
+CREATE INDEX idx_person_phones_person_id ON person_phones (person_id);
+CREATE INDEX idx_person_phones_value ON person_phones (value);
+
+CREATE TABLE person_documents (
+    id         SERIAL PRIMARY KEY,
+    person_id  INT     NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    kind       TEXT    NOT NULL,
+    number     TEXT    NOT NULL,
+    raw        TEXT,
+    is_primary BOOLEAN NOT NULL DEFAULT false,
+    valid_from DATE,
+    valid_to   DATE,
+    source_id  TEXT REFERENCES sources (id)
+);
+
+CREATE INDEX idx_person_documents_person_id ON person_documents (person_id);
+CREATE INDEX idx_person_documents_number ON person_documents (kind, number);
+
+ALTER TABLE person_addresses
+    ADD COLUMN raw        TEXT,
+    ADD COLUMN kind       TEXT,
+    ADD COLUMN is_primary BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN valid_from DATE,
+    ADD COLUMN valid_to   DATE,
+    ADD COLUMN source_id  TEXT REFERENCES sources (id);
+
+-- Существующие записи: значения плоских полей становятся основными контактами
+INSERT INTO person_phones (person_id, value, raw, kind, is_primary, source_id)
+SELECT id,
+       CASE
+           WHEN length(digits) = 11 AND left(digits, 1) IN ('7', '8') THEN '+7' || substr(digits, 2)
+           WHEN length(digits) = 10 THEN '+7' || digits
+           ELSE phone
+       END,
+       phone,
+       CASE
+           WHEN (length(digits) = 11 AND substr(digits, 2, 1) = '9') OR (length(digits) = 10 AND left(digits, 1) = '9') THEN 'mobile'
+           WHEN length(digits) IN (10, 11) THEN 'landline'
+       END,
+       true,
+       source_id
+FROM (SELECT id, phone, source_id, regexp_replace(phone, '\D', '', 'g') AS digits
+      FROM persons
+      WHERE COALESCE(phone, '') <> '') p;
+
+INSERT INTO person_documents (person_id, kind, number, raw, is_primary, source_id)
+SELECT id, 'passport', passport, passport, true, source_id FROM persons WHERE COALESCE(passport, '') <> ''
+UNION ALL
+SELECT id, 'snils', snils, snils, true, source_id FROM persons WHERE COALESCE(snils, '') <> ''
+UNION ALL
+SELECT id, 'inn', inn, inn, true, source_id FROM persons WHERE COALESCE(inn, '') <> '';
+
+UPDATE person_addresses a
+SET raw = p.address, is_primary = true, source_id = p.source_id
+FROM persons p
+WHERE p.id = a.person_id;
+
+INSERT INTO person_addresses (person_id, raw, is_primary, source_id)
+SELECT id, address, true, source_id
+FROM persons p
+WHERE COALESCE(address, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM person_addresses a WHERE a.person_id = p.id);
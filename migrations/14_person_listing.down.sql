@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_persons_birth_day;
+DROP INDEX IF EXISTS idx_persons_birth_day_keyset;
+DROP INDEX IF EXISTS idx_persons_created_at_keyset;
+DROP INDEX IF EXISTS idx_persons_name_keyset;
+
+ALTER TABLE persons DROP COLUMN IF EXISTS birth_day;
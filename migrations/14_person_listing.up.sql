@@ -0,0 +1,29 @@
+-- Дата рождения хранится текстом в формате источника; для диапазонов и сортировки нужна дата
+ALTER TABLE persons ADD COLUMN birth_day DATE;
+
+-- Несуществующие даты вроде 31.02.1990 остаются пустыми, а не обрывают миграцию
+CREATE FUNCTION migration_try_date(value TEXT, format TEXT) RETURNS DATE AS $$
+BEGIN
+    RETURN to_date(value, format);
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+UPDATE persons
+SET birth_day = CASE
+    WHEN birth_date ~ '^\d{4}-\d{2}-\d{2}' THEN migration_try_date(substr(birth_date, 1, 10), 'YYYY-MM-DD')
+    WHEN birth_date ~ '^\d{2}\.\d{2}\.\d{4}$' THEN migration_try_date(birth_date, 'DD.MM.YYYY')
+    WHEN birth_date ~ '^\d{2}/\d{2}/\d{4}$' THEN migration_try_date(birth_date, 'DD/MM/YYYY')
+    WHEN birth_date ~ '^\d{2}-\d{2}-\d{4}$' THEN migration_try_date(birth_date, 'DD-MM-YYYY')
+END
+WHERE birth_date IS NOT NULL;
+
+DROP FUNCTION migration_try_date(TEXT, TEXT);
+
+-- Индексы под сортировки постраничного списка (keyset)
+CREATE INDEX idx_persons_name_keyset
+    ON persons (COALESCE(surname, ''), COALESCE(first_name, ''), COALESCE(patronymic, ''), id);
+CREATE INDEX idx_persons_created_at_keyset ON persons (created_at, id);
+CREATE INDEX idx_persons_birth_day_keyset ON persons (COALESCE(birth_day, DATE '0001-01-01'), id);
+CREATE INDEX idx_persons_birth_day ON persons (birth_day);
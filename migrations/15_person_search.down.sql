@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_person_documents_person_kind;
+DROP INDEX IF EXISTS idx_person_phones_person_value;
@@ -0,0 +1,5 @@
+-- similarity() для нечеткого поиска
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_person_phones_person_value ON person_phones (person_id, value);
+CREATE INDEX idx_person_documents_person_kind ON person_documents (person_id, kind);
@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_persons_search_vector;
+ALTER TABLE persons DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_persons_patronymic_norm_trgm;
+DROP INDEX IF EXISTS idx_persons_first_name_norm_trgm;
+DROP INDEX IF EXISTS idx_persons_surname_norm_trgm;
+DROP INDEX IF EXISTS idx_persons_fio_norm_trgm;
+
+DROP INDEX IF EXISTS idx_person_documents_raw_trgm;
+DROP INDEX IF EXISTS idx_person_phones_raw_trgm;
+DROP INDEX IF EXISTS idx_person_addresses_raw_trgm;
+
+DROP INDEX IF EXISTS idx_persons_birth_date_trgm;
+DROP INDEX IF EXISTS idx_persons_passport_trgm;
+DROP INDEX IF EXISTS idx_persons_inn_trgm;
+DROP INDEX IF EXISTS idx_persons_snils_trgm;
+DROP INDEX IF EXISTS idx_persons_phone_trgm;
+DROP INDEX IF EXISTS idx_persons_address_trgm;
+DROP INDEX IF EXISTS idx_persons_patronymic_trgm;
+DROP INDEX IF EXISTS idx_persons_first_name_trgm;
+DROP INDEX IF EXISTS idx_persons_surname_trgm;
+DROP INDEX IF EXISTS idx_persons_fio_trgm;
@@ -0,0 +1,36 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Подстрочный поиск (ILIKE '%x%') и оператор % используют триграммные индексы
+CREATE INDEX idx_persons_fio_trgm ON persons USING GIN (fio gin_trgm_ops);
+CREATE INDEX idx_persons_surname_trgm ON persons USING GIN (surname gin_trgm_ops);
+CREATE INDEX idx_persons_first_name_trgm ON persons USING GIN (first_name gin_trgm_ops);
+CREATE INDEX idx_persons_patronymic_trgm ON persons USING GIN (patronymic gin_trgm_ops);
+CREATE INDEX idx_persons_address_trgm ON persons USING GIN (address gin_trgm_ops);
+CREATE INDEX idx_persons_phone_trgm ON persons USING GIN (phone gin_trgm_ops);
+CREATE INDEX idx_persons_snils_trgm ON persons USING GIN (snils gin_trgm_ops);
+CREATE INDEX idx_persons_inn_trgm ON persons USING GIN (inn gin_trgm_ops);
+CREATE INDEX idx_persons_passport_trgm ON persons USING GIN (passport gin_trgm_ops);
+CREATE INDEX idx_persons_birth_date_trgm ON persons USING GIN (birth_date gin_trgm_ops);
+
+CREATE INDEX idx_person_addresses_raw_trgm ON person_addresses USING GIN (raw gin_trgm_ops);
+CREATE INDEX idx_person_phones_raw_trgm ON person_phones USING GIN (raw gin_trgm_ops);
+CREATE INDEX idx_person_documents_raw_trgm ON person_documents USING GIN (raw gin_trgm_ops);
+
+-- Выражения структурированного поиска (нижний регистр, ё = е)
+CREATE INDEX idx_persons_fio_norm_trgm
+    ON persons USING GIN (lower(replace(COALESCE(fio, ''), 'ё', 'е')) gin_trgm_ops);
+CREATE INDEX idx_persons_surname_norm_trgm
+    ON persons USING GIN (lower(replace(COALESCE(surname, ''), 'ё', 'е')) gin_trgm_ops);
+CREATE INDEX idx_persons_first_name_norm_trgm
+    ON persons USING GIN (lower(replace(COALESCE(first_name, ''), 'ё', 'е')) gin_trgm_ops);
+CREATE INDEX idx_persons_patronymic_norm_trgm
+    ON persons USING GIN (lower(replace(COALESCE(patronymic, ''), 'ё', 'е')) gin_trgm_ops);
+
+-- Полнотекстовый поиск с русской морфологией: ФИО весомее адреса
+ALTER TABLE persons
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', replace(COALESCE(fio, ''), 'ё', 'е')), 'A') ||
+        setweight(to_tsvector('russian', replace(COALESCE(address, ''), 'ё', 'е')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_persons_search_vector ON persons USING GIN (search_vector);
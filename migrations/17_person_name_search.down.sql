@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_persons_name_unindexed;
+DROP INDEX IF EXISTS idx_persons_name_phonetic;
+DROP INDEX IF EXISTS idx_persons_name_canonical_trgm;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS name_phonetic,
+    DROP COLUMN IF EXISTS name_canonical;
@@ -0,0 +1,10 @@
+-- Ключи поиска по имени: канонический вид (кириллица, ё/э = е, й = и, без ъ/ь,
+-- латиница и похожие латинские буквы переведены в кириллицу) и фонетические ключи слов.
+-- Заполняются приложением, для старых записей — при запуске
+ALTER TABLE persons
+    ADD COLUMN name_canonical TEXT,
+    ADD COLUMN name_phonetic TEXT[];
+
+CREATE INDEX idx_persons_name_canonical_trgm ON persons USING GIN (name_canonical gin_trgm_ops);
+CREATE INDEX idx_persons_name_phonetic ON persons USING GIN (name_phonetic);
+CREATE INDEX idx_persons_name_unindexed ON persons (id) WHERE name_canonical IS NULL;
@@ -0,0 +1 @@
+-- Канонические номера не откатываются: исходное написание хранится в raw
@@ -0,0 +1,14 @@
+-- Миграция 13 переносит документы из плоских полей persons как есть.
+-- Приводим номера к каноническому виду импорта (parser.NormalizeIdentifier), исходное написание остается в raw
+UPDATE person_documents d
+SET number = CASE d.kind
+                 WHEN 'passport' THEN left(n.digits, 4) || ' ' || substr(n.digits, 5)
+                 WHEN 'snils' THEN substr(n.digits, 1, 3) || '-' || substr(n.digits, 4, 3) || '-' ||
+                                   substr(n.digits, 7, 3) || ' ' || substr(n.digits, 10)
+                 ELSE n.digits
+             END
+FROM (SELECT id, regexp_replace(number, '\D', '', 'g') AS digits FROM person_documents) n
+WHERE n.id = d.id
+  AND ((d.kind = 'passport' AND length(n.digits) = 10)
+    OR (d.kind = 'snils' AND length(n.digits) = 11)
+    OR (d.kind = 'inn' AND length(n.digits) IN (10, 12)));
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS export_runs;
+DROP TABLE IF EXISTS export_definitions;
+
+DROP TRIGGER IF EXISTS persons_updated_at ON persons;
+DROP FUNCTION IF EXISTS persons_touch_updated_at();
+DROP INDEX IF EXISTS idx_persons_updated_at;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,63 @@
+-- Время последнего изменения записи для выгрузок изменений (дельт).
+-- Перестроение ключей поиска по имени изменением не считается
+ALTER TABLE persons
+    ADD COLUMN updated_at TIMESTAMPTZ;
+
+UPDATE persons SET updated_at = created_at;
+
+ALTER TABLE persons
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT now();
+
+CREATE INDEX idx_persons_updated_at ON persons (updated_at, id);
+
+CREATE FUNCTION persons_touch_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - '{updated_at,name_canonical,name_phonetic,search_vector}'::text[]
+        IS DISTINCT FROM to_jsonb(OLD) - '{updated_at,name_canonical,name_phonetic,search_vector}'::text[] THEN
+        NEW.updated_at = now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER persons_updated_at
+    BEFORE UPDATE ON persons
+    FOR EACH ROW EXECUTE FUNCTION persons_touch_updated_at();
+
+-- Выгрузки по расписанию в MinIO
+CREATE TABLE export_definitions (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT        NOT NULL UNIQUE,
+    query       JSONB,
+    options     JSONB       NOT NULL DEFAULT '{}',
+    schedule    TEXT        NOT NULL,
+    mode        TEXT        NOT NULL DEFAULT 'full' CHECK (mode IN ('full', 'delta')),
+    bucket      TEXT        NOT NULL,
+    prefix      TEXT        NOT NULL DEFAULT '',
+    enabled     BOOLEAN     NOT NULL DEFAULT true,
+    next_run_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_export_definitions_due ON export_definitions (next_run_at) WHERE enabled;
+
+CREATE TABLE export_runs (
+    id          SERIAL PRIMARY KEY,
+    export_id   INT         NOT NULL REFERENCES export_definitions (id) ON DELETE CASCADE,
+    mode        TEXT        NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    since       TIMESTAMPTZ,
+    until       TIMESTAMPTZ NOT NULL,
+    rows        INT         NOT NULL DEFAULT 0,
+    size        BIGINT      NOT NULL DEFAULT 0,
+    checksum    TEXT        NOT NULL DEFAULT '',
+    object      TEXT        NOT NULL DEFAULT '',
+    manifest    TEXT        NOT NULL DEFAULT '',
+    error       TEXT        NOT NULL DEFAULT '',
+    started_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_export_runs_export_id ON export_runs (export_id, id DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_persons_name;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS surname,
+    DROP COLUMN IF EXISTS first_name,
+    DROP COLUMN IF EXISTS patronymic,
+    DROP COLUMN IF EXISTS gender;
@@ -0,0 +1,7 @@
+ALTER TABLE persons
+    ADD COLUMN surname    TEXT,
+    ADD COLUMN first_name TEXT,
+    ADD COLUMN patronymic TEXT,
+    ADD COLUMN gender     TEXT;
+
+CREATE INDEX idx_persons_name ON persons (surname, first_name, patronymic);
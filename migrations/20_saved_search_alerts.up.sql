@@ -0,0 +1,38 @@
+-- Сохраненные поиски: запрос (query.Node) проверяется на новых и измененных записях после каждого импорта.
+-- checked_until - до какого момента изменений поиск уже проверен
+CREATE TABLE saved_searches (
+    id             SERIAL PRIMARY KEY,
+    name           TEXT        NOT NULL,
+    owner          TEXT        NOT NULL,
+    query          JSONB       NOT NULL,
+    webhook_url    TEXT        NOT NULL DEFAULT '',
+    webhook_secret TEXT        NOT NULL DEFAULT '',
+    enabled        BOOLEAN     NOT NULL DEFAULT true,
+    muted_until    TIMESTAMPTZ,
+    checked_until  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_saved_searches_owner ON saved_searches (owner);
+
+-- Одна запись попадает в поиск один раз, повторная проверка тех же изменений ничего не добавляет
+CREATE TABLE alerts (
+    id              SERIAL PRIMARY KEY,
+    saved_search_id INT         NOT NULL REFERENCES saved_searches (id) ON DELETE CASCADE,
+    person_id       INT         NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    import_batch_id UUID,
+    status          TEXT        NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'acknowledged', 'muted')),
+    delivery        TEXT        NOT NULL DEFAULT 'pending' CHECK (delivery IN ('pending', 'delivered', 'failed', 'skipped')),
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT        NOT NULL DEFAULT '',
+    delivered_at    TIMESTAMPTZ,
+    acknowledged_at TIMESTAMPTZ,
+    acknowledged_by TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (saved_search_id, person_id)
+);
+
+CREATE INDEX idx_alerts_status ON alerts (status, id DESC);
+CREATE INDEX idx_alerts_delivery ON alerts (next_attempt_at) WHERE delivery = 'pending';
@@ -0,0 +1,2 @@
+ALTER TABLE import_batches
+    DROP COLUMN IF EXISTS duplicates;
@@ -0,0 +1,2 @@
+ALTER TABLE import_batches
+    ADD COLUMN duplicates INT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_person_versions_merged_into;
+
+ALTER TABLE person_versions
+    DROP COLUMN IF EXISTS merged_into;
@@ -0,0 +1,5 @@
+-- История поглощенной мастер-записи остается под ее id со ссылкой на мастер, в который она влита
+ALTER TABLE person_versions
+    ADD COLUMN merged_into INT;
+
+CREATE INDEX idx_person_versions_merged_into ON person_versions (merged_into) WHERE merged_into IS NOT NULL;
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_persons_phone_trgm ON persons USING GIN (phone gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_persons_snils_trgm ON persons USING GIN (snils gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_persons_inn_trgm ON persons USING GIN (inn gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_persons_passport_trgm ON persons USING GIN (passport gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_persons_birth_date_trgm ON persons USING GIN (birth_date gin_trgm_ops);
@@ -0,0 +1,8 @@
+-- Текстовый поиск идет только по ФИО и адресу (query.textColumns), идентификаторы ищутся по нормализованным
+-- значениям в person_phones и person_documents, дата рождения - по birth_day.
+-- Триграммные индексы плоских полей только замедляли импорт
+DROP INDEX IF EXISTS idx_persons_phone_trgm;
+DROP INDEX IF EXISTS idx_persons_snils_trgm;
+DROP INDEX IF EXISTS idx_persons_inn_trgm;
+DROP INDEX IF EXISTS idx_persons_passport_trgm;
+DROP INDEX IF EXISTS idx_persons_birth_date_trgm;
@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS persons_deletions ON persons;
+DROP FUNCTION IF EXISTS persons_record_deletions();
+DROP TABLE IF EXISTS person_deletions;
+
+DROP TRIGGER IF EXISTS person_addresses_delete_touch ON person_addresses;
+DROP TRIGGER IF EXISTS person_addresses_update_touch ON person_addresses;
+DROP TRIGGER IF EXISTS person_addresses_insert_touch ON person_addresses;
+DROP TRIGGER IF EXISTS person_documents_delete_touch ON person_documents;
+DROP TRIGGER IF EXISTS person_documents_update_touch ON person_documents;
+DROP TRIGGER IF EXISTS person_documents_insert_touch ON person_documents;
+DROP TRIGGER IF EXISTS person_phones_delete_touch ON person_phones;
+DROP TRIGGER IF EXISTS person_phones_update_touch ON person_phones;
+DROP TRIGGER IF EXISTS person_phones_insert_touch ON person_phones;
+DROP FUNCTION IF EXISTS persons_touch_from_contacts();
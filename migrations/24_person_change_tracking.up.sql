@@ -0,0 +1,61 @@
+-- Изменение телефонов, документов и адресов - изменение записи для дельт и оповещений.
+-- Триггеры уровня оператора: пакет контактов обновляет запись один раз, а записи, уже отмеченные
+-- в этой транзакции (например, только что вставленные), не трогаются
+CREATE FUNCTION persons_touch_from_contacts() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE persons
+    SET updated_at = now()
+    WHERE id IN (SELECT DISTINCT person_id FROM changed)
+      AND updated_at < now();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER person_phones_insert_touch
+    AFTER INSERT ON person_phones REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_phones_update_touch
+    AFTER UPDATE ON person_phones REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_phones_delete_touch
+    AFTER DELETE ON person_phones REFERENCING OLD TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+
+CREATE TRIGGER person_documents_insert_touch
+    AFTER INSERT ON person_documents REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_documents_update_touch
+    AFTER UPDATE ON person_documents REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_documents_delete_touch
+    AFTER DELETE ON person_documents REFERENCING OLD TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+
+CREATE TRIGGER person_addresses_insert_touch
+    AFTER INSERT ON person_addresses REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_addresses_update_touch
+    AFTER UPDATE ON person_addresses REFERENCING NEW TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+CREATE TRIGGER person_addresses_delete_touch
+    AFTER DELETE ON person_addresses REFERENCING OLD TABLE AS changed
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_touch_from_contacts();
+
+-- Удаленные записи (откат пакета и т.п.) попадают в дельты из этой таблицы
+CREATE TABLE person_deletions (
+    person_id  INT         NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_person_deletions_deleted_at ON person_deletions (deleted_at, person_id);
+
+CREATE FUNCTION persons_record_deletions() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO person_deletions (person_id) SELECT id FROM deleted;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER persons_deletions
+    AFTER DELETE ON persons REFERENCING OLD TABLE AS deleted
+    FOR EACH STATEMENT EXECUTE FUNCTION persons_record_deletions();
@@ -0,0 +1,6 @@
+-- watch_from - с какого момента поиск следит за изменениями (создание или включение).
+-- Перекрытие окна проверки не заходит раньше него, иначе новый поиск получил бы изменения до своего создания
+ALTER TABLE saved_searches
+    ADD COLUMN watch_from TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE saved_searches SET watch_from = created_at;
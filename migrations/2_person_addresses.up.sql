@@ -0,0 +1,21 @@
+CREATE TABLE person_addresses (
+    id              SERIAL PRIMARY KEY,
+    person_id       INT NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    postal_code     TEXT,
+    region          TEXT,
+    district        TEXT,
+    settlement_type TEXT,
+    settlement      TEXT,
+    street_type     TEXT,
+    street          TEXT,
+    house           TEXT,
+    building        TEXT,
+    structure       TEXT,
+    flat            TEXT
+);
+
+CREATE INDEX idx_person_addresses_person_id ON person_addresses (person_id);
+CREATE INDEX idx_person_addresses_postal_code ON person_addresses (postal_code);
+CREATE INDEX idx_person_addresses_settlement ON person_addresses (lower(settlement));
+CREATE INDEX idx_person_addresses_street ON person_addresses (lower(street));
+CREATE INDEX idx_person_addresses_region ON person_addresses (lower(region));
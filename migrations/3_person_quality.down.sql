@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_persons_import_batch_id;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS import_batch_id,
+    DROP COLUMN IF EXISTS quality_score,
+    DROP COLUMN IF EXISTS quality_issues;
@@ -0,0 +1,6 @@
+ALTER TABLE persons
+    ADD COLUMN import_batch_id UUID,
+    ADD COLUMN quality_score   REAL,
+    ADD COLUMN quality_issues  JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_persons_import_batch_id ON persons (import_batch_id);
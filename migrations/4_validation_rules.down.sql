@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS quarantined_persons;
+DROP TABLE IF EXISTS validation_rules;
@@ -0,0 +1,17 @@
+CREATE TABLE validation_rules (
+    name       TEXT PRIMARY KEY,
+    definition JSONB       NOT NULL,
+    enabled    BOOLEAN     NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE quarantined_persons (
+    id              SERIAL PRIMARY KEY,
+    import_batch_id UUID,
+    row_number      INT,
+    data            JSONB       NOT NULL,
+    violations      JSONB       NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_quarantined_persons_import_batch_id ON quarantined_persons (import_batch_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_persons_attributes;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS attributes;
@@ -0,0 +1,4 @@
+ALTER TABLE persons
+    ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_persons_attributes ON persons USING GIN (attributes jsonb_path_ops);
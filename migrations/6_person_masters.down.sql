@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS person_match_keys;
+
+DROP INDEX IF EXISTS idx_persons_master_id;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS master_id;
+
+DROP TABLE IF EXISTS person_masters;
@@ -0,0 +1,59 @@
+CREATE TABLE person_masters (
+    id           SERIAL PRIMARY KEY,
+    golden       JSONB       NOT NULL DEFAULT '{}',
+    member_count INT         NOT NULL DEFAULT 0,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE persons
+    ADD COLUMN master_id INT REFERENCES person_masters (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_persons_master_id ON persons (master_id);
+
+CREATE TABLE person_match_keys (
+    person_id INT  NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    kind      TEXT NOT NULL,
+    value     TEXT NOT NULL,
+    PRIMARY KEY (person_id, kind, value)
+);
+
+CREATE INDEX idx_person_match_keys_value ON person_match_keys (kind, value);
+
+-- Существующие записи становятся отдельными мастер-записями
+INSERT INTO person_masters (id, golden, member_count)
+SELECT id,
+       jsonb_build_object('fio', COALESCE(fio, ''), 'surname', COALESCE(surname, ''), 'first_name', COALESCE(first_name, ''),
+                          'patronymic', COALESCE(patronymic, ''), 'gender', COALESCE(gender, ''), 'phone', COALESCE(phone, ''),
+                          'snils', COALESCE(snils, ''), 'inn', COALESCE(inn, ''), 'passport', COALESCE(passport, ''),
+                          'birth_date', COALESCE(birth_date, ''), 'address', COALESCE(address, ''), 'attributes', attributes),
+       1
+FROM persons;
+
+UPDATE persons SET master_id = id;
+
+SELECT setval('person_masters_id_seq', COALESCE((SELECT max(id) FROM person_masters), 0) + 1, false);
+
+-- Ключи идентификаторов для существующих записей; контрольные суммы проверяются при сравнении
+INSERT INTO person_match_keys (person_id, kind, value)
+SELECT id, 'snils', substr(d, 1, 3) || '-' || substr(d, 4, 3) || '-' || substr(d, 7, 3) || ' ' || substr(d, 10, 2)
+FROM (SELECT id, regexp_replace(snils, '\D', '', 'g') AS d FROM persons) s
+WHERE length(d) = 11
+ON CONFLICT DO NOTHING;
+
+INSERT INTO person_match_keys (person_id, kind, value)
+SELECT id, 'inn', d
+FROM (SELECT id, regexp_replace(inn, '\D', '', 'g') AS d FROM persons) s
+WHERE length(d) = 12
+ON CONFLICT DO NOTHING;
+
+INSERT INTO person_match_keys (person_id, kind, value)
+SELECT id, 'passport', substr(d, 1, 4) || ' ' || substr(d, 5)
+FROM (SELECT id, regexp_replace(passport, '\D', '', 'g') AS d FROM persons) s
+WHERE length(d) = 10
+ON CONFLICT DO NOTHING;
+
+INSERT INTO person_match_keys (person_id, kind, value)
+SELECT id, 'phone', '+7' || right(d, 10)
+FROM (SELECT id, regexp_replace(phone, '\D', '', 'g') AS d FROM persons) s
+WHERE length(d) = 10 OR (length(d) = 11 AND left(d, 1) IN ('7', '8'))
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,17 @@
+CREATE TABLE match_reviews (
+    id         SERIAL PRIMARY KEY,
+    left_id    INT         NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    right_id   INT         NOT NULL REFERENCES persons (id) ON DELETE CASCADE,
+    score      REAL        NOT NULL,
+    reasons    JSONB       NOT NULL DEFAULT '[]',
+    status     TEXT        NOT NULL DEFAULT 'pending',
+    operator   TEXT,
+    comment    TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ,
+    CONSTRAINT match_reviews_pair UNIQUE (left_id, right_id),
+    CONSTRAINT match_reviews_order CHECK (left_id < right_id)
+);
+
+CREATE INDEX idx_match_reviews_status ON match_reviews (status, score DESC);
+CREATE INDEX idx_match_reviews_right_id ON match_reviews (right_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS person_merge_audit;
+
+ALTER TABLE person_masters
+    DROP COLUMN IF EXISTS overrides;
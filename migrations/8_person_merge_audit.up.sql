@@ -0,0 +1,17 @@
+ALTER TABLE person_masters
+    ADD COLUMN overrides JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE person_merge_audit (
+    id         SERIAL PRIMARY KEY,
+    action     TEXT        NOT NULL,
+    operator   TEXT        NOT NULL,
+    reason     TEXT,
+    master_id  INT         NOT NULL,
+    person_ids JSONB       NOT NULL DEFAULT '[]',
+    before     JSONB       NOT NULL,
+    after      JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    undone_by  INT REFERENCES person_merge_audit (id)
+);
+
+CREATE INDEX idx_person_merge_audit_master_id ON person_merge_audit (master_id, id DESC);
@@ -0,0 +1,13 @@
+ALTER TABLE persons
+    DROP CONSTRAINT IF EXISTS persons_import_batch_id_fkey;
+
+DROP INDEX IF EXISTS idx_persons_source_id;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS source_id,
+    DROP COLUMN IF EXISTS source_file,
+    DROP COLUMN IF EXISTS source_row,
+    DROP COLUMN IF EXISTS raw_row;
+
+DROP TABLE IF EXISTS import_batches;
+DROP TABLE IF EXISTS sources;
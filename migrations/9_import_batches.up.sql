@@ -0,0 +1,52 @@
+CREATE TABLE sources (
+    id         TEXT PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE import_batches (
+    id          UUID PRIMARY KEY,
+    source_id   TEXT        NOT NULL REFERENCES sources (id),
+    file_name   TEXT,
+    format      TEXT,
+    status      TEXT        NOT NULL DEFAULT 'running',
+    row_count   INT         NOT NULL DEFAULT 0,
+    saved       INT         NOT NULL DEFAULT 0,
+    merged      INT         NOT NULL DEFAULT 0,
+    rejected    INT         NOT NULL DEFAULT 0,
+    quarantined INT         NOT NULL DEFAULT 0,
+    warnings    INT         NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_import_batches_created_at ON import_batches (created_at DESC);
+CREATE INDEX idx_import_batches_source_id ON import_batches (source_id, created_at DESC);
+
+ALTER TABLE persons
+    ADD COLUMN source_id   TEXT REFERENCES sources (id),
+    ADD COLUMN source_file TEXT,
+    ADD COLUMN source_row  INT,
+    ADD COLUMN raw_row     JSONB;
+
+CREATE INDEX idx_persons_source_id ON persons (source_id);
+
+-- Записи, загруженные до учета источников
+INSERT INTO sources (id, name) VALUES ('legacy', 'Записи до учета источников');
+
+INSERT INTO import_batches (id, source_id, status, row_count, saved, finished_at)
+SELECT import_batch_id, 'legacy', 'completed', count(*), count(*), now()
+FROM persons
+WHERE import_batch_id IS NOT NULL
+GROUP BY import_batch_id;
+
+INSERT INTO import_batches (id, source_id, status, finished_at)
+SELECT DISTINCT import_batch_id, 'legacy', 'completed', now()
+FROM quarantined_persons
+WHERE import_batch_id IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+UPDATE persons SET source_id = 'legacy';
+
+ALTER TABLE persons
+    ADD CONSTRAINT persons_import_batch_id_fkey FOREIGN KEY (import_batch_id) REFERENCES import_batches (id);